@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// APIKeys authenticates the API keys of the clients against the SHA-256 hashes of the issued keys,
+// so the keys themselves are not kept by the service.
+type APIKeys struct {
+	hashes map[[sha256.Size]byte]bool
+}
+
+// LoadAPIKeys reads the hex encoded SHA-256 hashes of the issued keys from a file, one per line.
+// The empty lines and the lines starting with # are skipped.
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read the API keys")
+	}
+	defer file.Close()
+
+	keys := &APIKeys{hashes: make(map[[sha256.Size]byte]bool)}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var hash [sha256.Size]byte
+		if len(text) != hex.EncodedLen(sha256.Size) {
+			return nil, errors.Errorf("invalid SHA-256 hash on line %d of %s", line, path)
+		}
+		if _, err := hex.Decode(hash[:], []byte(text)); err != nil {
+			return nil, errors.Errorf("invalid SHA-256 hash on line %d of %s", line, path)
+		}
+		keys.hashes[hash] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "cannot read the API keys")
+	}
+	return keys, nil
+}
+
+// Authenticate tells whether key is one of the issued keys.
+func (k *APIKeys) Authenticate(key string) bool {
+	if key == "" {
+		return false
+	}
+	return k.hashes[sha256.Sum256([]byte(key))]
+}
@@ -0,0 +1,41 @@
+package auth
+
+import "crypto/x509"
+
+// The attributes of a certificate subject an identity can be taken from.
+const (
+	SubjectCommonName         = "CN"
+	SubjectOrganization       = "O"
+	SubjectOrganizationalUnit = "OU"
+)
+
+// CertificateIdentity maps the subject of a verified client certificate to an identity,
+// the tenant and the owner are the values of the given subject attributes.
+// The certificate without a tenant attribute belongs to the default tenant.
+func CertificateIdentity(cert *x509.Certificate, tenantAttribute string, ownerAttribute string) Identity {
+	identity := Identity{
+		TenantID: subjectAttribute(cert, tenantAttribute),
+		OwnerID:  subjectAttribute(cert, ownerAttribute),
+		Subject:  cert.Subject.String(),
+	}
+	if identity.TenantID == "" {
+		identity.TenantID = DefaultTenantID
+	}
+	return identity
+}
+
+func subjectAttribute(cert *x509.Certificate, attribute string) string {
+	var values []string
+	switch attribute {
+	case SubjectCommonName:
+		return cert.Subject.CommonName
+	case SubjectOrganization:
+		values = cert.Subject.Organization
+	case SubjectOrganizationalUnit:
+		values = cert.Subject.OrganizationalUnit
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,36 @@
+package auth
+
+import "context"
+
+// DefaultTenantID is the tenant that owns every row created before multi-tenancy was introduced.
+const DefaultTenantID = "default"
+
+type identityKey struct{}
+
+// Identity describes on whose behalf a request is executed.
+type Identity struct {
+	TenantID string
+	OwnerID  string
+	// Subject is the distinguished name of the client certificate the identity was taken from, if any.
+	Subject string
+}
+
+// WithIdentity returns a copy of ctx carrying the given identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity stored in ctx, if any.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// TenantID returns the tenant stored in ctx or an empty string if there is none.
+func TenantID(ctx context.Context) string {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return identity.TenantID
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidToken = errors.New("The token is invalid")
+
+// JWTVerifier verifies the signature and the validity period of the JSON Web Tokens,
+// HS256 with a shared secret, RS256 with an RSA public key or ES256 with a P-256 public key.
+// The tokens signed with any other algorithm are invalid.
+type JWTVerifier struct {
+	secret    []byte
+	publicKey crypto.PublicKey
+}
+
+// NewJWTVerifier verifies the tokens with the secret, or with the PEM encoded public key of publicKeyFile.
+func NewJWTVerifier(secret string, publicKeyFile string) (*JWTVerifier, error) {
+	switch {
+	case secret != "" && publicKeyFile != "":
+		return nil, errors.New("either a JWT secret or a JWT public key is required, not both")
+	case secret != "":
+		return &JWTVerifier{secret: []byte(secret)}, nil
+	case publicKeyFile == "":
+		return nil, errors.New("a JWT secret or a JWT public key is required")
+	}
+
+	content, err := ioutil.ReadFile(publicKeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read the JWT public key")
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.Errorf("no PEM block found in the JWT public key %s", publicKeyFile)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse the JWT public key")
+	}
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().BitSize != 256 {
+			return nil, errors.New("the JWT public key is not a P-256 key")
+		}
+	default:
+		return nil, errors.Errorf("unsupported JWT public key %T", publicKey)
+	}
+	return &JWTVerifier{publicKey: publicKey}, nil
+}
+
+// Subject returns the sub claim of the token, if its signature is valid and it is valid at now.
+func (v *JWTVerifier) Subject(token string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.WithStack(ErrInvalidToken)
+	}
+
+	header := struct {
+		Algorithm string `json:"alg"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.WithStack(ErrInvalidToken)
+	}
+	if !v.verify(header.Algorithm, parts[0]+"."+parts[1], signature) {
+		return "", errors.WithStack(ErrInvalidToken)
+	}
+
+	claims := struct {
+		Subject   string `json:"sub"`
+		ExpiresAt *int64 `json:"exp"`
+		NotBefore *int64 `json:"nbf"`
+	}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", err
+	}
+	if claims.ExpiresAt != nil && now.Unix() >= *claims.ExpiresAt {
+		return "", errors.Wrap(ErrInvalidToken, "expired")
+	}
+	if claims.NotBefore != nil && now.Unix() < *claims.NotBefore {
+		return "", errors.Wrap(ErrInvalidToken, "not valid yet")
+	}
+	if claims.Subject == "" {
+		return "", errors.Wrap(ErrInvalidToken, "no subject")
+	}
+	return claims.Subject, nil
+}
+
+// verify checks the signature with the key of the verifier, the algorithm has to match the kind of the key.
+func (v *JWTVerifier) verify(algorithm string, signed string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signed))
+	switch key := v.publicKey.(type) {
+	case nil:
+		if algorithm != "HS256" {
+			return false
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		return hmac.Equal(signature, mac.Sum(nil))
+	case *rsa.PublicKey:
+		return algorithm == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		// the signature is the concatenation of r and s, not the DER encoding
+		if algorithm != "ES256" || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.WithStack(ErrInvalidToken)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return errors.WithStack(ErrInvalidToken)
+	}
+	return nil
+}
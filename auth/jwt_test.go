@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func signedPart(algorithm string, claims string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"`+algorithm+`","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims))
+}
+
+func withSignature(signed string, signature []byte) string {
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writePublicKey(t *testing.T, publicKey crypto.PublicKey) (string, []byte) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, content
+}
+
+func TestJWTVerifier(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	claims := `{"sub":"client-1","exp":1600000060,"nbf":1599999940}`
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaFile, rsaPEM := writePublicKey(t, &rsaKey.PublicKey)
+	rsaVerifier, err := NewJWTVerifier("", rsaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecFile, _ := writePublicKey(t, &ecKey.PublicKey)
+	ecVerifier, err := NewJWTVerifier("", ecFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hsVerifier, err := NewJWTVerifier("jwt-secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signRS256 := func(claims string) string {
+		signed := signedPart("RS256", claims)
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return withSignature(signed, signature)
+	}
+	signES256 := func(claims string) string {
+		signed := signedPart("ES256", claims)
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return withSignature(signed, signature)
+	}
+	signHS256 := func(secret []byte, claims string) string {
+		signed := signedPart("HS256", claims)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		return withSignature(signed, mac.Sum(nil))
+	}
+
+	valid := []struct {
+		name     string
+		verifier *JWTVerifier
+		token    string
+	}{
+		{"RS256", rsaVerifier, signRS256(claims)},
+		{"ES256", ecVerifier, signES256(claims)},
+		{"HS256", hsVerifier, signHS256([]byte("jwt-secret"), claims)},
+	}
+	for _, test := range valid {
+		t.Run(test.name, func(t *testing.T) {
+			subject, err := test.verifier.Subject(test.token, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != "client-1" {
+				t.Errorf("subject = %q, want client-1", subject)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name     string
+		verifier *JWTVerifier
+		token    string
+	}{
+		{"wrong secret", hsVerifier, signHS256([]byte("other-secret"), claims)},
+		// the public key is known to everyone, it cannot be the secret of an HS256 token
+		{"public key as secret", rsaVerifier, signHS256(rsaPEM, claims)},
+		{"algorithm of another key", ecVerifier, signRS256(claims)},
+		{"no signature", hsVerifier, withSignature(signedPart("none", claims), nil)},
+		{"expired", hsVerifier, signHS256([]byte("jwt-secret"), `{"sub":"client-1","exp":1600000000}`)},
+		{"not valid yet", hsVerifier, signHS256([]byte("jwt-secret"), `{"sub":"client-1","nbf":1600000001}`)},
+		{"no subject", hsVerifier, signHS256([]byte("jwt-secret"), `{"exp":1600000060}`)},
+		{"malformed", hsVerifier, "client-1"},
+	}
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.verifier.Subject(test.token, now); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("got %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileStore keeps the blobs as files in a local directory.
+// A blob is written to a temporary file first and renamed into place, readers never see a partial blob.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &FileStore{
+		dir: dir,
+	}, nil
+}
+
+// path returns the file of the key, keys escaping the directory are rejected.
+func (s *FileStore) path(key string) (string, error) {
+	if key == "" || strings.ContainsRune(key, '\\') {
+		return "", errors.WithStack(ErrInvalidKey)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", errors.WithStack(ErrInvalidKey)
+		}
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func (s *FileStore) Put(_ context.Context, key string, r io.Reader, size int64, _ string, sha256Hex string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return errors.WithStack(err)
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		// a no-op once the file has been renamed
+		_ = os.Remove(file.Name())
+	}()
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), r)
+	if err != nil {
+		_ = file.Close()
+		return errors.WithStack(err)
+	}
+	if written != size || hex.EncodeToString(hash.Sum(nil)) != sha256Hex {
+		_ = file.Close()
+		return errors.WithStack(ErrChecksumMismatch)
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return errors.WithStack(err)
+	}
+	if err := file.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Rename(file.Name(), path))
+}
+
+func (s *FileStore) Open(_ context.Context, key string, offset int64) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.WithStack(ErrNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, errors.WithStack(err)
+	}
+
+	return file, nil
+}
+
+func (s *FileStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package blob
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Reader reads a blob of known size through the seeks and reads of an io.ReadSeeker,
+// so http.ServeContent can answer range requests from it.
+// Seeking is free, the blob is opened at the position of the first read after it.
+type Reader struct {
+	ctx    context.Context
+	store  Store
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func NewReader(ctx context.Context, store Store, key string, size int64) *Reader {
+	return &Reader{
+		ctx:   ctx,
+		store: store,
+		key:   key,
+		size:  size,
+	}
+}
+
+// Open opens the blob at the current position, so a missing blob is reported before anything is read.
+func (r *Reader) Open() error {
+	if r.body != nil {
+		return nil
+	}
+
+	body, err := r.store.Open(r.ctx, r.key, r.offset)
+	if err != nil {
+		return err
+	}
+	r.body = body
+	return nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if err := r.Open(); err != nil {
+		return 0, err
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if offset != r.offset {
+		if err := r.Close(); err != nil {
+			return 0, err
+		}
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+// Close releases the blob opened by the last read, the Reader can still be used afterwards.
+func (r *Reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return errors.WithStack(err)
+}
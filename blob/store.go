@@ -0,0 +1,24 @@
+// Package blob keeps the uploaded files of the resources outside of the database.
+package blob
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNotFound = errors.New("The blob not found")
+var ErrChecksumMismatch = errors.New("The blob content does not match its checksum")
+var ErrInvalidKey = errors.New("The blob key is invalid")
+
+// Store keeps the blobs by key, keys are slash separated paths.
+type Store interface {
+	// Put stores size bytes read from r under key, replacing the blob stored there before.
+	// sha256 is the hex encoded digest of the content, the blob is not stored if the content does not match it.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string, sha256 string) error
+	// Open reads the blob from offset until its end.
+	Open(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	// Delete removes the blob, deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache is a read-through cache over an in-process LRU and an optional remote Store.
+// Values are stored JSON encoded, so callers never share the cached instances.
+// Concurrent misses of the same key are coalesced into a single load.
+type Cache struct {
+	// generation changes on every invalidation, loads that overlap one are not cached.
+	// ttl is a time.Duration changed by SetTTL while the cache is in use.
+	// They are the first fields to keep them 64-bit aligned for the atomic operations.
+	generation uint64
+	ttl        int64
+
+	local       Store
+	remote      Store
+	loadTimeout time.Duration
+	group       singleflight.Group
+	metrics     *Metrics
+}
+
+// New creates a Cache named name in the metrics. remote may be nil.
+// A load is cancelled after loadTimeout, whatever happens to the lookups waiting for it.
+func New(name string, local Store, remote Store, ttl time.Duration, loadTimeout time.Duration) *Cache {
+	return &Cache{
+		local:       local,
+		remote:      remote,
+		ttl:         int64(ttl),
+		loadTimeout: loadTimeout,
+		metrics:     newMetrics(name),
+	}
+}
+
+// SetTTL changes the expiry of the values cached from now on, the ones cached already keep theirs.
+func (c *Cache) SetTTL(ttl time.Duration) {
+	atomic.StoreInt64(&c.ttl, int64(ttl))
+}
+
+func (c *Cache) getTTL() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.ttl))
+}
+
+// Metrics returns the lookup counters of the cache.
+func (c *Cache) Metrics() *Metrics {
+	return c.metrics
+}
+
+// Fetch decodes the cached value of key into dst. On a miss load is called, its result is cached and decoded into dst.
+// Cache failures are logged and fall back to load, they never fail the lookup.
+// The load is shared by the concurrent lookups of key, so it runs with the values of ctx but not its cancellation,
+// a lookup cancelled by its caller returns right away and leaves the load to the others.
+func (c *Cache) Fetch(ctx context.Context, key string, dst interface{}, load func(ctx context.Context) (interface{}, error)) error {
+	if data, ok := c.get(ctx, key); ok {
+		if err := json.Unmarshal(data, dst); err == nil {
+			atomic.AddInt64(&c.metrics.hits, 1)
+			return nil
+		}
+	}
+	atomic.AddInt64(&c.metrics.misses, 1)
+
+	loaded := false
+	result := c.group.DoChan(key, func() (interface{}, error) {
+		loaded = true
+		atomic.AddInt64(&c.metrics.loads, 1)
+
+		loadCtx, cancel := context.WithTimeout(detached{parent: ctx}, c.loadTimeout)
+		defer cancel()
+
+		generation := atomic.LoadUint64(&c.generation)
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot encode cached value")
+		}
+		if atomic.LoadUint64(&c.generation) == generation {
+			c.set(loadCtx, key, data)
+		}
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	case res := <-result:
+		if !loaded {
+			atomic.AddInt64(&c.metrics.coalesced, 1)
+		}
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), dst)
+	}
+}
+
+// detached keeps the values of its parent, like the identity of the tenant, without its deadline and cancellation.
+type detached struct {
+	parent context.Context
+}
+
+func (d detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (d detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (d detached) Err() error {
+	return nil
+}
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+// Invalidate removes the keys from every layer.
+func (c *Cache) Invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	// the loads in flight may have read the old value, they must not store it
+	atomic.AddUint64(&c.generation, 1)
+	for _, key := range keys {
+		c.group.Forget(key)
+	}
+
+	if err := c.local.Delete(ctx, keys...); err != nil {
+		c.logError(ctx, errors.Wrap(err, "local cache delete failed"))
+	}
+	if c.remote != nil {
+		if err := c.remote.Delete(ctx, keys...); err != nil {
+			c.logError(ctx, errors.Wrap(err, "remote cache delete failed"))
+		}
+	}
+}
+
+func (c *Cache) get(ctx context.Context, key string) ([]byte, bool) {
+	data, ok, err := c.local.Get(ctx, key)
+	if err != nil {
+		c.logError(ctx, errors.Wrap(err, "local cache get failed"))
+	}
+	if ok || c.remote == nil {
+		return data, ok
+	}
+
+	data, ok, err = c.remote.Get(ctx, key)
+	if err != nil {
+		c.logError(ctx, errors.Wrap(err, "remote cache get failed"))
+		return nil, false
+	}
+	if ok {
+		if err := c.local.Set(ctx, key, data, c.getTTL()); err != nil {
+			c.logError(ctx, errors.Wrap(err, "local cache set failed"))
+		}
+	}
+	return data, ok
+}
+
+func (c *Cache) set(ctx context.Context, key string, data []byte) {
+	if err := c.local.Set(ctx, key, data, c.getTTL()); err != nil {
+		c.logError(ctx, errors.Wrap(err, "local cache set failed"))
+	}
+	if c.remote != nil {
+		if err := c.remote.Set(ctx, key, data, c.getTTL()); err != nil {
+			c.logError(ctx, errors.Wrap(err, "remote cache set failed"))
+		}
+	}
+}
+
+func (c *Cache) logError(ctx context.Context, err error) {
+	atomic.AddInt64(&c.metrics.errors, 1)
+	log.Warn(ctx, err.Error(), "error", err)
+}
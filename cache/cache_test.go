@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type contextKey struct{}
+
+func newTestCache(t *testing.T) *Cache {
+	return New(t.Name(), NewLRU(10), nil, time.Minute, time.Second)
+}
+
+// countingLoad returns a load giving value and the number of times it was called.
+func countingLoad(value string) (func(ctx context.Context) (interface{}, error), *int64) {
+	calls := new(int64)
+	return func(context.Context) (interface{}, error) {
+		atomic.AddInt64(calls, 1)
+		return value, nil
+	}, calls
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t)
+	load, calls := countingLoad("value")
+
+	for i := 0; i < 2; i++ {
+		var value string
+		if err := c.Fetch(ctx, "key", &value, load); err != nil {
+			t.Fatal(err)
+		}
+		if value != "value" {
+			t.Fatalf("expected the loaded value, got %q", value)
+		}
+	}
+	if *calls != 1 {
+		t.Fatalf("expected the second lookup served from the cache, loaded %d times", *calls)
+	}
+
+	c.Invalidate(ctx, "key")
+	var value string
+	if err := c.Fetch(ctx, "key", &value, load); err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected a load after the invalidation, loaded %d times", *calls)
+	}
+
+	metrics := c.Metrics().Snapshot()
+	if metrics["hits"] != 1 || metrics["misses"] != 2 || metrics["loads"] != 2 {
+		t.Fatalf("unexpected metrics %v", metrics)
+	}
+}
+
+func TestCacheSkipsLoadsOverlappingInvalidation(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t)
+
+	var value string
+	err := c.Fetch(ctx, "key", &value, func(ctx context.Context) (interface{}, error) {
+		// the value read before the invalidation is stale
+		c.Invalidate(ctx, "key")
+		return "stale", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "stale" {
+		t.Fatalf("expected the loaded value returned, got %q", value)
+	}
+
+	load, calls := countingLoad("fresh")
+	if err := c.Fetch(ctx, "key", &value, load); err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 1 || value != "fresh" {
+		t.Fatalf("expected the stale value not cached, got %q", value)
+	}
+}
+
+func TestCacheLoadErrorsAreNotCached(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t)
+
+	var value string
+	failure := errors.New("unavailable")
+	err := c.Fetch(ctx, "key", &value, func(context.Context) (interface{}, error) {
+		return nil, failure
+	})
+	if err != failure {
+		t.Fatalf("expected the load error, got %v", err)
+	}
+
+	load, calls := countingLoad("value")
+	if err := c.Fetch(ctx, "key", &value, load); err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 1 {
+		t.Fatal("expected a new load after the failed one")
+	}
+}
+
+// TestCacheLoadOutlivesCancelledLookup checks that the lookup starting a load can give up without failing the others.
+func TestCacheLoadOutlivesCancelledLookup(t *testing.T) {
+	c := newTestCache(t)
+	var once sync.Once
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loadErr := make(chan error, 2)
+	load := func(ctx context.Context) (interface{}, error) {
+		once.Do(func() { close(started) })
+		<-release
+		if ctx.Value(contextKey{}) != "tenant" {
+			loadErr <- errors.New("expected the values of the lookup")
+		}
+		loadErr <- ctx.Err()
+		return "value", nil
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "tenant"))
+	leader := make(chan error, 1)
+	go func() {
+		var value string
+		leader <- c.Fetch(leaderCtx, "key", &value, load)
+	}()
+	<-started
+
+	waiter := make(chan error, 1)
+	var waited string
+	go func() {
+		waiter <- c.Fetch(context.Background(), "key", &waited, load)
+	}()
+	// let the second lookup join the load
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled lookup to return right away, got %v", err)
+	}
+
+	close(release)
+	if err := <-loadErr; err != nil {
+		t.Fatalf("expected the load to go on, got %v", err)
+	}
+	if err := <-waiter; err != nil {
+		t.Fatal(err)
+	}
+	if loads := c.Metrics().Snapshot()["loads"]; loads != 1 {
+		t.Fatalf("expected the lookups to share the load, loaded %d times", loads)
+	}
+	if waited != "value" {
+		t.Fatalf("expected the loaded value, got %q", waited)
+	}
+
+	var cached string
+	if err := c.Fetch(context.Background(), "key", &cached, func(context.Context) (interface{}, error) {
+		return nil, errors.New("expected the value cached")
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheLoadTimeout(t *testing.T) {
+	c := New(t.Name(), NewLRU(10), nil, time.Minute, 10*time.Millisecond)
+
+	var value string
+	err := c.Fetch(context.Background(), "key", &value, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the load timed out, got %v", err)
+	}
+}
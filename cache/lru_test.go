@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+	for _, key := range []string{"a", "b"} {
+		if err := lru.Set(ctx, key, []byte(key), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// reading a makes b the least recently used
+	if _, ok, _ := lru.Get(ctx, "a"); !ok {
+		t.Fatal("expected a cached")
+	}
+	if err := lru.Set(ctx, "c", []byte("c"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := lru.Get(ctx, "b"); ok {
+		t.Error("expected b evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if value, ok, _ := lru.Get(ctx, key); !ok || string(value) != key {
+			t.Errorf("expected %s cached, got %q", key, value)
+		}
+	}
+	if lru.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", lru.Len())
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	lru := NewLRU(10)
+	lru.now = func() time.Time { return now }
+
+	if err := lru.Set(ctx, "a", []byte("a"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(59 * time.Second)
+	if _, ok, _ := lru.Get(ctx, "a"); !ok {
+		t.Fatal("expected a cached before its expiry")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok, _ := lru.Get(ctx, "a"); ok {
+		t.Fatal("expected a expired")
+	}
+	if lru.Len() != 0 {
+		t.Fatalf("expected the expired entry removed, %d left", lru.Len())
+	}
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// Metrics counts the lookups of a Cache. The counters are published with expvar under "cache".
+type Metrics struct {
+	hits      int64
+	misses    int64
+	loads     int64
+	coalesced int64
+	errors    int64
+}
+
+var published = expvar.NewMap("cache")
+
+func newMetrics(name string) *Metrics {
+	m := &Metrics{}
+	published.Set(name, expvar.Func(func() interface{} {
+		return m.Snapshot()
+	}))
+	return m
+}
+
+// Snapshot returns the current value of the counters.
+func (m *Metrics) Snapshot() map[string]int64 {
+	return map[string]int64{
+		"hits":      atomic.LoadInt64(&m.hits),
+		"misses":    atomic.LoadInt64(&m.misses),
+		"loads":     atomic.LoadInt64(&m.loads),
+		"coalesced": atomic.LoadInt64(&m.coalesced),
+		"errors":    atomic.LoadInt64(&m.errors),
+	}
+}
@@ -0,0 +1,18 @@
+// Package cache provides the read-through cache in front of the storage.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps encoded values by key. Implementations must be safe for concurrent use.
+// Besides the in-process LRU, a shared remote cache like Redis or Memcached can be plugged in through it.
+type Store interface {
+	// Get returns the value of key, ok is false on a miss.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores the value of key, it expires after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the keys, missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,80 @@
+// Package certs serves the TLS certificates of the servers from files, they can be reloaded while the servers run.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// Config names the PEM files of the server certificate and the CAs of the client certificates.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables the verification of the client certificates, without it they are not requested.
+	ClientCAFile string
+}
+
+// Reloader holds the certificates loaded from the files of its Config.
+type Reloader struct {
+	config  Config
+	current atomic.Value
+}
+
+// NewReloader loads the certificates, it fails if the files are missing or invalid.
+func NewReloader(config Config) (*Reloader, error) {
+	r := &Reloader{config: config}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again, the connections opened afterwards use the new certificates.
+// The certificates in use are kept if the files are invalid, like in the middle of a rotation.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "cannot load the TLS certificate")
+	}
+
+	config := newTLSConfig()
+	config.Certificates = []tls.Certificate{cert}
+
+	if r.config.ClientCAFile != "" {
+		bundle, err := ioutil.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return errors.Wrap(err, "cannot read the client CA bundle")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return errors.Errorf("no certificate found in the client CA bundle %s", r.config.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		// the requests without a certificate are rejected by the server, except the health checks of the infrastructure
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	r.current.Store(config)
+	return nil
+}
+
+// TLSConfig returns the configuration of a server, every handshake uses the certificates loaded last.
+// HTTP/2 is offered to the clients.
+func (r *Reloader) TLSConfig() *tls.Config {
+	config := newTLSConfig()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return r.current.Load().(*tls.Config), nil
+	}
+	return config
+}
+
+func newTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+}
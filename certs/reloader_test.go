@@ -0,0 +1,149 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newCertificate(t, "ca", nil, nil)
+	writePEM(t, filepath.Join(dir, "ca.pem"), ca, nil)
+	server, serverKey := newCertificate(t, "server-1", ca, caKey)
+	writePEM(t, filepath.Join(dir, "server.pem"), server, serverKey)
+	client, clientKey := newCertificate(t, "client", ca, caKey)
+
+	reloader, err := NewReloader(Config{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the way echo serves TLS
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := &http.Server{
+		TLSConfig: reloader.TLSConfig(),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.VerifiedChains) > 0 {
+				w.Header().Set("X-Client", r.TLS.VerifiedChains[0][0].Subject.CommonName)
+			}
+		}),
+	}
+	go func() { _ = httpServer.Serve(tls.NewListener(listener, httpServer.TLSConfig)) }()
+	defer httpServer.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	get := func(withClientCert bool) *http.Response {
+		config := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+		if withClientCert {
+			config.Certificates = []tls.Certificate{{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: config, ForceAttemptHTTP2: true}}
+		resp, err := httpClient.Get("https://" + listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	resp := get(true)
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+	}
+	if resp.Header.Get("X-Client") != "client" {
+		t.Fatalf("expected the verified client certificate, got %q", resp.Header.Get("X-Client"))
+	}
+	if resp.TLS.PeerCertificates[0].Subject.CommonName != "server-1" {
+		t.Fatalf("expected server-1, got %s", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	}
+	if resp := get(false); resp.Header.Get("X-Client") != "" {
+		t.Fatal("expected no client certificate")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "server.pem"), []byte("rotating"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("expected an error for an invalid certificate")
+	}
+	if resp := get(true); resp.TLS.PeerCertificates[0].Subject.CommonName != "server-1" {
+		t.Fatal("expected the certificate in use to be kept")
+	}
+
+	server, serverKey = newCertificate(t, "server-2", ca, caKey)
+	writePEM(t, filepath.Join(dir, "server.pem"), server, serverKey)
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if resp := get(true); resp.TLS.PeerCertificates[0].Subject.CommonName != "server-2" {
+		t.Fatalf("expected the reloaded certificate, got %s", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	}
+}
+
+// newCertificate creates a certificate for localhost signed by parent, a self-signed CA without one.
+func newCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, path string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if key != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})...)
+	}
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
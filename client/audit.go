@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+)
+
+// GetAuditEvents returns the audit events matching the filter, newest first.
+func (c *Client) GetAuditEvents(ctx context.Context, filter *httpModels.GetAuditEventsRequest) ([]models.AuditEvent, error) {
+	query := url.Values{}
+	if filter != nil {
+		if filter.ResourceID != nil {
+			query.Set("resource_id", filter.ResourceID.String())
+		}
+		if filter.Actor != "" {
+			query.Set("actor", filter.Actor)
+		}
+		if filter.Since != nil {
+			query.Set("since", filter.Since.Format(time.RFC3339Nano))
+		}
+		if filter.Until != nil {
+			query.Set("until", filter.Until.Format(time.RFC3339Nano))
+		}
+		if filter.Limit != 0 {
+			query.Set("limit", strconv.Itoa(filter.Limit))
+		}
+		if filter.Offset != 0 {
+			query.Set("offset", strconv.Itoa(filter.Offset))
+		}
+	}
+
+	var resp []models.AuditEvent
+	err := c.do(ctx, &request{
+		method:  http.MethodGet,
+		path:    "/api/v1/audit",
+		query:   query,
+		out:     &resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+func blobPath(id uuid.UUID) string {
+	return "/api/v1/resources/" + id.String() + "/blob"
+}
+
+// UploadResourceBlob stores the content read from r as the blob of the resource and returns the updated resource,
+// with the size, content type and digest of the blob recorded and its location pointing at the download route.
+// The upload is streamed, so it is never retried.
+func (c *Client) UploadResourceBlob(ctx context.Context, id uuid.UUID, r io.Reader, contentType string) (*models.Resource, error) {
+	u := *c.baseURL
+	u.Path += blobPath(id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), r)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read response")
+	}
+
+	resource := &models.Resource{}
+	if err := decodeResponse(resp, respBody, &request{out: resource}); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// DownloadResourceBlob reads the blob of the resource from offset until its end, the returned body has to be closed.
+func (c *Client) DownloadResourceBlob(ctx context.Context, id uuid.UUID, offset int64) (io.ReadCloser, error) {
+	u := *c.baseURL
+	u.Path += blobPath(id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create request")
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent {
+		return resp.Body, nil
+	}
+
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read response")
+	}
+	if err := decodeResponse(resp, respBody, &request{}); err != nil {
+		return nil, err
+	}
+	return nil, newError(resp, "unexpected response")
+}
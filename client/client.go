@@ -0,0 +1,297 @@
+// Package client is the Go client of the resources service.
+// It talks to the /api/v1 routes and hides the differences of their response envelopes.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/requestinfo"
+)
+
+const (
+	defaultTenantHeader = "X-Tenant-ID"
+	defaultOwnerHeader  = "X-Owner-ID"
+	requestIDHeader     = "X-Request-ID"
+)
+
+// RetryPolicy controls how idempotent requests are repeated after a network error or a temporary server error.
+// The delay doubles after every attempt, starting from BaseDelay and capped at MaxDelay, with random jitter added.
+// A longer Retry-After sent by the rate limited server is waited instead.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy is used unless WithRetryPolicy is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// Client calls the resources service. It is safe for concurrent use.
+type Client struct {
+	baseURL      *url.URL
+	httpClient   *http.Client
+	retryPolicy  RetryPolicy
+	tenantHeader string
+	ownerHeader  string
+	tenantID     string
+	ownerID      string
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy. A MaxAttempts of 1 disables retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithTenant sends every request on behalf of the given tenant.
+func WithTenant(tenantID string) Option {
+	return func(c *Client) {
+		c.tenantID = tenantID
+	}
+}
+
+// WithOwner sends every request on behalf of the given owner.
+func WithOwner(ownerID string) Option {
+	return func(c *Client) {
+		c.ownerID = ownerID
+	}
+}
+
+// WithIdentityHeaders sets the header names, if the server is configured with other than the default ones.
+func WithIdentityHeaders(tenantHeader string, ownerHeader string) Option {
+	return func(c *Client) {
+		c.tenantHeader = tenantHeader
+		c.ownerHeader = ownerHeader
+	}
+}
+
+// New creates a Client for the server at baseURL, for example http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base url")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("invalid base url %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:      u,
+		httpClient:   http.DefaultClient,
+		retryPolicy:  DefaultRetryPolicy,
+		tenantHeader: defaultTenantHeader,
+		ownerHeader:  defaultOwnerHeader,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retryPolicy.MaxAttempts < 1 {
+		c.retryPolicy.MaxAttempts = 1
+	}
+
+	return c, nil
+}
+
+// envelope is the ResponseData wrapper most of the routes respond with.
+type envelope struct {
+	Error string          `json:"error"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// request describes a single call. A nil out skips decoding, wrapped tells whether the response is in an envelope.
+type request struct {
+	method  string
+	path    string
+	query   url.Values
+	body    interface{}
+	out     interface{}
+	wrapped bool
+}
+
+func (r *request) idempotent() bool {
+	return r.method != http.MethodPost
+}
+
+func (c *Client) do(ctx context.Context, r *request) error {
+	var body []byte
+	if r.body != nil {
+		var err error
+		body, err = json.Marshal(r.body)
+		if err != nil {
+			return errors.Wrap(err, "cannot encode request")
+		}
+	}
+
+	attempts := 1
+	if r.idempotent() {
+		attempts = c.retryPolicy.MaxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if waitErr := c.wait(ctx, attempt, err); waitErr != nil {
+				return waitErr
+			}
+		}
+
+		err = c.send(ctx, r, body)
+		if err == nil || !retryable(ctx, err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (c *Client) send(ctx context.Context, r *request, body []byte) error {
+	u := *c.baseURL
+	u.Path += r.path
+	if len(r.query) > 0 {
+		u.RawQuery = r.query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), bodyReader)
+	if err != nil {
+		return errors.Wrap(err, "cannot create request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "cannot read response")
+	}
+
+	return decodeResponse(resp, respBody, r)
+}
+
+// setHeaders adds the identity of the client and the request ID of the context to the request,
+// so a request is traced across the services calling each other.
+func (c *Client) setHeaders(req *http.Request) {
+	header := req.Header
+	if requestID := requestinfo.FromContext(req.Context()).RequestID; requestID != "" {
+		header.Set(requestIDHeader, requestID)
+	}
+	if c.tenantID != "" {
+		header.Set(c.tenantHeader, c.tenantID)
+	}
+	if c.ownerID != "" {
+		header.Set(c.ownerHeader, c.ownerID)
+	}
+}
+
+// decodeResponse turns the error responses into *Error and decodes the payload of the successful ones.
+// The legacy not found answer, 202 with an error message, is reported as an error as well.
+func decodeResponse(resp *http.Response, body []byte, r *request) error {
+	var env envelope
+	isJSON := strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")
+	if isJSON && len(body) > 0 {
+		_ = json.Unmarshal(body, &env)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest || env.Error != "" {
+		e := newError(resp, env.Error)
+		if e.Message == "" && !isJSON {
+			e.Message = strings.TrimSpace(string(body))
+		}
+		return e
+	}
+
+	if r.out == nil || len(body) == 0 {
+		return nil
+	}
+
+	payload := body
+	if r.wrapped {
+		payload = env.Data
+	}
+	if err := json.Unmarshal(payload, r.out); err != nil {
+		return errors.Wrap(err, "cannot decode response")
+	}
+
+	return nil
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Temporary()
+	}
+	// everything else is a transport error
+	return true
+}
+
+func (c *Client) wait(ctx context.Context, attempt int, lastErr error) error {
+	delay := c.retryPolicy.BaseDelay << uint(attempt-1)
+	if delay <= 0 || (c.retryPolicy.MaxDelay > 0 && delay > c.retryPolicy.MaxDelay) {
+		delay = c.retryPolicy.MaxDelay
+	}
+	if delay > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	var e *Error
+	if errors.As(lastErr, &e) && e.RetryAfter > delay {
+		delay = e.RetryAfter
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(lastErr, ctx.Err().Error())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func int64Path(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// MaxContentItems is the number of content entries the server accepts on a resource.
+const MaxContentItems = 2
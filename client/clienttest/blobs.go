@@ -0,0 +1,103 @@
+package clienttest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/client"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// maxBlobSize is the default upload limit of the real service.
+const maxBlobSize = 100 << 20
+
+func (s *Server) uploadBlob(eCtx echo.Context) error {
+	id, err := parseUUIDParam(eCtx, "resource_id")
+	if err != nil {
+		return err
+	}
+
+	body := io.Reader(eCtx.Request().Body)
+	contentType := eCtx.Request().Header.Get(echo.HeaderContentType)
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == echo.MIMEMultipartForm {
+		file, err := eCtx.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "the form has no file field")
+		}
+		part, err := file.Open()
+		if err != nil {
+			return fail(eCtx, http.StatusInternalServerError, err)
+		}
+		defer part.Close()
+		body, contentType = part, file.Header.Get(echo.HeaderContentType)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxBlobSize+1))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot read the upload")
+	}
+	if len(data) > maxBlobSize {
+		return fail(eCtx, http.StatusRequestEntityTooLarge, client.ErrBlobTooLarge)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	before, ok := t.resources[id]
+	if !ok {
+		return fail(eCtx, http.StatusAccepted, client.ErrResourceNotFound)
+	}
+
+	sum := sha256.Sum256(data)
+	after := before
+	after.Blob = &models.Blob{Size: int64(len(data)), ContentType: contentType, SHA256: hex.EncodeToString(sum[:])}
+	after.Content = make(models.ContentMap, len(before.Content)+1)
+	for k, v := range before.Content {
+		after.Content[k] = v
+	}
+	after.Content[models.LocationKey] = fmt.Sprintf("/api/v1/resources/%s/blob", id)
+	after.UpdatedAt = now()
+
+	t.resources[id] = after
+	t.blobs[id] = data
+	s.record(t, ownerID(eCtx), models.AuditOperationUpdate, &before, &after)
+
+	return eCtx.JSON(http.StatusOK, after)
+}
+
+func (s *Server) downloadBlob(eCtx echo.Context) error {
+	id, err := parseUUIDParam(eCtx, "resource_id")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	t := s.tenant(tenantID(eCtx))
+	resource, ok := t.resources[id]
+	data := t.blobs[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return fail(eCtx, http.StatusAccepted, client.ErrResourceNotFound)
+	}
+	if resource.Blob == nil {
+		return fail(eCtx, http.StatusNotFound, client.ErrBlobNotFound)
+	}
+
+	eCtx.Response().Header().Set(echo.HeaderContentType, resource.Blob.ContentType)
+	eCtx.Response().Header().Set("ETag", `"`+resource.Blob.SHA256+`"`)
+	http.ServeContent(eCtx.Response(), eCtx.Request(), "", resource.UpdatedAt, bytes.NewReader(data))
+	return nil
+}
@@ -0,0 +1,763 @@
+// Package clienttest provides an in-memory fake of the resources service for the tests of client consumers.
+package clienttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/client"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/requestinfo"
+)
+
+const tenantHeader = "X-Tenant-ID"
+const ownerHeader = "X-Owner-ID"
+const defaultTenantID = "default"
+
+// DefaultCategories are the categories of the default tenant, the same as the seed of the real database.
+// The tenants added later get copies of them under new IDs, like the tenants command of the service does.
+var DefaultCategories = []models.Category{
+	{ID: 1, Name: "News feed", Description: "Resource marked as news feed item"},
+	{ID: 2, Name: models.CategoryContent, Description: "All resource that has been uploaded as an attachement in another resource. For example, news feed image for news feed resource item"},
+}
+
+// Server is an httptest.Server serving the /api/v1 routes from memory.
+// It answers with the same status codes and envelopes as the real service, so clients see the same errors.
+// The schedule of the resources hides them from the listings and the stream, but no publication or expiry events are emitted.
+// Only the default tenant is provisioned, the writes of the other tenants are refused until they are added with AddTenant.
+// The webhook URLs are not checked, the real service refuses the private addresses.
+type Server struct {
+	*httptest.Server
+
+	validate *validator.Validate
+
+	mu             sync.Mutex
+	tenants        map[string]*tenant
+	failures       map[string][]failure
+	nextCategoryID int
+	nextEventID    int64
+	eventsChanged  chan struct{}
+	done           chan struct{}
+	closeOnce      sync.Once
+}
+
+type tenant struct {
+	categories    []models.Category
+	resources     map[uuid.UUID]models.Resource
+	blobs         map[uuid.UUID][]byte
+	audit         []models.AuditEvent
+	events        []client.StreamEvent
+	subscriptions map[uuid.UUID]models.WebhookSubscription
+	deadLetters   map[int64]models.WebhookDelivery
+}
+
+type failure struct {
+	statusCode int
+	message    string
+}
+
+// NewServer starts a fake server. Close it at the end of the test.
+func NewServer() *Server {
+	s := &Server{
+		validate:      validator.New(),
+		tenants:       make(map[string]*tenant),
+		failures:      make(map[string][]failure),
+		eventsChanged: make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	s.tenants[defaultTenantID] = newTenant(append([]models.Category(nil), DefaultCategories...))
+	s.nextCategoryID = len(DefaultCategories) + 1
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// AddTenant provisions the tenant with the default categories, adding a tenant again keeps its state.
+func (s *Server) AddTenant(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addTenant(tenantID)
+}
+
+// addTenant provisions the tenant unless it exists. It must be called with mu held.
+func (s *Server) addTenant(tenantID string) *tenant {
+	if t, ok := s.tenants[tenantID]; ok {
+		return t
+	}
+	categories := append([]models.Category(nil), DefaultCategories...)
+	for i := range categories {
+		categories[i].ID = s.nextCategoryID
+		s.nextCategoryID++
+	}
+	t := newTenant(categories)
+	s.tenants[tenantID] = t
+	return t
+}
+
+// Client returns a client of the fake server.
+func (s *Server) Client(opts ...client.Option) *client.Client {
+	c, err := client.New(s.URL, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Close ends the open resource streams and shuts the server down.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	s.Server.Close()
+}
+
+// FailNext makes the next request to the route fail with the given status and error message.
+// The route is the method and the registered path, like "GET /api/v1/resources/:resource_id/".
+// Calling it several times queues several failures, which is handy for testing retries.
+func (s *Server) FailNext(route string, statusCode int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[route] = append(s.failures[route], failure{statusCode: statusCode, message: message})
+}
+
+// AddResource stores a resource for the tenant without going through the API, the tenant is added if it was not.
+func (s *Server) AddResource(tenantID string, resource models.Resource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addTenant(tenantID).resources[resource.ID] = resource
+}
+
+// Resources returns the resources of the tenant.
+func (s *Server) Resources(tenantID string) []models.Resource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedResources(s.tenant(tenantID).resources)
+}
+
+// AddDeadLetter stores a dead webhook delivery for the tenant, the tenant is added if it was not.
+func (s *Server) AddDeadLetter(tenantID string, delivery models.WebhookDelivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivery.Status = models.WebhookDeliveryDead
+	s.addTenant(tenantID).deadLetters[delivery.ID] = delivery
+}
+
+func newTenant(categories []models.Category) *tenant {
+	return &tenant{
+		categories:    categories,
+		resources:     make(map[uuid.UUID]models.Resource),
+		blobs:         make(map[uuid.UUID][]byte),
+		subscriptions: make(map[uuid.UUID]models.WebhookSubscription),
+		deadLetters:   make(map[int64]models.WebhookDelivery),
+	}
+}
+
+// tenant returns the state of the tenant. A tenant not provisioned gets an empty state that is not kept,
+// the routes that add to it check provisioned first. It must be called with mu held.
+func (s *Server) tenant(tenantID string) *tenant {
+	if t, ok := s.tenants[tenantID]; ok {
+		return t
+	}
+	return newTenant(nil)
+}
+
+// provisioned reports whether the tenant was added. It must be called with mu held.
+func (s *Server) provisioned(tenantID string) bool {
+	_, ok := s.tenants[tenantID]
+	return ok
+}
+
+func tenantID(eCtx echo.Context) string {
+	if id := eCtx.Request().Header.Get(tenantHeader); id != "" {
+		return id
+	}
+	return defaultTenantID
+}
+
+func ownerID(eCtx echo.Context) string {
+	return eCtx.Request().Header.Get(ownerHeader)
+}
+
+func (s *Server) routes() *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = func(err error, eCtx echo.Context) {
+		statusCode := http.StatusInternalServerError
+		message := err.Error()
+		if eErr, ok := err.(*echo.HTTPError); ok {
+			statusCode = eErr.Code
+			switch statusCode {
+			case http.StatusNotFound:
+				message = fmt.Sprintf("route not found: %v", eErr.Message)
+			case http.StatusMethodNotAllowed:
+				message = fmt.Sprintf("method not allowed: %v", eErr.Message)
+			default:
+				message = fmt.Sprintf("validation error: %v", eErr.Message)
+			}
+		}
+		_ = eCtx.JSON(statusCode, httpModels.ResponseData{Error: message})
+	}
+	e.Use(echoRequestID)
+	e.Use(s.injectFailures)
+
+	e.GET("/healthcheck", func(eCtx echo.Context) error {
+		return eCtx.NoContent(http.StatusOK)
+	})
+
+	api := e.Group("/api/v1")
+	api.GET("/resources/", s.getResourcesByIDs)
+	api.GET("/resources/stream", s.streamResources)
+	api.POST("/resources/lookup", s.lookupResources)
+	api.GET("/resources/categories/:category", s.getResourcesByCategory)
+	api.GET("/resources/:resource_id/", s.getResource)
+	api.POST("/resources/:resource_id/", s.addResource)
+	api.PUT("/resources/:resource_id/", s.updateResource)
+	api.DELETE("/resources/:resource_id/", s.deleteResource)
+	api.POST("/resources/:resource_id/blob", s.uploadBlob)
+	api.GET("/resources/:resource_id/blob", s.downloadBlob)
+	api.GET("/categories/", s.getCategories)
+	api.GET("/audit", s.getAuditEvents)
+	api.GET("/webhooks/", s.getSubscriptions)
+	api.POST("/webhooks/", s.createSubscription)
+	api.GET("/webhooks/dead-letters", s.getDeadLetters)
+	api.POST("/webhooks/dead-letters/:delivery_id/replay", s.replayDeadLetter)
+	api.GET("/webhooks/:subscription_id/", s.getSubscription)
+	api.PUT("/webhooks/:subscription_id/", s.updateSubscription)
+	api.DELETE("/webhooks/:subscription_id/", s.deleteSubscription)
+
+	return e
+}
+
+// echoRequestID answers with the request ID sent, or a new one, like the service does.
+func echoRequestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(eCtx echo.Context) error {
+		requestID := requestinfo.RequestID(eCtx.Request().Header.Get(echo.HeaderXRequestID))
+		eCtx.Response().Header().Set(echo.HeaderXRequestID, requestID)
+		return next(eCtx)
+	}
+}
+
+func (s *Server) injectFailures(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(eCtx echo.Context) error {
+		route := eCtx.Request().Method + " " + eCtx.Path()
+
+		s.mu.Lock()
+		queue := s.failures[route]
+		var f *failure
+		if len(queue) > 0 {
+			f = &queue[0]
+			s.failures[route] = queue[1:]
+		}
+		s.mu.Unlock()
+
+		if f != nil {
+			return eCtx.JSON(f.statusCode, httpModels.ResponseData{Error: f.message})
+		}
+		return next(eCtx)
+	}
+}
+
+// bind binds the request and validates it by the tags of the request models, like the service does.
+func (s *Server) bind(eCtx echo.Context, req interface{}) error {
+	if err := eCtx.Bind(req); err != nil {
+		return err
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+func fail(eCtx echo.Context, statusCode int, err error) error {
+	return eCtx.JSON(statusCode, httpModels.ResponseData{Error: err.Error()})
+}
+
+func parseUUIDParam(eCtx echo.Context, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(eCtx.Param(name))
+	if err != nil {
+		return uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid "+name)
+	}
+	return id, nil
+}
+
+func sortedResources(resources map[uuid.UUID]models.Resource) []models.Resource {
+	list := make([]models.Resource, 0, len(resources))
+	for _, r := range resources {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID.String() < list[j].ID.String()
+	})
+	return list
+}
+
+// listResources filters and orders the resources like the listings of the real service.
+func listResources(resources []models.Resource, list *httpModels.ResourceListRequest) ([]models.Resource, error) {
+	column := strings.TrimPrefix(list.Sort, "-")
+	if column == "" {
+		column = models.ResourceSortCreatedAt
+	}
+	if column != models.ResourceSortCreatedAt && column != models.ResourceSortUpdatedAt {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid sort")
+	}
+	ascending := list.Sort != "" && !strings.HasPrefix(list.Sort, "-")
+
+	timestamp := func(resource *models.Resource) time.Time {
+		if column == models.ResourceSortUpdatedAt {
+			return resource.UpdatedAt
+		}
+		return resource.CreatedAt
+	}
+
+	now := time.Now()
+	filtered := make([]models.Resource, 0, len(resources))
+	for i := range resources {
+		if !list.IncludeHidden && resources[i].Hidden(now) {
+			continue
+		}
+		at := timestamp(&resources[i])
+		if (list.Since != nil && at.Before(*list.Since)) || (list.Until != nil && !at.Before(*list.Until)) {
+			continue
+		}
+		filtered = append(filtered, resources[i])
+	}
+
+	less := func(i, j int) bool {
+		a, b := timestamp(&filtered[i]), timestamp(&filtered[j])
+		if a.Equal(b) {
+			return filtered[i].ID.String() < filtered[j].ID.String()
+		}
+		return a.Before(b)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		if ascending {
+			return less(i, j)
+		}
+		return less(j, i)
+	})
+
+	return filtered, nil
+}
+
+func validSchedule(resource *models.Resource) bool {
+	return resource.PublishAt == nil || resource.ExpireAt == nil || resource.ExpireAt.After(*resource.PublishAt)
+}
+
+// now returns the current time at the precision of the real service.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// record adds the audit and change events of a mutation by the actor. It must be called with mu held.
+func (s *Server) record(t *tenant, actor string, operation string, before *models.Resource, after *models.Resource) {
+	resource := after
+	if resource == nil {
+		resource = before
+	}
+
+	t.audit = append(t.audit, models.AuditEvent{
+		ID:         int64(len(t.audit) + 1),
+		Actor:      actor,
+		Operation:  operation,
+		ResourceID: resource.ID,
+		Diff:       models.AuditDiff{Before: before, After: after},
+		CreatedAt:  time.Now().UTC(),
+	})
+
+	eventTypes := map[string]string{
+		models.AuditOperationAdd:    models.EventResourceCreated,
+		models.AuditOperationUpdate: models.EventResourceUpdated,
+		models.AuditOperationDelete: models.EventResourceDeleted,
+	}
+	s.nextEventID++
+	t.events = append(t.events, client.StreamEvent{ID: s.nextEventID, Type: eventTypes[operation], Resource: *resource})
+
+	close(s.eventsChanged)
+	s.eventsChanged = make(chan struct{})
+}
+
+func (s *Server) addResource(eCtx echo.Context) error {
+	req := &httpModels.AddResourceRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+	if req.Resource == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "resource is required")
+	}
+	if len(req.Resource.Content) > client.MaxContentItems {
+		return fail(eCtx, http.StatusInternalServerError, client.ErrResourceHasTooManyAttachments)
+	}
+	if !validSchedule(req.Resource) {
+		return fail(eCtx, http.StatusBadRequest, client.ErrInvalidSchedule)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.provisioned(tenantID(eCtx)) {
+		return fail(eCtx, http.StatusForbidden, client.ErrTenantNotFound)
+	}
+	t := s.tenant(tenantID(eCtx))
+
+	if !t.hasCategory(req.Resource.Category) {
+		return fail(eCtx, http.StatusBadRequest, client.ErrCategoryNotFound)
+	}
+	if _, ok := t.resources[req.Resource.ID]; ok {
+		return fail(eCtx, http.StatusInternalServerError, client.ErrResourceAlreadyExists)
+	}
+
+	now := now()
+	for k, v := range req.Resource.Content {
+		if k == models.LocationKey {
+			continue
+		}
+		attachment, err := models.NewResource(k, t.contentCategory(), v)
+		if err != nil {
+			return fail(eCtx, http.StatusInternalServerError, err)
+		}
+		attachment.OwnerID = ownerID(eCtx)
+		attachment.CreatedAt, attachment.UpdatedAt = now, now
+		t.resources[attachment.ID] = *attachment
+		s.record(t, ownerID(eCtx), models.AuditOperationAdd, nil, attachment)
+	}
+
+	req.Resource.OwnerID = ownerID(eCtx)
+	req.Resource.CreatedAt, req.Resource.UpdatedAt = now, now
+	// the fake does not verify the files, the resources never have an integrity
+	req.Resource.Blob, req.Resource.Integrity = nil, nil
+	req.Resource.Checksum = strings.ToLower(req.Resource.Checksum)
+	t.resources[req.Resource.ID] = *req.Resource
+	s.record(t, ownerID(eCtx), models.AuditOperationAdd, nil, req.Resource)
+
+	return eCtx.JSON(http.StatusOK, req.Resource)
+}
+
+func (s *Server) getResource(eCtx echo.Context) error {
+	id, err := parseUUIDParam(eCtx, "resource_id")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resource, ok := s.tenant(tenantID(eCtx)).resources[id]
+	if !ok {
+		return fail(eCtx, http.StatusAccepted, client.ErrResourceNotFound)
+	}
+
+	return eCtx.JSON(http.StatusOK, resource)
+}
+
+func (s *Server) updateResource(eCtx echo.Context) error {
+	resource := &models.Resource{}
+	if err := s.bind(eCtx, resource); err != nil {
+		return err
+	}
+	if !validSchedule(resource) {
+		return fail(eCtx, http.StatusBadRequest, client.ErrInvalidSchedule)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	before, ok := t.resources[resource.ID]
+	if !ok {
+		return fail(eCtx, http.StatusAccepted, client.ErrResourceNotFound)
+	}
+	if !t.hasCategory(resource.Category) {
+		return fail(eCtx, http.StatusBadRequest, client.ErrCategoryNotFound)
+	}
+
+	resource.OwnerID, resource.CreatedAt, resource.UpdatedAt = before.OwnerID, before.CreatedAt, now()
+	resource.Blob, resource.Integrity = before.Blob, nil
+	resource.Checksum = strings.ToLower(resource.Checksum)
+	t.resources[resource.ID] = *resource
+	s.record(t, ownerID(eCtx), models.AuditOperationUpdate, &before, resource)
+
+	return eCtx.NoContent(http.StatusCreated)
+}
+
+func (s *Server) deleteResource(eCtx echo.Context) error {
+	req := &httpModels.DeleteResourceRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	ids := []uuid.UUID{req.ID}
+	for k := range req.Content {
+		if id, err := uuid.Parse(k); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		if _, ok := t.resources[id]; !ok {
+			return fail(eCtx, http.StatusAccepted, client.ErrResourceNotFound)
+		}
+	}
+	for _, id := range ids {
+		before := t.resources[id]
+		delete(t.resources, id)
+		delete(t.blobs, id)
+		s.record(t, ownerID(eCtx), models.AuditOperationDelete, &before, nil)
+	}
+
+	return eCtx.NoContent(http.StatusOK)
+}
+
+func (s *Server) getResourcesByIDs(eCtx echo.Context) error {
+	req := &httpModels.GetResourcesByIDsRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+	if len(req.UUIDs) > httpModels.MaxResourceIDs {
+		return fail(eCtx, http.StatusBadRequest, client.ErrLookupTooLarge)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	resources := make([]models.Resource, 0, len(req.UUIDs))
+	for _, id := range req.UUIDs {
+		if resource, ok := t.resources[id]; ok {
+			resources = append(resources, resource)
+		}
+	}
+	resources, err := listResources(resources, &req.ResourceListRequest)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return fail(eCtx, http.StatusAccepted, client.ErrResourceNotFound)
+	}
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resources})
+}
+
+func (s *Server) lookupResources(eCtx echo.Context) error {
+	req := &httpModels.LookupResourcesRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+	if len(req.IDs) > httpModels.MaxResourceIDs {
+		return fail(eCtx, http.StatusBadRequest, client.ErrLookupTooLarge)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	now := time.Now()
+	find := func(id uuid.UUID) (models.Resource, bool) {
+		resource, ok := t.resources[id]
+		if !ok || (!req.IncludeHidden && resource.Hidden(now)) {
+			return models.Resource{}, false
+		}
+		return resource, true
+	}
+
+	resp := &httpModels.LookupResourcesResponse{Resources: make([]models.Resource, 0), Missing: make([]uuid.UUID, 0)}
+	seen := make(map[uuid.UUID]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if resource, ok := find(id); ok {
+			resp.Resources = append(resp.Resources, resource)
+		} else {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+
+	if req.IncludeAttachments {
+		attachmentIDs := make(map[uuid.UUID]bool)
+		for _, resource := range resp.Resources {
+			for key := range resource.Content {
+				if id, err := uuid.Parse(key); key != models.LocationKey && err == nil {
+					attachmentIDs[id] = true
+				}
+			}
+		}
+		if len(seen)+len(attachmentIDs) > httpModels.MaxResourceIDs {
+			return fail(eCtx, http.StatusBadRequest, client.ErrLookupTooLarge)
+		}
+
+		resp.Attachments = make(map[string]models.Resource)
+		for id := range attachmentIDs {
+			if attachment, ok := find(id); ok {
+				resp.Attachments[id.String()] = attachment
+			}
+		}
+	}
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+}
+
+func (s *Server) getResourcesByCategory(eCtx echo.Context) error {
+	req := &httpModels.GetResourcesByCategoryRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resources := make([]models.Resource, 0)
+	for _, resource := range s.tenant(tenantID(eCtx)).resources {
+		if resource.Category == req.Category {
+			resources = append(resources, resource)
+		}
+	}
+	resources, err := listResources(resources, &req.ResourceListRequest)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return fail(eCtx, http.StatusAccepted, client.ErrResourceNotFound)
+	}
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resources})
+}
+
+func (s *Server) getCategories(eCtx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.provisioned(tenantID(eCtx)) {
+		return fail(eCtx, http.StatusForbidden, client.ErrTenantNotFound)
+	}
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: s.tenant(tenantID(eCtx)).categories})
+}
+
+func (t *tenant) hasCategory(id int) bool {
+	for _, category := range t.categories {
+		if category.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// contentCategory is the category of the attachments of the tenant.
+func (t *tenant) contentCategory() int {
+	for _, category := range t.categories {
+		if category.Name == models.CategoryContent {
+			return category.ID
+		}
+	}
+	return 0
+}
+
+func (s *Server) getAuditEvents(eCtx echo.Context) error {
+	req := &httpModels.GetAuditEventsRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	events := make([]models.AuditEvent, 0)
+	for i := len(t.audit) - 1; i >= 0; i-- {
+		event := t.audit[i]
+		if req.ResourceID != nil && event.ResourceID != *req.ResourceID {
+			continue
+		}
+		if req.Actor != "" && event.Actor != req.Actor {
+			continue
+		}
+		if req.Since != nil && event.CreatedAt.Before(*req.Since) {
+			continue
+		}
+		if req.Until != nil && !event.CreatedAt.Before(*req.Until) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if req.Offset >= len(events) {
+		events = events[:0]
+	} else {
+		events = events[req.Offset:]
+	}
+	if req.Limit > 0 && len(events) > req.Limit {
+		events = events[:req.Limit]
+	}
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: events})
+}
+
+// streamResources sends the change events of the tenant as server-sent events until the client or the server goes away.
+func (s *Server) streamResources(eCtx echo.Context) error {
+	category, _ := strconv.Atoi(eCtx.QueryParam("category"))
+	includeHidden, _ := strconv.ParseBool(eCtx.QueryParam("include_hidden"))
+	lastEventID, _ := strconv.ParseInt(eCtx.Request().Header.Get("Last-Event-ID"), 10, 64)
+
+	s.mu.Lock()
+	if lastEventID == 0 {
+		lastEventID = s.nextEventID
+	}
+	s.mu.Unlock()
+
+	w := eCtx.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	for {
+		s.mu.Lock()
+		var pending []client.StreamEvent
+		for _, event := range s.tenant(tenantID(eCtx)).events {
+			if event.ID <= lastEventID || (category != 0 && event.Resource.Category != category) {
+				continue
+			}
+			// the changes of the resources hidden by their schedule are skipped, like the service does
+			if !includeHidden && event.Resource.Hidden(time.Now()) {
+				lastEventID = event.ID
+				continue
+			}
+			pending = append(pending, event)
+		}
+		changed := s.eventsChanged
+		s.mu.Unlock()
+
+		for _, event := range pending {
+			data, err := json.Marshal(event.Resource)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return nil
+			}
+			lastEventID = event.ID
+		}
+		w.Flush()
+
+		select {
+		case <-changed:
+		case <-eCtx.Request().Context().Done():
+			return nil
+		case <-s.done:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package clienttest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/client"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+)
+
+func (s *Server) createSubscription(eCtx echo.Context) error {
+	req := &httpModels.WebhookSubscriptionRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+	if req.Secret == "" {
+		return fail(eCtx, http.StatusBadRequest, client.ErrWebhookSecretRequired)
+	}
+
+	now := time.Now().UTC()
+	subscription := models.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        req.URL,
+		Categories: req.Categories,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+		Active:     req.Active == nil || *req.Active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.provisioned(tenantID(eCtx)) {
+		return fail(eCtx, http.StatusForbidden, client.ErrTenantNotFound)
+	}
+	s.tenant(tenantID(eCtx)).subscriptions[subscription.ID] = subscription
+
+	return eCtx.JSON(http.StatusCreated, httpModels.ResponseData{Data: subscription})
+}
+
+func (s *Server) getSubscriptions(eCtx echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := make([]models.WebhookSubscription, 0)
+	for _, subscription := range s.tenant(tenantID(eCtx)).subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: subscriptions})
+}
+
+func (s *Server) getSubscription(eCtx echo.Context) error {
+	id, err := parseUUIDParam(eCtx, "subscription_id")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, ok := s.tenant(tenantID(eCtx)).subscriptions[id]
+	if !ok {
+		return fail(eCtx, http.StatusNotFound, client.ErrWebhookSubscriptionNotFound)
+	}
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: subscription})
+}
+
+func (s *Server) updateSubscription(eCtx echo.Context) error {
+	id, err := parseUUIDParam(eCtx, "subscription_id")
+	if err != nil {
+		return err
+	}
+	req := &httpModels.WebhookSubscriptionRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	subscription, ok := t.subscriptions[id]
+	if !ok {
+		return fail(eCtx, http.StatusNotFound, client.ErrWebhookSubscriptionNotFound)
+	}
+
+	subscription.URL = req.URL
+	subscription.Categories = req.Categories
+	subscription.EventTypes = req.EventTypes
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	subscription.Active = req.Active == nil || *req.Active
+	if subscription.Active {
+		subscription.ConsecutiveFailures = 0
+	}
+	subscription.UpdatedAt = time.Now().UTC()
+	t.subscriptions[id] = subscription
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: subscription})
+}
+
+func (s *Server) deleteSubscription(eCtx echo.Context) error {
+	id, err := parseUUIDParam(eCtx, "subscription_id")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	if _, ok := t.subscriptions[id]; !ok {
+		return fail(eCtx, http.StatusNotFound, client.ErrWebhookSubscriptionNotFound)
+	}
+	delete(t.subscriptions, id)
+
+	return eCtx.NoContent(http.StatusOK)
+}
+
+func (s *Server) getDeadLetters(eCtx echo.Context) error {
+	req := &httpModels.GetWebhookDeadLettersRequest{}
+	if err := s.bind(eCtx, req); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, delivery := range s.tenant(tenantID(eCtx)).deadLetters {
+		if req.SubscriptionID == nil || delivery.SubscriptionID == *req.SubscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if req.Limit > 0 && len(deliveries) > req.Limit {
+		deliveries = deliveries[:req.Limit]
+	}
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: deliveries})
+}
+
+func (s *Server) replayDeadLetter(eCtx echo.Context) error {
+	id, err := strconv.ParseInt(eCtx.Param("delivery_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid delivery_id")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenant(tenantID(eCtx))
+
+	if _, ok := t.deadLetters[id]; !ok {
+		return fail(eCtx, http.StatusNotFound, client.ErrWebhookDeliveryNotFound)
+	}
+	// the fake has no delivery worker, a replayed delivery simply leaves the dead-letter list
+	delete(t.deadLetters, id)
+
+	return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: "OK"})
+}
@@ -0,0 +1,602 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+	"github.com/proemergotech/log/v3/zaplog"
+	"go.uber.org/zap"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	"github.com/artofimagination/mysql-resources-db-go-service/client"
+	"github.com/artofimagination/mysql-resources-db-go-service/client/clienttest"
+	"github.com/artofimagination/mysql-resources-db-go-service/di"
+	"github.com/artofimagination/mysql-resources-db-go-service/gql"
+	"github.com/artofimagination/mysql-resources-db-go-service/health"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/rest"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+)
+
+// The contract tests run the same scenarios with the client against the fake of clienttest and against the echo handler
+// of the service, so the fake cannot drift away from the service the client consumers run against in production.
+
+type emptyContextMapper struct{}
+
+func (emptyContextMapper) Values(context.Context) map[string]string {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	log.SetGlobalLogger(zaplog.NewLogger(zap.NewNop(), emptyContextMapper{}))
+	os.Exit(m.Run())
+}
+
+// backend is a server the contract is checked against.
+type backend struct {
+	url       string
+	addTenant func(t *testing.T, tenantID string)
+}
+
+func (b *backend) client(tenantID string) *client.Client {
+	c, err := client.New(b.url, client.WithTenant(tenantID), client.WithOwner("contract-owner"),
+		client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func newFakeBackend(t *testing.T) *backend {
+	server := clienttest.NewServer()
+	t.Cleanup(server.Close)
+
+	return &backend{
+		url: server.URL,
+		addTenant: func(_ *testing.T, tenantID string) {
+			server.AddTenant(tenantID)
+		},
+	}
+}
+
+// newServiceBackend serves the routes of the service from a SQLite database, wired like the container does.
+func newServiceBackend(t *testing.T) *backend {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "resources.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	sqlStorage := storage.NewSQLite(db, 0, nil)
+	if err := sqlStorage.BootstrapSystem(""); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewService(sqlStorage, blobs, 100<<20, "", false)
+
+	v, err := di.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	graphQL, err := gql.NewServer(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cachePolicies, err := rest.ParseCachePolicies("private, no-cache", "", "X-Tenant-ID", "X-Owner-ID")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(rest.RequestInfoMiddleware())
+	e.Use(rest.IdentityMiddleware("X-Tenant-ID", "X-Owner-ID"))
+	e.HTTPErrorHandler = rest.DLiveRHTTPErrorHandler
+	e.Validator = v
+	controller := rest.NewController(e, svc, graphQL, health.NewReadiness(), false, false, cachePolicies, 10*time.Millisecond, time.Second)
+	controller.Start()
+
+	server := httptest.NewServer(e)
+	t.Cleanup(func() {
+		controller.Stop()
+		server.Close()
+	})
+
+	// the change log sequencer of the container, the streams only see the sequenced events
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = svc.SequenceChangeEvents(ctx)
+			}
+		}
+	}()
+
+	return &backend{
+		url: server.URL,
+		addTenant: func(t *testing.T, tenantID string) {
+			if err := svc.AddTenant(context.Background(), tenantID, nil); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+}
+
+func TestContract(t *testing.T) {
+	backends := map[string]func(t *testing.T) *backend{
+		"fake":    newFakeBackend,
+		"service": newServiceBackend,
+	}
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t)
+			t.Run("categories", func(t *testing.T) { testCategories(t, b) })
+			t.Run("resources", func(t *testing.T) { testResources(t, b) })
+			t.Run("errors", func(t *testing.T) { testErrors(t, b) })
+			t.Run("lookup", func(t *testing.T) { testLookup(t, b) })
+			t.Run("blobs", func(t *testing.T) { testBlobs(t, b) })
+			t.Run("audit", func(t *testing.T) { testAudit(t, b) })
+			t.Run("webhooks", func(t *testing.T) { testWebhooks(t, b) })
+			t.Run("stream", func(t *testing.T) { testStream(t, b) })
+		})
+	}
+}
+
+// newTenant provisions a new tenant and returns its client with its categories by name.
+func newTenant(t *testing.T, b *backend) (*client.Client, map[string]int) {
+	t.Helper()
+	tenantID := "contract-" + uuid.New().String()[:8]
+	b.addTenant(t, tenantID)
+
+	c := b.client(tenantID)
+	categories, err := c.GetCategories(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]int, len(categories))
+	for _, category := range categories {
+		byName[category.Name] = category.ID
+	}
+	return c, byName
+}
+
+func newsFeed(categories map[string]int) *models.Resource {
+	return &models.Resource{
+		ID:       uuid.New(),
+		Category: categories["News feed"],
+		Content:  models.ContentMap{models.LocationKey: "https://example.com/" + uuid.New().String()},
+	}
+}
+
+func assertKind(t *testing.T, err error, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("got %v, want %v", err, want)
+	}
+}
+
+func testCategories(t *testing.T, b *backend) {
+	ctx := context.Background()
+	_, categories := newTenant(t, b)
+	if len(categories) != len(clienttest.DefaultCategories) {
+		t.Errorf("categories = %v, want the ones of the default tenant", categories)
+	}
+	for _, category := range clienttest.DefaultCategories {
+		if _, ok := categories[category.Name]; !ok {
+			t.Errorf("category %q is missing", category.Name)
+		}
+	}
+
+	defaultCategories, err := b.client("default").GetCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, category := range defaultCategories {
+		if category.ID != clienttest.DefaultCategories[i].ID || category.Name != clienttest.DefaultCategories[i].Name {
+			t.Errorf("default category %v, want %v", category, clienttest.DefaultCategories[i])
+		}
+	}
+
+	_, err = b.client("contract-unprovisioned").GetCategories(ctx)
+	assertKind(t, err, client.ErrTenantNotFound)
+}
+
+func testResources(t *testing.T, b *backend) {
+	ctx := context.Background()
+	c, categories := newTenant(t, b)
+
+	attachmentID := uuid.New()
+	resource := newsFeed(categories)
+	resource.Content[attachmentID.String()] = "https://example.com/attachment"
+	added, err := c.AddResource(ctx, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.ID != resource.ID || added.CreatedAt.IsZero() {
+		t.Errorf("added %v", added)
+	}
+
+	got, err := c.GetResource(ctx, resource.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OwnerID != "contract-owner" || got.Category != resource.Category || got.Content[models.LocationKey] != resource.Content[models.LocationKey] {
+		t.Errorf("got %v, want %v", got, resource)
+	}
+
+	attachment, err := c.GetResource(ctx, attachmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.Category != categories[models.CategoryContent] || attachment.Content[models.LocationKey] != "https://example.com/attachment" {
+		t.Errorf("attachment %v, want it in the content category of the tenant", attachment)
+	}
+
+	resource.Content[models.LocationKey] = "https://example.com/updated"
+	if err := c.UpdateResource(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := c.GetResourcesByIDs(ctx, []uuid.UUID{resource.ID, uuid.New()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].Content[models.LocationKey] != "https://example.com/updated" {
+		t.Errorf("listed %v, want the updated resource", listed)
+	}
+
+	byCategory, err := c.GetResourcesByCategory(ctx, categories[models.CategoryContent], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byCategory) != 1 || byCategory[0].ID != attachmentID {
+		t.Errorf("listed %v, want the attachment", byCategory)
+	}
+
+	hidden := newsFeed(categories)
+	publishAt := time.Now().Add(time.Hour).UTC()
+	hidden.PublishAt = &publishAt
+	if _, err := c.AddResource(ctx, hidden); err != nil {
+		t.Fatal(err)
+	}
+	visible, err := c.GetResourcesByCategory(ctx, categories["News feed"], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visible) != 1 || visible[0].ID != resource.ID {
+		t.Errorf("listed %v, want the published resource only", visible)
+	}
+	all, err := c.GetResourcesByCategory(ctx, categories["News feed"], &httpModels.ResourceListRequest{IncludeHidden: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("listed %d resources with the hidden ones, want 2", len(all))
+	}
+
+	if err := c.DeleteResource(ctx, resource.ID, resource.Content); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetResource(ctx, resource.ID)
+	assertKind(t, err, client.ErrResourceNotFound)
+	_, err = c.GetResource(ctx, attachmentID)
+	assertKind(t, err, client.ErrResourceNotFound)
+	_, err = c.GetResourcesByIDs(ctx, []uuid.UUID{resource.ID}, nil)
+	assertKind(t, err, client.ErrResourceNotFound)
+}
+
+func testErrors(t *testing.T, b *backend) {
+	ctx := context.Background()
+	c, categories := newTenant(t, b)
+
+	resource := newsFeed(categories)
+	if _, err := c.AddResource(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("already exists", func(t *testing.T) {
+		_, err := c.AddResource(ctx, resource)
+		assertKind(t, err, client.ErrResourceAlreadyExists)
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		unknown := newsFeed(categories)
+		unknown.Category = 1 << 30
+		_, err := c.AddResource(ctx, unknown)
+		assertKind(t, err, client.ErrCategoryNotFound)
+
+		updated := *resource
+		updated.Category = 1 << 30
+		assertKind(t, c.UpdateResource(ctx, &updated), client.ErrCategoryNotFound)
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		scheduled := newsFeed(categories)
+		publishAt, expireAt := time.Now().Add(time.Hour).UTC(), time.Now().UTC()
+		scheduled.PublishAt, scheduled.ExpireAt = &publishAt, &expireAt
+		_, err := c.AddResource(ctx, scheduled)
+		assertKind(t, err, client.ErrInvalidSchedule)
+	})
+
+	t.Run("missing resource", func(t *testing.T) {
+		_, err := c.GetResource(ctx, uuid.New())
+		assertKind(t, err, client.ErrResourceNotFound)
+		assertKind(t, c.UpdateResource(ctx, newsFeed(categories)), client.ErrResourceNotFound)
+		assertKind(t, c.DeleteResource(ctx, uuid.New(), nil), client.ErrResourceNotFound)
+	})
+
+	t.Run("other tenant", func(t *testing.T) {
+		other, _ := newTenant(t, b)
+		_, err := other.GetResource(ctx, resource.ID)
+		assertKind(t, err, client.ErrResourceNotFound)
+	})
+
+	t.Run("unprovisioned tenant", func(t *testing.T) {
+		unprovisioned := b.client("contract-unprovisioned")
+		_, err := unprovisioned.AddResource(ctx, newsFeed(categories))
+		assertKind(t, err, client.ErrTenantNotFound)
+		_, err = unprovisioned.GetResource(ctx, resource.ID)
+		assertKind(t, err, client.ErrResourceNotFound)
+		_, err = unprovisioned.CreateWebhookSubscription(ctx, &httpModels.WebhookSubscriptionRequest{URL: "https://203.0.113.10/hook", Secret: "contract-secret-0123"})
+		assertKind(t, err, client.ErrTenantNotFound)
+	})
+
+	t.Run("missing webhook", func(t *testing.T) {
+		_, err := c.GetWebhookSubscription(ctx, uuid.New())
+		assertKind(t, err, client.ErrWebhookSubscriptionNotFound)
+		assertKind(t, c.ReplayWebhookDelivery(ctx, 1<<40), client.ErrWebhookDeliveryNotFound)
+	})
+}
+
+func testLookup(t *testing.T, b *backend) {
+	ctx := context.Background()
+	c, categories := newTenant(t, b)
+
+	attachmentID := uuid.New()
+	resource := newsFeed(categories)
+	resource.Content[attachmentID.String()] = "https://example.com/attachment"
+	if _, err := c.AddResource(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := uuid.New()
+	resp, err := c.LookupResources(ctx, []uuid.UUID{resource.ID, missing, resource.ID}, &httpModels.LookupResourcesRequest{IncludeAttachments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Resources) != 1 || resp.Resources[0].ID != resource.ID {
+		t.Errorf("resources = %v, want the one added", resp.Resources)
+	}
+	if len(resp.Missing) != 1 || resp.Missing[0] != missing {
+		t.Errorf("missing = %v, want %v", resp.Missing, missing)
+	}
+	if attachment, ok := resp.Attachments[attachmentID.String()]; !ok || attachment.ID != attachmentID {
+		t.Errorf("attachments = %v, want the attachment of the resource", resp.Attachments)
+	}
+
+	ids := make([]uuid.UUID, httpModels.MaxResourceIDs+1)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	_, err = c.LookupResources(ctx, ids, nil)
+	assertKind(t, err, client.ErrLookupTooLarge)
+	_, err = c.GetResourcesByIDs(ctx, ids, nil)
+	assertKind(t, err, client.ErrLookupTooLarge)
+}
+
+func testBlobs(t *testing.T, b *backend) {
+	ctx := context.Background()
+	c, categories := newTenant(t, b)
+
+	resource := newsFeed(categories)
+	if _, err := c.AddResource(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.DownloadResourceBlob(ctx, resource.ID, 0)
+	assertKind(t, err, client.ErrBlobNotFound)
+	_, err = c.UploadResourceBlob(ctx, uuid.New(), bytes.NewReader([]byte("data")), "text/plain")
+	assertKind(t, err, client.ErrResourceNotFound)
+
+	data := []byte("the content of the blob")
+	uploaded, err := c.UploadResourceBlob(ctx, resource.ID, bytes.NewReader(data), "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploaded.Blob == nil || uploaded.Blob.Size != int64(len(data)) || uploaded.Blob.ContentType != "text/plain" {
+		t.Errorf("blob = %v, want the uploaded one", uploaded.Blob)
+	}
+
+	body, err := c.DownloadResourceBlob(ctx, resource.ID, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	downloaded, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data[5:]) {
+		t.Errorf("downloaded %q, want %q", downloaded, data[5:])
+	}
+}
+
+func testAudit(t *testing.T, b *backend) {
+	ctx := context.Background()
+	c, categories := newTenant(t, b)
+
+	resource := newsFeed(categories)
+	if _, err := c.AddResource(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+	resource.Content[models.LocationKey] = "https://example.com/updated"
+	if err := c.UpdateResource(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := c.GetAuditEvents(ctx, &httpModels.GetAuditEventsRequest{ResourceID: &resource.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Operation != models.AuditOperationUpdate || events[1].Operation != models.AuditOperationAdd {
+		t.Fatalf("events = %v, want the update then the add", events)
+	}
+	if events[0].Actor != "contract-owner" {
+		t.Errorf("actor = %q, want the owner of the client", events[0].Actor)
+	}
+	if events[0].Diff.Before == nil || events[0].Diff.After == nil || events[0].Diff.After.Content[models.LocationKey] != "https://example.com/updated" {
+		t.Errorf("diff = %v, want the update", events[0].Diff)
+	}
+
+	limited, err := c.GetAuditEvents(ctx, &httpModels.GetAuditEventsRequest{ResourceID: &resource.ID, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited) != 1 || limited[0].Operation != models.AuditOperationAdd {
+		t.Errorf("events = %v, want the add", limited)
+	}
+}
+
+func testWebhooks(t *testing.T, b *backend) {
+	ctx := context.Background()
+	c, categories := newTenant(t, b)
+
+	_, err := c.CreateWebhookSubscription(ctx, &httpModels.WebhookSubscriptionRequest{URL: "https://203.0.113.10/hook"})
+	assertKind(t, err, client.ErrWebhookSecretRequired)
+	_, err = c.CreateWebhookSubscription(ctx, &httpModels.WebhookSubscriptionRequest{URL: "https://203.0.113.10/hook", Secret: "short"})
+	assertKind(t, err, client.ErrValidation)
+	_, err = c.CreateWebhookSubscription(ctx, &httpModels.WebhookSubscriptionRequest{URL: "not a url", Secret: "contract-secret-0123"})
+	assertKind(t, err, client.ErrValidation)
+
+	created, err := c.CreateWebhookSubscription(ctx, &httpModels.WebhookSubscriptionRequest{
+		URL:        "https://203.0.113.10/hook",
+		Secret:     "contract-secret-0123",
+		Categories: []int{categories["News feed"]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Active || created.URL != "https://203.0.113.10/hook" {
+		t.Errorf("created %v", created)
+	}
+
+	inactive := false
+	updated, err := c.UpdateWebhookSubscription(ctx, created.ID, &httpModels.WebhookSubscriptionRequest{URL: "https://203.0.113.11/hook", Active: &inactive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Active || updated.URL != "https://203.0.113.11/hook" {
+		t.Errorf("updated %v", updated)
+	}
+
+	got, err := c.GetWebhookSubscription(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.URL != updated.URL || got.Active {
+		t.Errorf("got %v, want %v", got, updated)
+	}
+
+	subscriptions, err := c.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subscriptions) != 1 || subscriptions[0].ID != created.ID {
+		t.Errorf("subscriptions = %v, want the created one", subscriptions)
+	}
+
+	deadLetters, err := c.GetWebhookDeadLetters(ctx, &created.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters) != 0 {
+		t.Errorf("dead letters = %v, want none", deadLetters)
+	}
+
+	if err := c.DeleteWebhookSubscription(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetWebhookSubscription(ctx, created.ID)
+	assertKind(t, err, client.ErrWebhookSubscriptionNotFound)
+	assertKind(t, c.DeleteWebhookSubscription(ctx, created.ID), client.ErrWebhookSubscriptionNotFound)
+}
+
+func testStream(t *testing.T, b *backend) {
+	c, categories := newTenant(t, b)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan client.StreamEvent, 16)
+	streamed := make(chan error, 1)
+	go func() {
+		streamed <- c.StreamResources(ctx, categories["News feed"], 0, func(event *client.StreamEvent) error {
+			events <- *event
+			return nil
+		})
+	}()
+
+	// the stream starts at the end of the change log when it connects, resources are added until one of them is seen,
+	// each after a resource published in the future, which is never streamed before it is published
+	added := make(map[uuid.UUID]bool)
+	embargoed := make(map[uuid.UUID]bool)
+	publishAt := time.Now().Add(time.Hour).UTC()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-events:
+			if embargoed[event.Resource.ID] {
+				t.Fatalf("streamed %v before its publication", event)
+			}
+			if !added[event.Resource.ID] {
+				t.Fatalf("streamed %v, want one of the resources added", event)
+			}
+			if event.ID == 0 || event.Type != models.EventResourceCreated {
+				t.Errorf("streamed %v, want a %s event", event, models.EventResourceCreated)
+			}
+			cancel()
+			if err := <-streamed; err != nil {
+				t.Fatal(err)
+			}
+			return
+		case <-ticker.C:
+			hidden := newsFeed(categories)
+			hidden.PublishAt = &publishAt
+			if _, err := c.AddResource(ctx, hidden); err != nil {
+				t.Fatal(err)
+			}
+			embargoed[hidden.ID] = true
+
+			resource := newsFeed(categories)
+			if _, err := c.AddResource(ctx, resource); err != nil {
+				t.Fatal(err)
+			}
+			added[resource.ID] = true
+		case err := <-streamed:
+			t.Fatalf("the stream ended with %v before an event", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The errors of the server error catalog. Use errors.Is to match them against the errors returned by the Client.
+var (
+	ErrResourceNotFound              = errors.New("The selected resource not found")
+	ErrResourceAlreadyExists         = errors.New("The resource already exists")
+	ErrResourceHasTooManyAttachments = errors.New("The resource has too many attachements")
+	ErrCategoryNotFound              = errors.New("The selected category not found")
+	ErrTenantQuotaExceeded           = errors.New("The resource quota of the tenant is exceeded")
+	ErrTenantNotFound                = errors.New("The tenant is not provisioned")
+	ErrInvalidSchedule               = errors.New("The resource must expire after it is published")
+	ErrBlobNotFound                  = errors.New("The resource has no blob")
+	ErrBlobTooLarge                  = errors.New("The blob exceeds the size limit")
+	ErrLookupTooLarge                = errors.New("The lookup exceeds the maximum number of resources")
+	ErrWebhookSubscriptionNotFound   = errors.New("The selected webhook subscription not found")
+	ErrWebhookDeliveryNotFound       = errors.New("The selected webhook delivery not found")
+	ErrWebhookSecretRequired         = errors.New("The webhook secret is required")
+	ErrRateLimited                   = errors.New("The rate limit is exceeded")
+	ErrClientCertificateRequired     = errors.New("A client certificate is required")
+	ErrValidation                    = errors.New("validation error")
+	ErrRouteNotFound                 = errors.New("route not found")
+	ErrMethodNotAllowed              = errors.New("method not allowed")
+)
+
+// catalog lists the known server errors. The server prefixes the messages with their context,
+// so they are matched as substrings of the error message in the response.
+var catalog = []error{
+	ErrResourceNotFound,
+	ErrResourceAlreadyExists,
+	ErrResourceHasTooManyAttachments,
+	ErrCategoryNotFound,
+	ErrTenantQuotaExceeded,
+	ErrTenantNotFound,
+	ErrInvalidSchedule,
+	ErrBlobNotFound,
+	ErrBlobTooLarge,
+	ErrLookupTooLarge,
+	ErrWebhookSubscriptionNotFound,
+	ErrWebhookDeliveryNotFound,
+	ErrWebhookSecretRequired,
+	ErrRateLimited,
+	ErrClientCertificateRequired,
+	ErrValidation,
+	ErrRouteNotFound,
+	ErrMethodNotAllowed,
+}
+
+// Error is returned for every request the server answered with an error.
+type Error struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Message is the error message sent by the server.
+	Message string
+	// Kind is the matching error of the catalog, nil if the message is unknown.
+	Kind error
+	// RetryAfter is how long the server asked to wait before repeating the request, zero if it did not.
+	RetryAfter time.Duration
+	// RequestID identifies the request in the logs of the server.
+	RequestID string
+}
+
+func newError(resp *http.Response, message string) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		RequestID:  resp.Header.Get(requestIDHeader),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	for _, kind := range catalog {
+		if strings.Contains(message, kind.Error()) {
+			e.Kind = kind
+			break
+		}
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("resources server responded %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("resources server responded %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Temporary reports whether repeating the request may succeed.
+func (e *Error) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
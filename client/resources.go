@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+)
+
+// AddResource stores a new resource together with the attachments listed in its content.
+func (c *Client) AddResource(ctx context.Context, resource *models.Resource) (*models.Resource, error) {
+	resp := &models.Resource{}
+	err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/api/v1/resources/" + resource.ID.String() + "/",
+		body:   httpModels.AddResourceRequest{UUID: resource.ID, Resource: resource},
+		out:    resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetResource returns the resource with the given id.
+func (c *Client) GetResource(ctx context.Context, id uuid.UUID) (*models.Resource, error) {
+	resp := &models.Resource{}
+	err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/api/v1/resources/" + id.String() + "/",
+		out:    resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// UpdateResource replaces the resource, new attachments in its content are created as well.
+func (c *Client) UpdateResource(ctx context.Context, resource *models.Resource) error {
+	return c.do(ctx, &request{
+		method: http.MethodPut,
+		path:   "/api/v1/resources/" + resource.ID.String() + "/",
+		body:   resource,
+	})
+}
+
+// DeleteResource deletes the resource and the attachments listed in content.
+func (c *Client) DeleteResource(ctx context.Context, id uuid.UUID, content models.ContentMap) error {
+	return c.do(ctx, &request{
+		method: http.MethodDelete,
+		path:   "/api/v1/resources/" + id.String() + "/",
+		body:   httpModels.DeleteResourceRequest{ID: id, Content: content},
+	})
+}
+
+// GetResourcesByIDs returns the resources found with the given ids, at most httpModels.MaxResourceIDs of them.
+// list orders and filters them, it may be nil.
+func (c *Client) GetResourcesByIDs(ctx context.Context, ids []uuid.UUID, list *httpModels.ResourceListRequest) ([]models.Resource, error) {
+	query := listQuery(list)
+	for _, id := range ids {
+		query.Add("ids", id.String())
+	}
+
+	var resp []models.Resource
+	err := c.do(ctx, &request{
+		method:  http.MethodGet,
+		path:    "/api/v1/resources/",
+		query:   query,
+		out:     &resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// LookupResources returns the resources with the given ids in their order and the ids not found,
+// at most httpModels.MaxResourceIDs of them with the attachments. req.IDs may be left empty, ids are sent instead.
+func (c *Client) LookupResources(ctx context.Context, ids []uuid.UUID, req *httpModels.LookupResourcesRequest) (*httpModels.LookupResourcesResponse, error) {
+	body := httpModels.LookupResourcesRequest{}
+	if req != nil {
+		body = *req
+	}
+	body.IDs = ids
+
+	resp := &httpModels.LookupResourcesResponse{}
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    "/api/v1/resources/lookup",
+		body:    body,
+		out:     resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetResourcesByCategory returns the resources of the category. list orders and filters them, it may be nil.
+func (c *Client) GetResourcesByCategory(ctx context.Context, category int, list *httpModels.ResourceListRequest) ([]models.Resource, error) {
+	var resp []models.Resource
+	err := c.do(ctx, &request{
+		method:  http.MethodGet,
+		path:    "/api/v1/resources/categories/" + strconv.Itoa(category),
+		query:   listQuery(list),
+		out:     &resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func listQuery(list *httpModels.ResourceListRequest) url.Values {
+	query := url.Values{}
+	if list == nil {
+		return query
+	}
+
+	if list.Sort != "" {
+		query.Set("sort", list.Sort)
+	}
+	if list.Since != nil {
+		query.Set("since", list.Since.Format(time.RFC3339Nano))
+	}
+	if list.Until != nil {
+		query.Set("until", list.Until.Format(time.RFC3339Nano))
+	}
+	if list.IncludeHidden {
+		query.Set("include_hidden", "true")
+	}
+	return query
+}
+
+// GetCategories returns the categories of the tenant.
+func (c *Client) GetCategories(ctx context.Context) ([]models.Category, error) {
+	var resp []models.Category
+	err := c.do(ctx, &request{
+		method:  http.MethodGet,
+		path:    "/api/v1/categories/",
+		out:     &resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Healthcheck returns nil if the server is up.
+func (c *Client) Healthcheck(ctx context.Context) error {
+	return c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/healthcheck",
+	})
+}
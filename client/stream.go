@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// StreamEvent is a resource change received from the resource stream.
+type StreamEvent struct {
+	ID       int64
+	Type     string
+	Resource models.Resource
+}
+
+// StreamResources follows the resource changes of the tenant and calls handle for each of them, in order.
+// A category of 0 streams every category. The stream starts after lastEventID, or at the current end of the change log if it is 0.
+// It returns when ctx is done, the server closes the stream or handle returns an error.
+// Streams are not retried, callers resume by passing the ID of the last handled event.
+func (c *Client) StreamResources(ctx context.Context, category int, lastEventID int64, handle func(*StreamEvent) error) error {
+	u := *c.baseURL
+	u.Path += "/api/v1/resources/stream"
+	query := url.Values{}
+	if category != 0 {
+		query.Set("category", strconv.Itoa(category))
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "cannot create request")
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatInt(lastEventID, 10))
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var env envelope
+		_ = json.NewDecoder(resp.Body).Decode(&env)
+		return newError(resp, env.Error)
+	}
+
+	event := &StreamEvent{}
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+			if err := json.Unmarshal([]byte(data.String()), &event.Resource); err != nil {
+				return errors.Wrap(err, "cannot decode stream event")
+			}
+			if err := handle(event); err != nil {
+				return err
+			}
+			event = &StreamEvent{}
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// comment, the heartbeats of the server
+		case strings.HasPrefix(line, "id: "):
+			event.ID, _ = strconv.ParseInt(strings.TrimPrefix(line, "id: "), 10, 64)
+		case strings.HasPrefix(line, "event: "):
+			event.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data.WriteString(strings.TrimPrefix(line, "data: "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return errors.Wrap(err, "stream failed")
+	}
+
+	return nil
+}
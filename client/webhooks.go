@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+)
+
+// CreateWebhookSubscription registers a new webhook subscription.
+func (c *Client) CreateWebhookSubscription(ctx context.Context, req *httpModels.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	resp := &models.WebhookSubscription{}
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    "/api/v1/webhooks/",
+		body:    req,
+		out:     resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetWebhookSubscriptions returns every webhook subscription of the tenant.
+func (c *Client) GetWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var resp []models.WebhookSubscription
+	err := c.do(ctx, &request{
+		method:  http.MethodGet,
+		path:    "/api/v1/webhooks/",
+		out:     &resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetWebhookSubscription returns the webhook subscription with the given id.
+func (c *Client) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	resp := &models.WebhookSubscription{}
+	err := c.do(ctx, &request{
+		method:  http.MethodGet,
+		path:    "/api/v1/webhooks/" + id.String() + "/",
+		out:     resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// UpdateWebhookSubscription replaces the webhook subscription. An empty secret keeps the current one.
+func (c *Client) UpdateWebhookSubscription(ctx context.Context, id uuid.UUID, req *httpModels.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	resp := &models.WebhookSubscription{}
+	err := c.do(ctx, &request{
+		method:  http.MethodPut,
+		path:    "/api/v1/webhooks/" + id.String() + "/",
+		body:    req,
+		out:     resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DeleteWebhookSubscription deletes the webhook subscription with the given id.
+func (c *Client) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, &request{
+		method: http.MethodDelete,
+		path:   "/api/v1/webhooks/" + id.String() + "/",
+	})
+}
+
+// GetWebhookDeadLetters returns the deliveries that ran out of attempts.
+// A nil subscriptionID returns the dead letters of every subscription, a zero limit uses the server default.
+func (c *Client) GetWebhookDeadLetters(ctx context.Context, subscriptionID *uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	query := url.Values{}
+	if subscriptionID != nil {
+		query.Set("subscription_id", subscriptionID.String())
+	}
+	if limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp []models.WebhookDelivery
+	err := c.do(ctx, &request{
+		method:  http.MethodGet,
+		path:    "/api/v1/webhooks/dead-letters",
+		query:   query,
+		out:     &resp,
+		wrapped: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReplayWebhookDelivery schedules a dead delivery for another round of attempts.
+func (c *Client) ReplayWebhookDelivery(ctx context.Context, deliveryID int64) error {
+	return c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/api/v1/webhooks/dead-letters/" + int64Path(deliveryID) + "/replay",
+	})
+}
@@ -1,17 +1,164 @@
 package config
 
+import "time"
+
 const AppName = "mysql-resources-db-go-service"
 
 var AppVersion string
 
+// Config holds the settings of the service, read from the environment and the optional config file.
+// The settings tagged reload are applied again when the configuration is reloaded, the others need a restart.
+// The ones tagged secret are redacted when the configuration is printed, they can also be read from the file named by
+// their _file variant, like MYSQL_DB_PASSWORD_FILE, or from the SecretsProvider.
+// The files named by the settings tagged watch are watched, their changes reload the configuration.
 type Config struct {
+	// LogLevel is the level of the logger, debug, info, warn or error.
+	LogLevel string `mapstructure:"log_level" default:"info" validate:"oneof=debug info warn error DEBUG INFO WARN ERROR" reload:"true"`
+
 	Port       int  `mapstructure:"server_port" default:"8080"`
 	DebugPProf bool `mapstructure:"debug_pprof" default:"false"`
+	// DocsUI serves a documentation page of the OpenAPI document at /api/v1/docs.
+	DocsUI bool `mapstructure:"docs_ui" default:"false"`
+
+	// TLSCertFile and TLSKeyFile enable TLS on the gRPC server and TLS and HTTP/2 on the HTTP server.
+	// TLSClientCAFile requires a client certificate signed by one of its CAs on every request and call but the health checks,
+	// the identity is taken from the certificate subject instead of the TenantHeader and the OwnerHeader or the metadata:
+	// the tenant from its TLSClientTenantAttribute and the owner from its TLSClientOwnerAttribute, CN, O or OU.
+	TLSCertFile              string `mapstructure:"tls_cert_file" validate:"required_with=TLSKeyFile TLSClientCAFile" watch:"true"`
+	TLSKeyFile               string `mapstructure:"tls_key_file" validate:"required_with=TLSCertFile" watch:"true"`
+	TLSClientCAFile          string `mapstructure:"tls_client_ca_file" watch:"true"`
+	TLSClientTenantAttribute string `mapstructure:"tls_client_tenant_attribute" default:"O" validate:"oneof=CN O OU"`
+	TLSClientOwnerAttribute  string `mapstructure:"tls_client_owner_attribute" default:"CN" validate:"oneof=CN O OU"`
+
+	// ShutdownDrainDelay is how long the readiness check fails before the servers stop, so the load balancers stop
+	// sending requests first. ShutdownTimeout bounds the stop of the components after it, finishing the requests in flight.
+	ShutdownDrainDelay time.Duration `mapstructure:"shutdown_drain_delay" default:"5s" validate:"min=0"`
+	ShutdownTimeout    time.Duration `mapstructure:"shutdown_timeout" default:"30s" validate:"required"`
+
+	// WorkerRestartAttempts is how many times a failed background worker is restarted in a row, 0 makes its failure fatal.
+	// WorkerRestartBackoff is the wait before the first restart, it doubles for every further one up to a minute.
+	WorkerRestartAttempts int           `mapstructure:"worker_restart_attempts" default:"5" validate:"min=0"`
+	WorkerRestartBackoff  time.Duration `mapstructure:"worker_restart_backoff" default:"1s" validate:"required"`
+
+	// GRPCPort is where the gRPC API listens, 0 disables it.
+	GRPCPort int `mapstructure:"grpc_port" default:"9090" validate:"min=0"`
+
+	// DBDialect is the database the resources are stored in, mysql, postgres or sqlite, set up by the settings named after it.
+	DBDialect string `mapstructure:"db_dialect" default:"mysql" validate:"oneof=mysql postgres sqlite"`
+
+	MySQLDBAddress string `mapstructure:"mysql_db_address" validate:"required_if=DBDialect mysql"`
+	MySQLDBPort    int    `mapstructure:"mysql_db_port" default:"3306"`
+	MySQLDBUser    string `mapstructure:"mysql_db_user" validate:"required_if=DBDialect mysql"`
+	// MySQLDBPassword is reloaded by recycling the idle connections of the pool, the new ones log in with it.
+	MySQLDBPassword string `mapstructure:"mysql_db_password" validate:"required_if=DBDialect mysql" secret:"true" reload:"true"`
+	MySQLDBName     string `mapstructure:"mysql_db_name" default:"resource_database"`
+	// MySQLDBMigrationDirectory overrides the migrations built into the binary with the ones in the directory.
+	MySQLDBMigrationDirectory string `mapstructure:"mysql_db_migration_dir"`
+
+	PostgresDBAddress string `mapstructure:"postgres_db_address" validate:"required_if=DBDialect postgres"`
+	PostgresDBPort    int    `mapstructure:"postgres_db_port" default:"5432"`
+	PostgresDBUser    string `mapstructure:"postgres_db_user" validate:"required_if=DBDialect postgres"`
+	// PostgresDBPassword is reloaded like MySQLDBPassword.
+	PostgresDBPassword string `mapstructure:"postgres_db_password" validate:"required_if=DBDialect postgres" secret:"true" reload:"true"`
+	PostgresDBName     string `mapstructure:"postgres_db_name" default:"resource_database"`
+	PostgresDBSSLMode  string `mapstructure:"postgres_db_ssl_mode" default:"disable" validate:"oneof=disable require verify-ca verify-full"`
+	// PostgresDBMigrationDirectory overrides the built in migrations like MySQLDBMigrationDirectory.
+	PostgresDBMigrationDirectory string `mapstructure:"postgres_db_migration_dir"`
+
+	// SQLiteDBPath is the database file, it is created on the first start with the migrations built into the binary.
+	SQLiteDBPath string `mapstructure:"sqlite_db_path" validate:"required_if=DBDialect sqlite"`
+
+	// SecretsProvider looks up the secrets that are neither set nor given in a file:
+	// encrypted_file reads them from SecretsFile, encrypted with the base64 encoded AES-256 key in SecretsKeyFile.
+	SecretsProvider string `mapstructure:"secrets_provider" validate:"omitempty,oneof=encrypted_file"`
+	SecretsFile     string `mapstructure:"secrets_file" validate:"required_if=SecretsProvider encrypted_file" watch:"true"`
+	SecretsKeyFile  string `mapstructure:"secrets_key_file" validate:"required_if=SecretsProvider encrypted_file" watch:"true"`
+
+	TenantHeader        string `mapstructure:"tenant_header" default:"X-Tenant-ID"`
+	OwnerHeader         string `mapstructure:"owner_header" default:"X-Owner-ID"`
+	TenantResourceQuota int    `mapstructure:"tenant_resource_quota" default:"0" validate:"min=0"`
+
+	// CacheSize is the number of resource and category lookups kept in memory, 0 disables the cache.
+	CacheSize int           `mapstructure:"cache_size" default:"10000" validate:"min=0"`
+	CacheTTL  time.Duration `mapstructure:"cache_ttl" default:"1m" validate:"required" reload:"true"`
+	// CacheLoadTimeout limits a database read shared by the concurrent lookups of a key, the lookups themselves may give up earlier.
+	CacheLoadTimeout time.Duration `mapstructure:"cache_load_timeout" default:"10s" validate:"required"`
+
+	// CacheControl is the Cache-Control header of the resource and category read endpoints.
+	// The responses vary by TenantHeader and OwnerHeader, they are always private with TLSClientCAFile.
+	CacheControl string `mapstructure:"cache_control" default:"private, no-cache"`
+	// CacheControlCategories overrides CacheControl for the resources of a category,
+	// as a list like "News feed=public, max-age=60; Content=public, max-age=3600".
+	CacheControlCategories string `mapstructure:"cache_control_categories"`
+
+	// RateLimitReadRate and RateLimitWriteRate are the requests per second a client may send to the read and the write endpoints,
+	// the bursts how many it may send at once. A rate of 0 disables the limit.
+	// RateLimitKeys lists the client identifiers in order of preference: api_key (the RateLimitAPIKeyHeader, if its
+	// SHA-256 hash is listed in RateLimitAPIKeysFile), jwt_subject (the sub claim of the bearer token, if it is signed
+	// with RateLimitJWTSecret or with the key of RateLimitJWTPublicKeyFile), certificate (the verified client certificate)
+	// and ip. A request is limited by the first identifier verified, the ip is the address of the connection,
+	// the X-Forwarded-For header is only read from RateLimitTrustedProxies, a comma separated list of addresses and CIDRs.
+	// RateLimitAPIKeysFile has a hex encoded hash per line, RateLimitJWTPublicKeyFile is the PEM encoded RSA key
+	// of RS256 tokens or P-256 key of ES256 tokens, the secret verifies HS256 tokens.
+	RateLimitReadRate         float64 `mapstructure:"rate_limit_read_rate" default:"50" validate:"min=0" reload:"true"`
+	RateLimitReadBurst        int     `mapstructure:"rate_limit_read_burst" default:"100" validate:"min=1" reload:"true"`
+	RateLimitWriteRate        float64 `mapstructure:"rate_limit_write_rate" default:"10" validate:"min=0" reload:"true"`
+	RateLimitWriteBurst       int     `mapstructure:"rate_limit_write_burst" default:"20" validate:"min=1" reload:"true"`
+	RateLimitKeys             string  `mapstructure:"rate_limit_keys" default:"certificate,ip" reload:"true"`
+	RateLimitTrustedProxies   string  `mapstructure:"rate_limit_trusted_proxies" reload:"true"`
+	RateLimitAPIKeyHeader     string  `mapstructure:"rate_limit_api_key_header" default:"X-API-Key" reload:"true"`
+	RateLimitAPIKeysFile      string  `mapstructure:"rate_limit_api_keys_file" watch:"true" reload:"true"`
+	RateLimitJWTSecret        string  `mapstructure:"rate_limit_jwt_secret" secret:"true" reload:"true"`
+	RateLimitJWTPublicKeyFile string  `mapstructure:"rate_limit_jwt_public_key_file" watch:"true" reload:"true"`
+
+	// BlobBackend is where the uploaded blobs of the resources are kept, local or s3.
+	BlobBackend string `mapstructure:"blob_backend" default:"local" validate:"oneof=local s3"`
+	BlobMaxSize int64  `mapstructure:"blob_max_size" default:"104857600" validate:"min=1"`
+	// BlobDirectory is the directory of the local backend.
+	BlobDirectory string `mapstructure:"blob_dir" default:"blobs"`
+	// The S3 settings are used by the s3 backend, BlobS3PathStyle addresses the bucket in the path as most S3 compatible servers expect.
+	BlobS3Endpoint  string `mapstructure:"blob_s3_endpoint" validate:"required_if=BlobBackend s3,omitempty,url"`
+	BlobS3Region    string `mapstructure:"blob_s3_region" default:"us-east-1"`
+	BlobS3Bucket    string `mapstructure:"blob_s3_bucket" validate:"required_if=BlobBackend s3"`
+	BlobS3AccessKey string `mapstructure:"blob_s3_access_key" validate:"required_if=BlobBackend s3" secret:"true"`
+	BlobS3SecretKey string `mapstructure:"blob_s3_secret_key" validate:"required_if=BlobBackend s3" secret:"true"`
+	BlobS3PathStyle bool   `mapstructure:"blob_s3_path_style" default:"true"`
+
+	// VerifyInterval is how often the files of the resources not verified within VerifyMaxAge are checked, 0 disables it.
+	// VerifyLocalRoot is the directory the locations of the resources without a blob are looked up in,
+	// without it only the blobs are verified.
+	VerifyInterval  time.Duration `mapstructure:"verify_interval" default:"1m" validate:"min=0"`
+	VerifyBatchSize int           `mapstructure:"verify_batch_size" default:"100" validate:"min=1"`
+	VerifyMaxAge    time.Duration `mapstructure:"verify_max_age" default:"24h" validate:"min=0"`
+	VerifyLocalRoot string        `mapstructure:"verify_local_root"`
+
+	// ScheduleInterval is how often the publication and expiry events of the scheduled resources are emitted.
+	ScheduleInterval  time.Duration `mapstructure:"schedule_interval" default:"1s" validate:"required"`
+	ScheduleBatchSize int           `mapstructure:"schedule_batch_size" default:"100" validate:"min=1"`
+
+	// AuditRetention is how long audit events are kept, 0 keeps them forever.
+	AuditRetention time.Duration `mapstructure:"audit_retention" default:"0s" validate:"min=0"`
+
+	OutboxPollInterval   time.Duration `mapstructure:"outbox_poll_interval" default:"1s" validate:"required"`
+	OutboxBatchSize      int           `mapstructure:"outbox_batch_size" default:"100" validate:"min=1"`
+	OutboxWebhookURL     string        `mapstructure:"outbox_webhook_url" validate:"omitempty,url"`
+	OutboxWebhookTimeout time.Duration `mapstructure:"outbox_webhook_timeout" default:"5s" validate:"required"`
+	OutboxFilePath       string        `mapstructure:"outbox_file_path"`
+	// OutboxMaxAttempts is the number of deliveries tried before an event is moved to the dead letters.
+	OutboxMaxAttempts int           `mapstructure:"outbox_max_attempts" default:"10" validate:"min=1"`
+	OutboxBackoffBase time.Duration `mapstructure:"outbox_backoff_base" default:"1s" validate:"required"`
+	OutboxBackoffMax  time.Duration `mapstructure:"outbox_backoff_max" default:"10m" validate:"required"`
+
+	WebhookDeliveryInterval     time.Duration `mapstructure:"webhook_delivery_interval" default:"1s" validate:"required"`
+	WebhookDeliveryBatchSize    int           `mapstructure:"webhook_delivery_batch_size" default:"20" validate:"min=1"`
+	WebhookDeliveryTimeout      time.Duration `mapstructure:"webhook_delivery_timeout" default:"10s" validate:"required"`
+	WebhookMaxAttempts          int           `mapstructure:"webhook_max_attempts" default:"8" validate:"min=1"`
+	WebhookBackoffBase          time.Duration `mapstructure:"webhook_backoff_base" default:"10s" validate:"required"`
+	WebhookBackoffMax           time.Duration `mapstructure:"webhook_backoff_max" default:"1h" validate:"required"`
+	WebhookDisableAfterFailures int           `mapstructure:"webhook_disable_after_failures" default:"50" validate:"min=1"`
+	// WebhookAllowPrivateTargets accepts the subscriptions to loopback, link-local and private addresses, for development only.
+	WebhookAllowPrivateTargets bool `mapstructure:"webhook_allow_private_targets" default:"false"`
 
-	MySQLDBAddress            string `mapstructure:"mysql_db_address" validate:"required"`
-	MySQLDBPort               int    `mapstructure:"mysql_db_port" default:"3306"`
-	MySQLDBUser               string `mapstructure:"mysql_db_user" validate:"required"`
-	MySQLDBPassword           string `mapstructure:"mysql_db_password" validate:"required"`
-	MySQLDBName               string `mapstructure:"mysql_db_name" default:"resource_database"`
-	MySQLDBMigrationDirectory string `mapstructure:"mysql_db_migration_dir" validate:"required"`
+	StreamPollInterval      time.Duration `mapstructure:"stream_poll_interval" default:"1s" validate:"required"`
+	StreamHeartbeatInterval time.Duration `mapstructure:"stream_heartbeat_interval" default:"15s" validate:"required"`
 }
@@ -0,0 +1,56 @@
+// Package migrations holds the database migrations built into the binary.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed mysql/*.sql
+var mysql embed.FS
+
+//go:embed postgres/*.sql
+var postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// MySQL returns the migrations of the MySQL database.
+func MySQL() fs.FS {
+	return sub(mysql, "mysql")
+}
+
+// PostgreSQL returns the migrations of the PostgreSQL database.
+func PostgreSQL() fs.FS {
+	return sub(postgres, "postgres")
+}
+
+// SQLite returns the migrations of the SQLite database.
+func SQLite() fs.FS {
+	return sub(sqlite, "sqlite")
+}
+
+// Version returns the schema version of the migrations, the timestamp their latest one is named after.
+func Version(migrations fs.FS) string {
+	names, err := fs.Glob(migrations, "*.up.sql")
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	latest := names[len(names)-1]
+	if i := strings.Index(latest, "_"); i > 0 {
+		return latest[:i]
+	}
+	return strings.TrimSuffix(latest, ".up.sql")
+}
+
+func sub(files embed.FS, dir string) fs.FS {
+	migrations, err := fs.Sub(files, dir)
+	if err != nil {
+		// the directories are fixed at build time
+		panic(err)
+	}
+	return migrations
+}
@@ -0,0 +1,28 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS tenants(
+   id VARCHAR (64) PRIMARY KEY,
+   resource_quota INT,
+   created_at DATETIME NOT NULL DEFAULT NOW(),
+   updated_at DATETIME NOT NULL DEFAULT NOW()
+);
+
+-- +migrate Up
+INSERT INTO tenants (id) VALUES ('default');
+
+-- +migrate Up
+ALTER TABLE categories
+   ADD COLUMN tenant_id VARCHAR (64) NOT NULL DEFAULT 'default' AFTER id,
+   ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id);
+
+-- +migrate Up
+ALTER TABLE categories ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- +migrate Up
+ALTER TABLE resources
+   ADD COLUMN tenant_id VARCHAR (64) NOT NULL DEFAULT 'default' AFTER id,
+   ADD COLUMN owner_id VARCHAR (64) AFTER tenant_id,
+   ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id),
+   ADD INDEX resources_tenant_category (tenant_id, category, created_at);
+
+-- +migrate Up
+ALTER TABLE resources ALTER COLUMN tenant_id DROP DEFAULT;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS audit_events(
+   id BIGINT PRIMARY KEY AUTO_INCREMENT,
+   tenant_id VARCHAR (64) NOT NULL,
+   actor VARCHAR (64),
+   request_id VARCHAR (128),
+   client_ip VARCHAR (64),
+   operation VARCHAR (16) NOT NULL,
+   resource_id binary(16) NOT NULL,
+   diff json,
+   created_at DATETIME(3) NOT NULL,
+   INDEX audit_events_tenant_resource (tenant_id, resource_id, created_at),
+   INDEX audit_events_tenant_actor (tenant_id, actor, created_at),
+   INDEX audit_events_created_at (created_at)
+);
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS outbox_events(
+   id BIGINT PRIMARY KEY AUTO_INCREMENT,
+   tenant_id VARCHAR (64) NOT NULL,
+   aggregate_type VARCHAR (16) NOT NULL,
+   aggregate_id VARCHAR (64) NOT NULL,
+   event_type VARCHAR (32) NOT NULL,
+   payload json,
+   attempts INT NOT NULL DEFAULT 0,
+   created_at DATETIME(3) NOT NULL,
+   published_at DATETIME(3),
+   INDEX outbox_events_unpublished (published_at, id)
+);
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE outbox_events
+   ADD INDEX outbox_events_tenant_log (tenant_id, aggregate_type, id);
@@ -0,0 +1,32 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS webhook_subscriptions(
+   id binary(16) PRIMARY KEY,
+   tenant_id VARCHAR (64) NOT NULL,
+   url VARCHAR (2048) NOT NULL,
+   categories json,
+   event_types json,
+   secret VARCHAR (256) NOT NULL,
+   active BOOLEAN NOT NULL DEFAULT TRUE,
+   consecutive_failures INT NOT NULL DEFAULT 0,
+   created_at DATETIME(3) NOT NULL,
+   updated_at DATETIME(3) NOT NULL,
+   INDEX webhook_subscriptions_tenant (tenant_id, active)
+);
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+   id BIGINT PRIMARY KEY AUTO_INCREMENT,
+   subscription_id binary(16) NOT NULL,
+   tenant_id VARCHAR (64) NOT NULL,
+   event_id BIGINT NOT NULL,
+   payload json NOT NULL,
+   status VARCHAR (16) NOT NULL,
+   attempts INT NOT NULL DEFAULT 0,
+   next_attempt_at DATETIME(3) NOT NULL,
+   last_error VARCHAR (1024),
+   created_at DATETIME(3) NOT NULL,
+   updated_at DATETIME(3) NOT NULL,
+   UNIQUE INDEX webhook_deliveries_event (subscription_id, event_id),
+   INDEX webhook_deliveries_due (status, next_attempt_at),
+   FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE resources
+   ADD INDEX resources_tenant_category_updated (tenant_id, category, updated_at);
@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE resources
+   ADD COLUMN publish_at DATETIME(3) NULL,
+   ADD COLUMN expire_at DATETIME(3) NULL,
+   ADD COLUMN publish_event_pending BOOLEAN NOT NULL DEFAULT FALSE,
+   ADD COLUMN expire_event_pending BOOLEAN NOT NULL DEFAULT FALSE,
+   ADD INDEX resources_publish_pending (publish_event_pending, publish_at),
+   ADD INDEX resources_expire_pending (expire_event_pending, expire_at);
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE resources
+   ADD COLUMN blob_meta JSON NULL;
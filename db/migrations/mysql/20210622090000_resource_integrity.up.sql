@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE resources
+   ADD COLUMN checksum CHAR(64) NULL,
+   ADD COLUMN size BIGINT NULL,
+   ADD COLUMN integrity JSON NULL,
+   ADD COLUMN verified_at DATETIME(3) NULL,
+   ADD INDEX resources_verified_at (verified_at);
@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE outbox_events
+   ADD COLUMN next_attempt_at DATETIME(3) NULL,
+   ADD COLUMN last_error VARCHAR(1024) NULL,
+   ADD COLUMN dead_at DATETIME(3) NULL,
+   ADD INDEX outbox_events_aggregate (aggregate_type, aggregate_id, published_at, id);
@@ -0,0 +1,18 @@
+-- +migrate Up
+ALTER TABLE outbox_events
+   ADD COLUMN log_position BIGINT NULL,
+   ADD UNIQUE INDEX outbox_events_log_position (log_position),
+   ADD INDEX outbox_events_tenant_log_position (tenant_id, aggregate_type, log_position);
+
+-- +migrate Up
+-- the events written so far keep their IDs, so the readers resume where they stopped
+UPDATE outbox_events SET log_position = id;
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS change_log_sequence(
+   id INT PRIMARY KEY,
+   last_position BIGINT NOT NULL
+);
+
+-- +migrate Up
+INSERT INTO change_log_sequence(id, last_position) SELECT 1, COALESCE(MAX(id), 0) FROM outbox_events;
@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE resources
+   DROP PRIMARY KEY,
+   ADD PRIMARY KEY (tenant_id, id);
@@ -0,0 +1,122 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS tenants(
+   id VARCHAR (64) PRIMARY KEY,
+   resource_quota INT,
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+   updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +migrate Up
+INSERT INTO tenants (id) VALUES ('default');
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS categories(
+   id SMALLSERIAL PRIMARY KEY,
+   tenant_id VARCHAR (64) NOT NULL REFERENCES tenants(id),
+   name VARCHAR (50),
+   description VARCHAR (300),
+   created_at TIMESTAMPTZ(0) NOT NULL DEFAULT NOW(),
+   updated_at TIMESTAMPTZ(0) NOT NULL DEFAULT NOW()
+);
+
+-- +migrate Up
+INSERT INTO categories (tenant_id, name, description) VALUES ('default', 'News feed', 'Resource marked as news feed item');
+INSERT INTO categories (tenant_id, name, description) VALUES ('default', 'Content', 'All resource that has been uploaded as an attachement in another resource. For example, news feed image for news feed resource item');
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS resources(
+   id uuid PRIMARY KEY,
+   tenant_id VARCHAR (64) NOT NULL REFERENCES tenants(id),
+   owner_id VARCHAR (64),
+   category SMALLINT REFERENCES categories(id),
+   content jsonb,
+   created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+   updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+   publish_at TIMESTAMPTZ(3) NULL,
+   expire_at TIMESTAMPTZ(3) NULL,
+   publish_event_pending BOOLEAN NOT NULL DEFAULT FALSE,
+   expire_event_pending BOOLEAN NOT NULL DEFAULT FALSE,
+   blob_meta jsonb NULL,
+   checksum VARCHAR (64) NULL,
+   size BIGINT NULL,
+   integrity jsonb NULL,
+   verified_at TIMESTAMPTZ(3) NULL
+);
+
+-- +migrate Up
+CREATE INDEX resources_tenant_category ON resources (tenant_id, category, created_at);
+CREATE INDEX resources_tenant_category_updated ON resources (tenant_id, category, updated_at);
+CREATE INDEX resources_publish_pending ON resources (publish_event_pending, publish_at);
+CREATE INDEX resources_expire_pending ON resources (expire_event_pending, expire_at);
+CREATE INDEX resources_verified_at ON resources (verified_at NULLS FIRST);
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS audit_events(
+   id BIGSERIAL PRIMARY KEY,
+   tenant_id VARCHAR (64) NOT NULL,
+   actor VARCHAR (64),
+   request_id VARCHAR (128),
+   client_ip VARCHAR (64),
+   operation VARCHAR (16) NOT NULL,
+   resource_id uuid NOT NULL,
+   diff jsonb,
+   created_at TIMESTAMPTZ(3) NOT NULL
+);
+
+-- +migrate Up
+CREATE INDEX audit_events_tenant_resource ON audit_events (tenant_id, resource_id, created_at);
+CREATE INDEX audit_events_tenant_actor ON audit_events (tenant_id, actor, created_at);
+CREATE INDEX audit_events_created_at ON audit_events (created_at);
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS outbox_events(
+   id BIGSERIAL PRIMARY KEY,
+   tenant_id VARCHAR (64) NOT NULL,
+   aggregate_type VARCHAR (16) NOT NULL,
+   aggregate_id VARCHAR (64) NOT NULL,
+   event_type VARCHAR (32) NOT NULL,
+   payload jsonb,
+   attempts INT NOT NULL DEFAULT 0,
+   created_at TIMESTAMPTZ(3) NOT NULL,
+   published_at TIMESTAMPTZ(3)
+);
+
+-- +migrate Up
+CREATE INDEX outbox_events_unpublished ON outbox_events (published_at, id);
+CREATE INDEX outbox_events_tenant_log ON outbox_events (tenant_id, aggregate_type, id);
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS webhook_subscriptions(
+   id uuid PRIMARY KEY,
+   tenant_id VARCHAR (64) NOT NULL,
+   url VARCHAR (2048) NOT NULL,
+   categories jsonb,
+   event_types jsonb,
+   secret VARCHAR (256) NOT NULL,
+   active BOOLEAN NOT NULL DEFAULT TRUE,
+   consecutive_failures INT NOT NULL DEFAULT 0,
+   created_at TIMESTAMPTZ(3) NOT NULL,
+   updated_at TIMESTAMPTZ(3) NOT NULL
+);
+
+-- +migrate Up
+CREATE INDEX webhook_subscriptions_tenant ON webhook_subscriptions (tenant_id, active);
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+   id BIGSERIAL PRIMARY KEY,
+   subscription_id uuid NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+   tenant_id VARCHAR (64) NOT NULL,
+   event_id BIGINT NOT NULL,
+   payload jsonb NOT NULL,
+   status VARCHAR (16) NOT NULL,
+   attempts INT NOT NULL DEFAULT 0,
+   next_attempt_at TIMESTAMPTZ(3) NOT NULL,
+   last_error VARCHAR (1024),
+   created_at TIMESTAMPTZ(3) NOT NULL,
+   updated_at TIMESTAMPTZ(3) NOT NULL,
+   CONSTRAINT webhook_deliveries_event UNIQUE (subscription_id, event_id)
+);
+
+-- +migrate Up
+CREATE INDEX webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE outbox_events
+   ADD COLUMN next_attempt_at TIMESTAMPTZ(3),
+   ADD COLUMN last_error VARCHAR (1024),
+   ADD COLUMN dead_at TIMESTAMPTZ(3);
+
+-- +migrate Up
+CREATE INDEX outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id, published_at, id);
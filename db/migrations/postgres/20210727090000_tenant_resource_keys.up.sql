@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE resources
+   DROP CONSTRAINT resources_pkey,
+   ADD PRIMARY KEY (tenant_id, id);
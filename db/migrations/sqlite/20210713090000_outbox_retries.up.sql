@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE outbox_events ADD COLUMN next_attempt_at DATETIME;
+
+-- +migrate Up
+ALTER TABLE outbox_events ADD COLUMN last_error VARCHAR (1024);
+
+-- +migrate Up
+ALTER TABLE outbox_events ADD COLUMN dead_at DATETIME;
+
+-- +migrate Up
+CREATE INDEX outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id, published_at, id);
@@ -0,0 +1,40 @@
+-- +migrate Up
+CREATE TABLE resources_tenant_keys(
+   id TEXT NOT NULL,
+   tenant_id VARCHAR (64) NOT NULL REFERENCES tenants(id),
+   owner_id VARCHAR (64),
+   category INTEGER REFERENCES categories(id),
+   content TEXT,
+   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   publish_at DATETIME NULL,
+   expire_at DATETIME NULL,
+   publish_event_pending BOOLEAN NOT NULL DEFAULT FALSE,
+   expire_event_pending BOOLEAN NOT NULL DEFAULT FALSE,
+   blob_meta TEXT NULL,
+   checksum VARCHAR (64) NULL,
+   size INTEGER NULL,
+   integrity TEXT NULL,
+   verified_at DATETIME NULL,
+   PRIMARY KEY (tenant_id, id)
+);
+
+-- +migrate Up
+INSERT INTO resources_tenant_keys (id, tenant_id, owner_id, category, content, created_at, updated_at, publish_at, expire_at,
+   publish_event_pending, expire_event_pending, blob_meta, checksum, size, integrity, verified_at)
+SELECT id, tenant_id, owner_id, category, content, created_at, updated_at, publish_at, expire_at,
+   publish_event_pending, expire_event_pending, blob_meta, checksum, size, integrity, verified_at
+FROM resources;
+
+-- +migrate Up
+DROP TABLE resources;
+
+-- +migrate Up
+ALTER TABLE resources_tenant_keys RENAME TO resources;
+
+-- +migrate Up
+CREATE INDEX resources_tenant_category ON resources (tenant_id, category, created_at);
+CREATE INDEX resources_tenant_category_updated ON resources (tenant_id, category, updated_at);
+CREATE INDEX resources_publish_pending ON resources (publish_event_pending, publish_at);
+CREATE INDEX resources_expire_pending ON resources (expire_event_pending, expire_at);
+CREATE INDEX resources_verified_at ON resources (verified_at);
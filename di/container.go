@@ -2,30 +2,81 @@ package di
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"github.com/proemergotech/log/v3"
 	"github.com/proemergotech/log/v3/echolog"
 
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	"github.com/artofimagination/mysql-resources-db-go-service/cache"
+	"github.com/artofimagination/mysql-resources-db-go-service/certs"
 	"github.com/artofimagination/mysql-resources-db-go-service/config"
+	"github.com/artofimagination/mysql-resources-db-go-service/gql"
+	"github.com/artofimagination/mysql-resources-db-go-service/grpcapi"
+	"github.com/artofimagination/mysql-resources-db-go-service/health"
+	"github.com/artofimagination/mysql-resources-db-go-service/outbox"
+	"github.com/artofimagination/mysql-resources-db-go-service/ratelimit"
 	"github.com/artofimagination/mysql-resources-db-go-service/rest"
 	"github.com/artofimagination/mysql-resources-db-go-service/service"
 	"github.com/artofimagination/mysql-resources-db-go-service/storage"
 	"github.com/artofimagination/mysql-resources-db-go-service/validation"
+	"github.com/artofimagination/mysql-resources-db-go-service/webhook"
+	"github.com/artofimagination/mysql-resources-db-go-service/worker"
 )
 
+// auditRetentionInterval is how often the audit events beyond the retention period are removed.
+const auditRetentionInterval = time.Hour
+
+// maxIdleConnections is the number of idle database connections kept in the pool, the default of database/sql.
+const maxIdleConnections = 2
+
+// rotatingConnector opens the database connections of the pool, its password can be rotated while the pool is in use.
+type rotatingConnector interface {
+	driver.Connector
+	SetPassword(password string) bool
+}
+
+// sqlStorage is the storage on the database selected by the DBDialect setting.
+type sqlStorage interface {
+	storage.Storage
+	outbox.Storage
+	webhook.Storage
+	BootstrapSystem(migrationDirectory string) error
+	SchemaVersion() string
+}
+
 type Container struct {
-	RestServer *rest.Server
-	database   *sqlx.DB
+	RestServer     *rest.Server
+	GRPCServer     *grpcapi.Server
+	AuditRetention *worker.Periodic
+	ChangeLog      *worker.Periodic
+	OutboxRelay    *worker.Periodic
+	Scheduler      *worker.Periodic
+	Verifier       *worker.Periodic
+	WebhookWorker  *worker.Periodic
+	Service        *service.Service
+	Readiness      *health.Readiness
+	database       *sqlx.DB
+	connector      rotatingConnector
+	fileSink       *outbox.FileSink
+	storageCache   *cache.Cache
+	rateLimits     *rest.RateLimits
+	certificates   *certs.Reloader
 }
 
 func NewContainer(cfg *config.Config) (*Container, error) {
@@ -36,31 +87,156 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 		return nil, errors.Wrap(err, "cannot initialize validator")
 	}
 
-	c.database, err = newSQLDatabase(cfg)
+	c.connector, c.database, err = newDatabase(cfg)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot initialize MySQL database")
+		return nil, errors.Wrap(err, "cannot open database")
 	}
+	c.database.SetMaxIdleConns(maxIdleConnections)
 
-	mysqlStorage := storage.NewMySQL(c.database)
+	c.Readiness = health.NewReadiness()
+	c.Readiness.AddCheck("database", c.database.PingContext)
 
-	err = mysqlStorage.BootstrapSystem(cfg.MySQLDBMigrationDirectory)
+	if cfg.CacheSize > 0 {
+		// a shared remote Store can be passed here to keep the replicas of the service on one cache
+		c.storageCache = cache.New("storage", cache.NewLRU(cfg.CacheSize), nil, cfg.CacheTTL, cfg.CacheLoadTimeout)
+	}
+
+	sqlStorage, migrationDirectory := newSQLStorage(cfg, c.database, c.storageCache)
+	c.Readiness.SetSchemaVersion(sqlStorage.SchemaVersion())
+
+	err = sqlStorage.BootstrapSystem(migrationDirectory)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot bootstrap MySQL database")
+		return nil, errors.Wrapf(err, "cannot bootstrap %s database", cfg.DBDialect)
 	}
 
-	echoEngine := newEcho(cfg.Port, v, rest.DLiveRHTTPErrorHandler)
+	rateLimit, err := newRateLimitConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.rateLimits = rest.NewRateLimits(rateLimit)
+
+	if cfg.TLSCertFile != "" {
+		c.certificates, err = certs.NewReloader(certs.Config{
+			CertFile:     cfg.TLSCertFile,
+			KeyFile:      cfg.TLSKeyFile,
+			ClientCAFile: cfg.TLSClientCAFile,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot initialize TLS")
+		}
+	}
+
+	echoEngine := newEcho(cfg, v, rest.DLiveRHTTPErrorHandler, c.rateLimits, c.certificates)
+
+	var svcStorage storage.Storage = sqlStorage
+	if c.storageCache != nil {
+		svcStorage = storage.NewCached(sqlStorage, c.storageCache)
+	}
+	blobs, err := newBlobStore(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot initialize blob store")
+	}
+
+	svc := service.NewService(svcStorage, blobs, cfg.BlobMaxSize, cfg.VerifyLocalRoot, cfg.WebhookAllowPrivateTargets)
+	c.Service = svc
+
+	if cfg.AuditRetention > 0 {
+		c.AuditRetention = worker.NewPeriodic("audit retention", auditRetentionInterval, func(ctx context.Context) error {
+			return svc.PruneAuditEvents(ctx, cfg.AuditRetention)
+		})
+	}
 
-	svc := service.NewService(mysqlStorage)
+	// the change log is extended as often as the streams read it
+	c.ChangeLog = worker.NewPeriodic("change log sequencer", cfg.StreamPollInterval, svc.SequenceChangeEvents)
+
+	c.Scheduler = worker.NewPeriodic("resource scheduler", cfg.ScheduleInterval, func(ctx context.Context) error {
+		return svc.EmitScheduledResourceEvents(ctx, cfg.ScheduleBatchSize)
+	})
+
+	if cfg.VerifyInterval > 0 {
+		c.Verifier = worker.NewPeriodic("resource verifier", cfg.VerifyInterval, func(ctx context.Context) error {
+			return svc.VerifyOutdatedResources(ctx, cfg.VerifyMaxAge, cfg.VerifyBatchSize)
+		})
+	}
+
+	sinks := []outbox.Sink{webhook.NewSubscriptionSink(sqlStorage)}
+	if cfg.OutboxWebhookURL != "" {
+		sinks = append(sinks, outbox.NewWebhookSink(cfg.OutboxWebhookURL, &http.Client{Timeout: cfg.OutboxWebhookTimeout}))
+	}
+	if cfg.OutboxFilePath != "" {
+		c.fileSink, err = outbox.NewFileSink(cfg.OutboxFilePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot open outbox file sink")
+		}
+		sinks = append(sinks, c.fileSink)
+	}
+	relay := outbox.NewRelay(sqlStorage, outbox.RelayConfig{
+		BatchSize: cfg.OutboxBatchSize,
+		// the events are published one after the other, the lease has to outlive the whole batch
+		Lease:       time.Duration(cfg.OutboxBatchSize) * cfg.OutboxWebhookTimeout,
+		MaxAttempts: cfg.OutboxMaxAttempts,
+		BackoffBase: cfg.OutboxBackoffBase,
+		BackoffMax:  cfg.OutboxBackoffMax,
+	}, sinks...)
+	c.OutboxRelay = worker.NewPeriodic("outbox relay", cfg.OutboxPollInterval, relay.Run)
+
+	dispatcher := webhook.NewDispatcher(sqlStorage, webhook.DispatcherConfig{
+		BatchSize:    cfg.WebhookDeliveryBatchSize,
+		Timeout:      cfg.WebhookDeliveryTimeout,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		BackoffBase:  cfg.WebhookBackoffBase,
+		BackoffMax:   cfg.WebhookBackoffMax,
+		DisableAfter: cfg.WebhookDisableAfterFailures,
+
+		AllowPrivateTargets: cfg.WebhookAllowPrivateTargets,
+	})
+	c.WebhookWorker = worker.NewPeriodic("webhook delivery", cfg.WebhookDeliveryInterval, dispatcher.Run)
+
+	graphQL, err := gql.NewServer(svc)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot initialize GraphQL schema")
+	}
+
+	identityHeaders := []string{cfg.TenantHeader, cfg.OwnerHeader}
+	if cfg.TLSClientCAFile != "" {
+		// the identity comes from the client certificates, the headers are ignored
+		identityHeaders = nil
+	}
+	cachePolicies, err := rest.ParseCachePolicies(cfg.CacheControl, cfg.CacheControlCategories, identityHeaders...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse cache control policies")
+	}
 
 	c.RestServer = rest.NewServer(
 		echoEngine,
 		rest.NewController(
 			echoEngine,
 			svc,
+			graphQL,
+			c.Readiness,
 			cfg.DebugPProf,
+			cfg.DocsUI,
+			cachePolicies,
+			cfg.StreamPollInterval,
+			cfg.StreamHeartbeatInterval,
 		),
 	)
 
+	if cfg.GRPCPort != 0 {
+		var tlsConfig *tls.Config
+		var clientCertificates *grpcapi.ClientCertificates
+		if c.certificates != nil {
+			tlsConfig = c.certificates.TLSConfig()
+		}
+		if cfg.TLSClientCAFile != "" {
+			clientCertificates = &grpcapi.ClientCertificates{
+				TenantAttribute: cfg.TLSClientTenantAttribute,
+				OwnerAttribute:  cfg.TLSClientOwnerAttribute,
+			}
+		}
+		c.GRPCServer = grpcapi.NewServer(cfg.GRPCPort, svc, v, cfg.TenantHeader, cfg.OwnerHeader, tlsConfig, clientCertificates)
+	}
+
 	return c, nil
 }
 
@@ -87,40 +263,184 @@ func NewValidator() (*validation.Validator, error) {
 	return validation.NewValidator(v), nil
 }
 
-func newSQLDatabase(cfg *config.Config) (*sqlx.DB, error) {
-	return sqlx.Open(
-		"mysql",
-		fmt.Sprintf(
-			"%s:%s@tcp(%s:%d)/%s?parseTime=true&interpolateParams=true",
-			cfg.MySQLDBUser,
-			cfg.MySQLDBPassword,
-			cfg.MySQLDBAddress,
-			cfg.MySQLDBPort,
-			cfg.MySQLDBName,
-		),
-	)
+// newDatabase opens the database selected by DBDialect. The connector is nil for SQLite, it has no password to rotate.
+func newDatabase(cfg *config.Config) (rotatingConnector, *sqlx.DB, error) {
+	switch cfg.DBDialect {
+	case "sqlite":
+		database, err := storage.OpenSQLite(cfg.SQLiteDBPath)
+		return nil, database, err
+	case "postgres":
+		connector := storage.NewPostgreSQLConnector(storage.PostgreSQLConfig{
+			Host:     cfg.PostgresDBAddress,
+			Port:     cfg.PostgresDBPort,
+			User:     cfg.PostgresDBUser,
+			Password: cfg.PostgresDBPassword,
+			DBName:   cfg.PostgresDBName,
+			SSLMode:  cfg.PostgresDBSSLMode,
+		})
+		return connector, sqlx.NewDb(sql.OpenDB(connector), "postgres"), nil
+	}
+	connector := storage.NewConnector(newMySQLConfig(cfg))
+	return connector, sqlx.NewDb(sql.OpenDB(connector), "mysql"), nil
+}
+
+// newSQLStorage returns the storage on the database selected by DBDialect with the directory of its migrations,
+// empty to apply the ones built into the binary.
+func newSQLStorage(cfg *config.Config, database *sqlx.DB, storageCache *cache.Cache) (sqlStorage, string) {
+	switch cfg.DBDialect {
+	case "sqlite":
+		return storage.NewSQLite(database, cfg.TenantResourceQuota, storageCache), ""
+	case "postgres":
+		return storage.NewPostgreSQL(database, cfg.TenantResourceQuota, storageCache), cfg.PostgresDBMigrationDirectory
+	}
+	return storage.NewMySQL(database, cfg.TenantResourceQuota, storageCache), cfg.MySQLDBMigrationDirectory
+}
+
+func newMySQLConfig(cfg *config.Config) *mysql.Config {
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.User = cfg.MySQLDBUser
+	mysqlConfig.Passwd = cfg.MySQLDBPassword
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = fmt.Sprintf("%s:%d", cfg.MySQLDBAddress, cfg.MySQLDBPort)
+	mysqlConfig.DBName = cfg.MySQLDBName
+	mysqlConfig.ParseTime = true
+	mysqlConfig.InterpolateParams = true
+	return mysqlConfig
+}
+
+func newRateLimitConfig(cfg *config.Config) (rest.RateLimitConfig, error) {
+	keys, err := rest.ParseRateLimitKeys(cfg.RateLimitKeys)
+	if err != nil {
+		return rest.RateLimitConfig{}, errors.Wrap(err, "cannot parse rate limit keys")
+	}
+	trustedProxies, err := rest.ParseTrustedProxies(cfg.RateLimitTrustedProxies)
+	if err != nil {
+		return rest.RateLimitConfig{}, errors.Wrap(err, "cannot parse rate limit trusted proxies")
+	}
+
+	rateLimit := rest.RateLimitConfig{
+		Read:           ratelimit.Limit{Rate: cfg.RateLimitReadRate, Burst: cfg.RateLimitReadBurst},
+		Write:          ratelimit.Limit{Rate: cfg.RateLimitWriteRate, Burst: cfg.RateLimitWriteBurst},
+		Keys:           keys,
+		TrustedProxies: trustedProxies,
+		APIKeyHeader:   cfg.RateLimitAPIKeyHeader,
+	}
+	for _, key := range keys {
+		switch key {
+		case rest.RateLimitKeyAPIKey:
+			if cfg.RateLimitAPIKeysFile == "" {
+				return rest.RateLimitConfig{}, errors.New("rate limit key api_key requires rate_limit_api_keys_file")
+			}
+			rateLimit.APIKeys, err = auth.LoadAPIKeys(cfg.RateLimitAPIKeysFile)
+		case rest.RateLimitKeyJWTSubject:
+			rateLimit.JWT, err = auth.NewJWTVerifier(cfg.RateLimitJWTSecret, cfg.RateLimitJWTPublicKeyFile)
+		}
+		if err != nil {
+			return rest.RateLimitConfig{}, errors.Wrapf(err, "cannot initialize rate limit key %s", key)
+		}
+	}
+
+	return rateLimit, nil
 }
 
-func newEcho(port int, validator *validation.Validator, httpErrorHandler echo.HTTPErrorHandler) *echo.Echo {
+func newBlobStore(cfg *config.Config) (blob.Store, error) {
+	if cfg.BlobBackend == "s3" {
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.BlobS3Endpoint,
+			Region:    cfg.BlobS3Region,
+			Bucket:    cfg.BlobS3Bucket,
+			AccessKey: cfg.BlobS3AccessKey,
+			SecretKey: cfg.BlobS3SecretKey,
+			PathStyle: cfg.BlobS3PathStyle,
+		}, http.DefaultClient)
+	}
+	return blob.NewFileStore(cfg.BlobDirectory)
+}
+
+func newEcho(
+	cfg *config.Config,
+	validator *validation.Validator,
+	httpErrorHandler echo.HTTPErrorHandler,
+	rateLimits *rest.RateLimits,
+	certificates *certs.Reloader,
+) *echo.Echo {
 	e := echo.New()
 
 	e.Use(echolog.RecoveryMiddleware(log.GlobalLogger()))
+	e.Use(rest.RequestInfoMiddleware())
+	// a shared Store can be passed here to keep the replicas of the service on the same limits
+	e.Use(rest.RateLimitMiddleware(ratelimit.NewMemory(), rateLimits))
+	if cfg.TLSClientCAFile != "" {
+		e.Use(rest.ClientCertificateMiddleware(cfg.TLSClientTenantAttribute, cfg.TLSClientOwnerAttribute))
+	}
+	e.Use(rest.IdentityMiddleware(cfg.TenantHeader, cfg.OwnerHeader))
 	e.HTTPErrorHandler = httpErrorHandler
 	e.Validator = validator
 	e.HideBanner = true
 	e.HidePort = true
 
 	e.Server = &http.Server{
-		Addr:    ":" + strconv.Itoa(port),
+		Addr:    ":" + strconv.Itoa(cfg.Port),
 		Handler: e,
 	}
+	if certificates != nil {
+		e.Server.TLSConfig = certificates.TLSConfig()
+	}
 
 	return e
 }
 
+// Reload applies the settings that can change while the service is running, see the reload tag of config.Config.
+func (c *Container) Reload(cfg *config.Config) error {
+	rateLimit, err := newRateLimitConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if c.certificates != nil {
+		// the files are read again on every reload, it is how the rotated certificates are picked up
+		if err := c.certificates.Reload(); err != nil {
+			return err
+		}
+	}
+	c.rateLimits.Set(rateLimit)
+
+	if c.storageCache != nil {
+		c.storageCache.SetTTL(cfg.CacheTTL)
+	}
+
+	password := cfg.MySQLDBPassword
+	if cfg.DBDialect == "postgres" {
+		password = cfg.PostgresDBPassword
+	}
+	if c.connector != nil && c.connector.SetPassword(password) {
+		// the connections in use are discarded when they are returned, closing the idle ones recycles the rest of the pool,
+		// the new connections log in with the rotated password
+		c.database.SetMaxIdleConns(0)
+		c.database.SetMaxIdleConns(maxIdleConnections)
+		log.Info(context.Background(), "Database connection pool recycled with the rotated password")
+	}
+	return nil
+}
+
+// Drain makes the readiness checks of the servers fail, the first step of a graceful shutdown.
+func (c *Container) Drain() {
+	c.Readiness.Drain()
+	if c.GRPCServer != nil {
+		c.GRPCServer.Drain()
+	}
+}
+
+// Close releases the resources shared by the components, it is called after they are stopped.
 func (c *Container) Close() {
 	if err := c.database.Close(); err != nil {
 		err = errors.Wrap(err, "Database graceful close failed")
 		log.Warn(context.Background(), err.Error(), "error", err)
 	}
+
+	if c.fileSink != nil {
+		if err := c.fileSink.Close(); err != nil {
+			err = errors.Wrap(err, "Outbox file sink graceful close failed")
+			log.Warn(context.Background(), err.Error(), "error", err)
+		}
+	}
 }
@@ -0,0 +1,142 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// batchFn loads the resources with the given ids in one call. Missing ids are simply left out of the result.
+type batchFn func(ctx context.Context, ids []uuid.UUID) ([]models.Resource, error)
+
+// resourceLoader batches and caches the resource lookups of a single request, the way DataLoader does.
+// Keys requested within the batch window, or queued up front with Prime, are fetched with a single batchFn call.
+type resourceLoader struct {
+	fetch   batchFn
+	wait    time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	cache   map[uuid.UUID]*result
+	pending *batch
+}
+
+type result struct {
+	done     chan struct{}
+	resource *models.Resource
+	err      error
+}
+
+type batch struct {
+	ids     []uuid.UUID
+	results map[uuid.UUID]*result
+	full    chan struct{}
+}
+
+func newResourceLoader(fetch batchFn, wait time.Duration, maxSize int) *resourceLoader {
+	return &resourceLoader{
+		fetch:   fetch,
+		wait:    wait,
+		maxSize: maxSize,
+		cache:   make(map[uuid.UUID]*result),
+	}
+}
+
+// Prime queues the ids for the next batch without waiting for it, so resolvers that know all the keys of a page up front
+// turn the lookups of every item into one query.
+func (l *resourceLoader) Prime(ctx context.Context, ids []uuid.UUID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range ids {
+		l.enqueue(ctx, id)
+	}
+}
+
+// Load returns the resource with the given id, or nil if it does not exist.
+func (l *resourceLoader) Load(ctx context.Context, id uuid.UUID) (*models.Resource, error) {
+	l.mu.Lock()
+	r := l.enqueue(ctx, id)
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.resource, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// LoadMany returns the resources with the given ids in the same order, leaving out the missing ones.
+func (l *resourceLoader) LoadMany(ctx context.Context, ids []uuid.UUID) ([]*models.Resource, error) {
+	l.Prime(ctx, ids)
+
+	resources := make([]*models.Resource, 0, len(ids))
+	for _, id := range ids {
+		resource, err := l.Load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if resource != nil {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+// enqueue returns the cached or pending result of id, adding it to the pending batch if needed. It must be called with mu held.
+func (l *resourceLoader) enqueue(ctx context.Context, id uuid.UUID) *result {
+	if r, ok := l.cache[id]; ok {
+		return r
+	}
+
+	if l.pending == nil {
+		l.pending = &batch{
+			results: make(map[uuid.UUID]*result),
+			full:    make(chan struct{}),
+		}
+		go l.dispatchAfterWait(ctx, l.pending)
+	}
+
+	r := &result{done: make(chan struct{})}
+	l.cache[id] = r
+	l.pending.ids = append(l.pending.ids, id)
+	l.pending.results[id] = r
+
+	if len(l.pending.ids) >= l.maxSize {
+		close(l.pending.full)
+		l.pending = nil
+	}
+
+	return r
+}
+
+func (l *resourceLoader) dispatchAfterWait(ctx context.Context, b *batch) {
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		l.mu.Unlock()
+	case <-b.full:
+	}
+
+	resources, err := l.fetch(ctx, b.ids)
+	found := make(map[uuid.UUID]*models.Resource, len(resources))
+	for i := range resources {
+		found[resources[i].ID] = &resources[i]
+	}
+
+	for id, r := range b.results {
+		r.resource = found[id]
+		r.err = err
+		close(r.done)
+	}
+}
@@ -0,0 +1,511 @@
+package gql
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+)
+
+// maxPageSize caps the first argument of the connections.
+const maxPageSize = 100
+
+var (
+	errResourceFilterMissing = errors.New("either ids or category is required")
+	errTooManyResourceIDs    = errors.Errorf("at most %d ids can be listed", httpModels.MaxResourceIDs)
+)
+
+type rootResolver struct {
+	svc *service.Service
+}
+
+// isNotFound tells whether err is the not found answer of the service, which is an empty result in the graph.
+func isNotFound(err error) bool {
+	code, ok := myerrors.Field(err, models.HTTPCode).(int)
+	return ok && (code == http.StatusAccepted || code == http.StatusNotFound)
+}
+
+func (r *rootResolver) Resource(ctx context.Context, args struct{ ID graphql.ID }) (*resourceResolver, error) {
+	id, err := uuid.Parse(string(args.ID))
+	if err != nil {
+		return nil, errors.Errorf("invalid resource id %q", args.ID)
+	}
+
+	resource, err := loadersFromContext(ctx).resources.Load(ctx, id)
+	if err != nil || resource == nil {
+		return nil, err
+	}
+
+	return &resourceResolver{resource: resource}, nil
+}
+
+type resourcesArgs struct {
+	IDs      *[]graphql.ID
+	Category *int32
+	OwnerID  *string
+	First    int32
+	After    *string
+}
+
+func (r *rootResolver) Resources(ctx context.Context, args resourcesArgs) (*connectionResolver, error) {
+	var resources []*models.Resource
+	switch {
+	case args.IDs != nil:
+		if len(*args.IDs) > httpModels.MaxResourceIDs {
+			return nil, errTooManyResourceIDs
+		}
+		ids := make([]uuid.UUID, 0, len(*args.IDs))
+		for _, idString := range *args.IDs {
+			id, err := uuid.Parse(string(idString))
+			if err != nil {
+				return nil, errors.Errorf("invalid resource id %q", idString)
+			}
+			ids = append(ids, id)
+		}
+
+		var err error
+		resources, err = loadersFromContext(ctx).resources.LoadMany(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		if args.Category != nil {
+			resources = filter(resources, func(resource *models.Resource) bool {
+				return resource.Category == int(*args.Category)
+			})
+		}
+	case args.Category != nil:
+		var err error
+		resources, err = r.resourcesByCategory(ctx, int(*args.Category))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errResourceFilterMissing
+	}
+
+	return newConnection(ctx, resources, args.OwnerID, args.First, args.After)
+}
+
+func (r *rootResolver) resourcesByCategory(ctx context.Context, category int) ([]*models.Resource, error) {
+	list, err := r.svc.GetResourcesByCategory(ctx, &httpModels.GetResourcesByCategoryRequest{Category: category})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	resources := make([]*models.Resource, len(list))
+	for i := range list {
+		resources[i] = &list[i]
+	}
+	return resources, nil
+}
+
+func (r *rootResolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	categories, err := loadersFromContext(ctx).categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*categoryResolver, len(categories))
+	for i := range categories {
+		resolvers[i] = &categoryResolver{category: &categories[i]}
+	}
+	return resolvers, nil
+}
+
+func (r *rootResolver) Category(ctx context.Context, args struct{ ID int32 }) (*categoryResolver, error) {
+	category, err := loadersFromContext(ctx).category(ctx, int(args.ID))
+	if err != nil || category == nil {
+		return nil, err
+	}
+	return &categoryResolver{category: category}, nil
+}
+
+type resourceResolver struct {
+	resource *models.Resource
+}
+
+func (r *resourceResolver) ID() graphql.ID {
+	return graphql.ID(r.resource.ID.String())
+}
+
+func (r *resourceResolver) OwnerID() *string {
+	if r.resource.OwnerID == "" {
+		return nil
+	}
+	return &r.resource.OwnerID
+}
+
+func (r *resourceResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.resource.CreatedAt}
+}
+
+func (r *resourceResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.resource.UpdatedAt}
+}
+
+func (r *resourceResolver) PublishAt() *graphql.Time {
+	return optionalTime(r.resource.PublishAt)
+}
+
+func (r *resourceResolver) ExpireAt() *graphql.Time {
+	return optionalTime(r.resource.ExpireAt)
+}
+
+func optionalTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+func (r *resourceResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	category, err := loadersFromContext(ctx).category(ctx, r.resource.Category)
+	if err != nil || category == nil {
+		return nil, err
+	}
+	return &categoryResolver{category: category}, nil
+}
+
+func (r *resourceResolver) Location() *string {
+	location, ok := r.resource.Content[models.LocationKey]
+	if !ok {
+		return nil
+	}
+	return &location
+}
+
+func (r *resourceResolver) Content() []*contentEntryResolver {
+	keys := make([]string, 0, len(r.resource.Content))
+	for k := range r.resource.Content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := make([]*contentEntryResolver, len(keys))
+	for i, k := range keys {
+		entries[i] = &contentEntryResolver{key: k, value: r.resource.Content[k]}
+	}
+	return entries
+}
+
+func (r *resourceResolver) Attachments(ctx context.Context) ([]*resourceResolver, error) {
+	resources, err := loadersFromContext(ctx).resources.LoadMany(ctx, attachmentIDs(r.resource))
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*resourceResolver, len(resources))
+	for i := range resources {
+		resolvers[i] = &resourceResolver{resource: resources[i]}
+	}
+	return resolvers, nil
+}
+
+// attachmentIDs returns the ids of the resources referenced by the content, sorted.
+func attachmentIDs(resource *models.Resource) []uuid.UUID {
+	keys := make([]string, 0, len(resource.Content))
+	for k := range resource.Content {
+		if k != models.LocationKey {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	ids := make([]uuid.UUID, 0, len(keys))
+	for _, k := range keys {
+		if id, err := uuid.Parse(k); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (r *resourceResolver) Blob() *blobResolver {
+	if r.resource.Blob == nil {
+		return nil
+	}
+	return &blobResolver{blob: r.resource.Blob}
+}
+
+type blobResolver struct {
+	blob *models.Blob
+}
+
+func (r *blobResolver) Size() float64 {
+	return float64(r.blob.Size)
+}
+
+func (r *blobResolver) ContentType() string {
+	return r.blob.ContentType
+}
+
+func (r *blobResolver) SHA256() string {
+	return r.blob.SHA256
+}
+
+func (r *resourceResolver) Checksum() *string {
+	if r.resource.Checksum == "" {
+		return nil
+	}
+	return &r.resource.Checksum
+}
+
+func (r *resourceResolver) Size() *float64 {
+	if r.resource.Size == nil {
+		return nil
+	}
+	size := float64(*r.resource.Size)
+	return &size
+}
+
+func (r *resourceResolver) Integrity() *integrityResolver {
+	if r.resource.Integrity == nil {
+		return nil
+	}
+	return &integrityResolver{integrity: r.resource.Integrity}
+}
+
+func (r *resourceResolver) Broken() bool {
+	return r.resource.Integrity.Broken()
+}
+
+type integrityResolver struct {
+	integrity *models.Integrity
+}
+
+func (r *integrityResolver) Status() string {
+	return r.integrity.Status
+}
+
+func (r *integrityResolver) Detail() *string {
+	if r.integrity.Detail == "" {
+		return nil
+	}
+	return &r.integrity.Detail
+}
+
+func (r *integrityResolver) CheckedAt() graphql.Time {
+	return graphql.Time{Time: r.integrity.CheckedAt}
+}
+
+type contentEntryResolver struct {
+	key   string
+	value string
+}
+
+func (r *contentEntryResolver) Key() string {
+	return r.key
+}
+
+func (r *contentEntryResolver) Value() string {
+	return r.value
+}
+
+type categoryResolver struct {
+	category *models.Category
+}
+
+func (r *categoryResolver) ID() int32 {
+	return int32(r.category.ID)
+}
+
+func (r *categoryResolver) Name() string {
+	return r.category.Name
+}
+
+func (r *categoryResolver) Description() string {
+	return r.category.Description
+}
+
+type categoryResourcesArgs struct {
+	OwnerID *string
+	First   int32
+	After   *string
+}
+
+func (r *categoryResolver) Resources(ctx context.Context, args categoryResourcesArgs) (*connectionResolver, error) {
+	resources, err := loadersFromContext(ctx).root.resourcesByCategory(ctx, r.category.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newConnection(ctx, resources, args.OwnerID, args.First, args.After)
+}
+
+type connectionResolver struct {
+	page       []*models.Resource
+	offset     int
+	totalCount int
+}
+
+// newConnection returns the requested page of resources and queues their attachments for a single batched lookup.
+func newConnection(ctx context.Context, resources []*models.Resource, ownerID *string, first int32, after *string) (*connectionResolver, error) {
+	if ownerID != nil {
+		resources = filter(resources, func(resource *models.Resource) bool {
+			return resource.OwnerID == *ownerID
+		})
+	}
+
+	size := int(first)
+	if size < 0 || size > maxPageSize {
+		return nil, errors.Errorf("first must be between 0 and %d", maxPageSize)
+	}
+
+	offset := 0
+	if after != nil {
+		var err error
+		offset, err = decodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		offset++
+	}
+	if offset > len(resources) {
+		offset = len(resources)
+	}
+	end := offset + size
+	if end > len(resources) {
+		end = len(resources)
+	}
+
+	page := resources[offset:end]
+	var ids []uuid.UUID
+	for _, resource := range page {
+		ids = append(ids, attachmentIDs(resource)...)
+	}
+	if len(ids) > 0 {
+		loadersFromContext(ctx).resources.Prime(ctx, ids)
+	}
+
+	return &connectionResolver{
+		page:       page,
+		offset:     offset,
+		totalCount: len(resources),
+	}, nil
+}
+
+func filter(resources []*models.Resource, keep func(resource *models.Resource) bool) []*models.Resource {
+	kept := make([]*models.Resource, 0, len(resources))
+	for _, resource := range resources {
+		if keep(resource) {
+			kept = append(kept, resource)
+		}
+	}
+	return kept
+}
+
+func (r *connectionResolver) Edges() []*edgeResolver {
+	edges := make([]*edgeResolver, len(r.page))
+	for i, resource := range r.page {
+		edges[i] = &edgeResolver{cursor: encodeCursor(r.offset + i), resource: resource}
+	}
+	return edges
+}
+
+func (r *connectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNextPage: r.offset+len(r.page) < r.totalCount}
+	if len(r.page) > 0 {
+		cursor := encodeCursor(r.offset + len(r.page) - 1)
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+func (r *connectionResolver) TotalCount() int32 {
+	return int32(r.totalCount)
+}
+
+type edgeResolver struct {
+	cursor   string
+	resource *models.Resource
+}
+
+func (r *edgeResolver) Cursor() string {
+	return r.cursor
+}
+
+func (r *edgeResolver) Node() *resourceResolver {
+	return &resourceResolver{resource: r.resource}
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+const cursorPrefix = "offset:"
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(data), cursorPrefix) {
+		return 0, errors.Errorf("invalid cursor %q", cursor)
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, errors.Errorf("invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
+
+// loaders holds the request scoped caches of the resolvers.
+type loaders struct {
+	root      *rootResolver
+	resources *resourceLoader
+
+	categoriesOnce sync.Once
+	categoryList   []models.Category
+	categoriesErr  error
+}
+
+type loadersKey struct{}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (l *loaders) categories(ctx context.Context) ([]models.Category, error) {
+	l.categoriesOnce.Do(func() {
+		l.categoryList, l.categoriesErr = l.root.svc.GetCategories(ctx)
+	})
+	return l.categoryList, l.categoriesErr
+}
+
+func (l *loaders) category(ctx context.Context, id int) (*models.Category, error) {
+	categories, err := l.categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,91 @@
+package gql
+
+// schemaString describes the resources and categories of the tenant as a graph.
+// The attachments of a resource are the resources referenced by the keys of its content.
+const schemaString = `
+schema {
+	query: Query
+}
+
+# RFC 3339 timestamp.
+scalar Time
+
+type Query {
+	# The resource with the given id, null if it does not exist or its schedule hides it.
+	resource(id: ID!): Resource
+	# Resources selected by id, at most 100 of them, or by category, at least one of the two filters is required.
+	resources(ids: [ID!], category: Int, ownerId: String, first: Int = 20, after: String): ResourceConnection!
+	# The categories of the tenant.
+	categories: [Category!]!
+	# The category with the given id, null if it does not exist.
+	category(id: Int!): Category
+}
+
+type Resource {
+	id: ID!
+	ownerId: String
+	category: Category
+	# The location entry of the content.
+	location: String
+	content: [ContentEntry!]!
+	# The resources referenced by the content, in the order of their keys.
+	attachments: [Resource!]!
+	createdAt: Time!
+	updatedAt: Time!
+	# The listings leave out the resources before publishAt and after expireAt.
+	publishAt: Time
+	expireAt: Time
+	# The file uploaded to the resource, it is downloaded from the location.
+	blob: Blob
+	# The hex encoded SHA-256 digest and the size in bytes of the file at the location, when known.
+	checksum: String
+	size: Float
+	# The result of the last verification of the file.
+	integrity: Integrity
+	# Whether the file was found missing or changed, clients may hide these resources.
+	broken: Boolean!
+}
+
+type Integrity {
+	# ok, missing or mismatch.
+	status: String!
+	detail: String
+	checkedAt: Time!
+}
+
+type Blob {
+	# The size in bytes, a Float as it may not fit an Int.
+	size: Float!
+	contentType: String!
+	# The hex encoded SHA-256 digest of the content.
+	sha256: String!
+}
+
+type ContentEntry {
+	key: String!
+	value: String!
+}
+
+type Category {
+	id: Int!
+	name: String!
+	description: String!
+	resources(ownerId: String, first: Int = 20, after: String): ResourceConnection!
+}
+
+type ResourceConnection {
+	edges: [ResourceEdge!]!
+	pageInfo: PageInfo!
+	totalCount: Int!
+}
+
+type ResourceEdge {
+	cursor: String!
+	node: Resource!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+`
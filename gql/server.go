@@ -0,0 +1,68 @@
+// Package gql serves the resources and categories of the tenant as a GraphQL graph.
+package gql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+)
+
+const (
+	// batchWait is how long the resource loader collects keys before it queries them.
+	batchWait = 2 * time.Millisecond
+	// maxBatchSize caps the number of ids of a single lookup, like the REST and gRPC lookups are.
+	maxBatchSize  = httpModels.MaxResourceIDs
+	maxQueryDepth = 10
+)
+
+// Request is the body of a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query" query:"query" validate:"required"`
+	OperationName string                 `json:"operationName" query:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type Server struct {
+	root   *rootResolver
+	schema *graphql.Schema
+}
+
+func NewServer(svc *service.Service) (*Server, error) {
+	root := &rootResolver{svc: svc}
+	schema, err := graphql.ParseSchema(schemaString, root, graphql.MaxDepth(maxQueryDepth))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		root:   root,
+		schema: schema,
+	}, nil
+}
+
+// Exec runs the request. The resolvers of a request share one resource loader,
+// so the attachments of every resource in the response are fetched with a single query.
+func (s *Server) Exec(ctx context.Context, req *Request) *graphql.Response {
+	l := &loaders{root: s.root}
+	l.resources = newResourceLoader(s.fetchResources, batchWait, maxBatchSize)
+	ctx = context.WithValue(ctx, loadersKey{}, l)
+
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+func (s *Server) fetchResources(ctx context.Context, ids []uuid.UUID) ([]models.Resource, error) {
+	resources, err := s.root.svc.GetResourcesByIDs(ctx, &httpModels.GetResourcesByIDsRequest{UUIDs: ids})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return resources, nil
+}
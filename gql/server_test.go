@@ -0,0 +1,80 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/proemergotech/log/v3"
+	"github.com/proemergotech/log/v3/zaplog"
+	"go.uber.org/zap"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+)
+
+type emptyContextMapper struct{}
+
+func (emptyContextMapper) Values(context.Context) map[string]string {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	log.SetGlobalLogger(zaplog.NewLogger(zap.NewNop(), emptyContextMapper{}))
+	os.Exit(m.Run())
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "resources.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	st := storage.NewSQLite(db, 0, nil)
+	if err := st.BootstrapSystem(""); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewServer(service.NewService(st, blobs, 1<<20, "", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestResourcesIDsLimit(t *testing.T) {
+	server := newTestServer(t)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{TenantID: auth.DefaultTenantID})
+
+	query := func(count int) string {
+		ids := make([]string, count)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("%q", uuid.New().String())
+		}
+		return fmt.Sprintf("{ resources(ids: [%s]) { totalCount } }", strings.Join(ids, ", "))
+	}
+
+	resp := server.Exec(ctx, &Request{Query: query(httpModels.MaxResourceIDs + 1)})
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != errTooManyResourceIDs.Error() {
+		t.Errorf("errors = %v, want the ids refused", resp.Errors)
+	}
+
+	resp = server.Exec(ctx, &Request{Query: query(httpModels.MaxResourceIDs)})
+	if len(resp.Errors) != 0 {
+		t.Errorf("errors = %v, want the ids looked up", resp.Errors)
+	}
+}
@@ -0,0 +1,3 @@
+package grpcapi
+
+//go:generate protoc -I ../proto --go_out=resourcesv1 --go_opt=paths=source_relative --go-grpc_out=resourcesv1 --go-grpc_opt=paths=source_relative resources/v1/resources.proto
@@ -0,0 +1,283 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/grpcapi/resourcesv1"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+	"github.com/artofimagination/mysql-resources-db-go-service/validation"
+)
+
+// handler implements the ResourceService on top of service.Service.
+// The requests are converted to the REST request models, so both APIs are validated by the same rules.
+type handler struct {
+	resourcesv1.UnimplementedResourceServiceServer
+
+	svc       *service.Service
+	validator *validation.Validator
+}
+
+func newHandler(svc *service.Service, validator *validation.Validator) *handler {
+	return &handler{
+		svc:       svc,
+		validator: validator,
+	}
+}
+
+func (h *handler) AddResource(ctx context.Context, req *resourcesv1.AddResourceRequest) (*resourcesv1.AddResourceResponse, error) {
+	resource, err := fromProtoResource(req.GetResource())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.validator.Validate(resource); err != nil {
+		return nil, err
+	}
+
+	resp, err := h.svc.AddResource(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resourcesv1.AddResourceResponse{Resource: toProtoResource(resp)}, nil
+}
+
+func (h *handler) GetResource(ctx context.Context, req *resourcesv1.GetResourceRequest) (*resourcesv1.Resource, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := h.svc.GetResourceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoResource(resource), nil
+}
+
+func (h *handler) UpdateResource(ctx context.Context, req *resourcesv1.UpdateResourceRequest) (*resourcesv1.UpdateResourceResponse, error) {
+	resource, err := fromProtoResource(req.GetResource())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.validator.Validate(resource); err != nil {
+		return nil, err
+	}
+
+	if err := h.svc.UpdateResource(ctx, resource); err != nil {
+		return nil, err
+	}
+
+	return &resourcesv1.UpdateResourceResponse{}, nil
+}
+
+func (h *handler) DeleteResource(ctx context.Context, req *resourcesv1.DeleteResourceRequest) (*resourcesv1.DeleteResourceResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	deleteReq := &httpModels.DeleteResourceRequest{
+		ID:       id,
+		Category: int(req.GetCategory()),
+		Content:  models.ContentMap(req.GetContent()),
+	}
+	if err := h.validator.Validate(deleteReq); err != nil {
+		return nil, err
+	}
+
+	if err := h.svc.DeleteResource(ctx, deleteReq); err != nil {
+		return nil, err
+	}
+
+	return &resourcesv1.DeleteResourceResponse{}, nil
+}
+
+func (h *handler) ListResourcesByIDs(req *resourcesv1.ListResourcesByIDsRequest, stream resourcesv1.ResourceService_ListResourcesByIDsServer) error {
+	listReq := &httpModels.GetResourcesByIDsRequest{ResourceListRequest: fromProtoListOptions(req.GetOptions())}
+	for _, idString := range req.GetIds() {
+		id, err := parseID(idString)
+		if err != nil {
+			return err
+		}
+		listReq.UUIDs = append(listReq.UUIDs, id)
+	}
+
+	if err := h.validator.Validate(listReq); err != nil {
+		return err
+	}
+
+	resources, err := h.svc.GetResourcesByIDs(stream.Context(), listReq)
+	if err != nil {
+		return err
+	}
+
+	return sendResources(resources, stream.Send)
+}
+
+func (h *handler) ListResourcesByCategory(req *resourcesv1.ListResourcesByCategoryRequest, stream resourcesv1.ResourceService_ListResourcesByCategoryServer) error {
+	listReq := &httpModels.GetResourcesByCategoryRequest{
+		Category:            int(req.GetCategory()),
+		ResourceListRequest: fromProtoListOptions(req.GetOptions()),
+	}
+	if err := h.validator.Validate(listReq); err != nil {
+		return err
+	}
+
+	resources, err := h.svc.GetResourcesByCategory(stream.Context(), listReq)
+	if err != nil {
+		return err
+	}
+
+	return sendResources(resources, stream.Send)
+}
+
+func (h *handler) ListCategories(_ *resourcesv1.ListCategoriesRequest, stream resourcesv1.ResourceService_ListCategoriesServer) error {
+	categories, err := h.svc.GetCategories(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	for i := range categories {
+		if err := stream.Send(toProtoCategory(&categories[i])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sendResources(resources []models.Resource, send func(*resourcesv1.Resource) error) error {
+	for i := range resources {
+		if err := send(toProtoResource(&resources[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseID(idString string) (uuid.UUID, error) {
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid resource id %q", idString)
+	}
+	return id, nil
+}
+
+func fromProtoResource(resource *resourcesv1.Resource) (*models.Resource, error) {
+	if resource == nil {
+		return nil, status.Error(codes.InvalidArgument, "resource is required")
+	}
+
+	id, err := parseID(resource.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Resource{
+		ID:        id,
+		Category:  int(resource.GetCategory()),
+		Content:   models.ContentMap(resource.GetContent()),
+		PublishAt: fromProtoTime(resource.GetPublishAt()),
+		ExpireAt:  fromProtoTime(resource.GetExpireAt()),
+		Checksum:  resource.GetChecksum(),
+		Size:      fromProtoInt64(resource.GetSize()),
+	}, nil
+}
+
+func toProtoResource(resource *models.Resource) *resourcesv1.Resource {
+	return &resourcesv1.Resource{
+		Id:        resource.ID.String(),
+		OwnerId:   resource.OwnerID,
+		Category:  int32(resource.Category),
+		Content:   resource.Content,
+		CreatedAt: timestamppb.New(resource.CreatedAt),
+		UpdatedAt: timestamppb.New(resource.UpdatedAt),
+		PublishAt: toProtoTime(resource.PublishAt),
+		ExpireAt:  toProtoTime(resource.ExpireAt),
+		Blob:      toProtoBlob(resource.Blob),
+		Checksum:  resource.Checksum,
+		Size:      toProtoInt64(resource.Size),
+		Integrity: toProtoIntegrity(resource.Integrity),
+	}
+}
+
+func toProtoBlob(blob *models.Blob) *resourcesv1.Blob {
+	if blob == nil {
+		return nil
+	}
+	return &resourcesv1.Blob{
+		Size:        blob.Size,
+		ContentType: blob.ContentType,
+		Sha256:      blob.SHA256,
+	}
+}
+
+func toProtoIntegrity(integrity *models.Integrity) *resourcesv1.Integrity {
+	if integrity == nil {
+		return nil
+	}
+	return &resourcesv1.Integrity{
+		Status:    integrity.Status,
+		Detail:    integrity.Detail,
+		CheckedAt: timestamppb.New(integrity.CheckedAt),
+	}
+}
+
+func fromProtoInt64(v *wrapperspb.Int64Value) *int64 {
+	if v == nil {
+		return nil
+	}
+	value := v.GetValue()
+	return &value
+}
+
+func toProtoInt64(v *int64) *wrapperspb.Int64Value {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Int64(*v)
+}
+
+func fromProtoTime(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	at := t.AsTime()
+	return &at
+}
+
+func toProtoTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func fromProtoListOptions(options *resourcesv1.ListOptions) httpModels.ResourceListRequest {
+	return httpModels.ResourceListRequest{
+		Sort:          options.GetSort(),
+		Since:         fromProtoTime(options.GetSince()),
+		Until:         fromProtoTime(options.GetUntil()),
+		IncludeHidden: options.GetIncludeHidden(),
+	}
+}
+
+func toProtoCategory(category *models.Category) *resourcesv1.Category {
+	return &resourcesv1.Category{
+		Id:          int32(category.ID),
+		Name:        category.Name,
+		Description: category.Description,
+	}
+}
@@ -0,0 +1,260 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/requestinfo"
+)
+
+// maxIdentityLength matches the size of the tenant_id and owner_id columns.
+const maxIdentityLength = 64
+
+const requestIDKey = "x-request-id"
+
+// httpToGRPCCodes translates the HTTP status attached to the service errors.
+// The legacy REST routes answer 202 for missing resources, over gRPC that is a plain NotFound.
+var httpToGRPCCodes = map[int]codes.Code{
+	http.StatusAccepted:            codes.NotFound,
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+// toStatus converts a service error to a gRPC status error, using the same HTTP code field as the REST error handler.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if err == context.Canceled {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	if err == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	code := codes.Internal
+	if httpCode, ok := myerrors.Field(err, models.HTTPCode).(int); ok {
+		if c, ok := httpToGRPCCodes[httpCode]; ok {
+			code = c
+		}
+	}
+
+	return status.Error(code, err.Error())
+}
+
+// logError logs the error with the same severity split as the REST error handler.
+func logError(ctx context.Context, err error) {
+	switch status.Code(err) {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		log.Error(ctx, err.Error(), "error", err)
+	default:
+		log.Warn(ctx, err.Error(), "error", err)
+	}
+}
+
+func errorUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			err = toStatus(err)
+			logError(ctx, err)
+		}
+		return resp, err
+	}
+}
+
+func errorStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if err != nil {
+			err = toStatus(err)
+			logError(ss.Context(), err)
+		}
+		return err
+	}
+}
+
+func recoveryUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func recoveryStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, method string, r interface{}) error {
+	err := errors.Errorf("panic in %s: %v", method, r)
+	log.Error(ctx, err.Error(), "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// withRequestContext attaches the request details and the caller identity to ctx,
+// the same way the REST middlewares do from the HTTP headers.
+func withRequestContext(ctx context.Context, method string, tenantHeader string, ownerHeader string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	info := requestinfo.Info{
+		RequestID: requestinfo.RequestID(firstValue(md, requestIDKey)),
+		Route:     method,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(info.ClientIP); err == nil {
+			info.ClientIP = host
+		}
+	}
+	ctx = requestinfo.WithInfo(ctx, info)
+
+	if _, ok := auth.IdentityFromContext(ctx); ok {
+		return ctx, nil
+	}
+
+	identity := auth.Identity{
+		TenantID: firstValue(md, tenantHeader),
+		OwnerID:  firstValue(md, ownerHeader),
+	}
+	if identity.TenantID == "" {
+		identity.TenantID = auth.DefaultTenantID
+	}
+
+	if len(identity.TenantID) > maxIdentityLength || len(identity.OwnerID) > maxIdentityLength {
+		return nil, status.Error(codes.InvalidArgument, "tenant or owner identifier is too long")
+	}
+
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(strings.ToLower(key))
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func contextUnaryInterceptor(tenantHeader string, ownerHeader string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := withRequestContext(ctx, info.FullMethod, tenantHeader, ownerHeader)
+		if err != nil {
+			return nil, err
+		}
+		// the request ID is echoed like the REST API does, a failure only loses the header
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestinfo.FromContext(ctx).RequestID))
+		return handler(ctx, req)
+	}
+}
+
+func contextStreamInterceptor(tenantHeader string, ownerHeader string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withRequestContext(ss.Context(), info.FullMethod, tenantHeader, ownerHeader)
+		if err != nil {
+			return err
+		}
+		_ = ss.SetHeader(metadata.Pairs(requestIDKey, requestinfo.FromContext(ctx).RequestID))
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextServerStream replaces the context of a server stream.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// ClientCertificates takes the caller identity from the verified client certificates instead of the metadata,
+// the tenant and the owner are the given attributes of the certificate subject, see auth.CertificateIdentity.
+type ClientCertificates struct {
+	TenantAttribute string
+	OwnerAttribute  string
+}
+
+// clientCertificateSkippedServices are served without a client certificate, the health checks come from the infrastructure.
+var clientCertificateSkippedServices = map[string]bool{healthpb.Health_ServiceDesc.ServiceName: true}
+
+// withCertificateIdentity attaches the identity of the verified client certificate of the peer to ctx,
+// the calls without a certificate are rejected with Unauthenticated.
+func withCertificateIdentity(ctx context.Context, method string, clientCertificates *ClientCertificates) (context.Context, error) {
+	if clientCertificateSkippedServices[serviceName(method)] {
+		return ctx, nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "A client certificate is required")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "A client certificate is required")
+	}
+
+	identity := auth.CertificateIdentity(tlsInfo.State.VerifiedChains[0][0], clientCertificates.TenantAttribute, clientCertificates.OwnerAttribute)
+	if len(identity.TenantID) > maxIdentityLength || len(identity.OwnerID) > maxIdentityLength {
+		return nil, status.Error(codes.InvalidArgument, "tenant or owner identifier is too long")
+	}
+
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+// serviceName returns the service of a full method name, /package.Service/Method.
+func serviceName(method string) string {
+	parts := strings.SplitN(strings.TrimPrefix(method, "/"), "/", 2)
+	return parts[0]
+}
+
+func certificateUnaryInterceptor(clientCertificates *ClientCertificates) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := withCertificateIdentity(ctx, info.FullMethod, clientCertificates)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func certificateStreamInterceptor(clientCertificates *ClientCertificates) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withCertificateIdentity(ss.Context(), info.FullMethod, clientCertificates)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
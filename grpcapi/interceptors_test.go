@@ -0,0 +1,92 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+)
+
+func TestToStatus(t *testing.T) {
+	withCode := func(httpCode int) error {
+		return myerrors.WithFields(errors.New("service error"), models.HTTPCode, httpCode)
+	}
+
+	tests := map[string]struct {
+		err  error
+		want codes.Code
+	}{
+		"missing legacy":    {withCode(http.StatusAccepted), codes.NotFound},
+		"bad request":       {withCode(http.StatusBadRequest), codes.InvalidArgument},
+		"forbidden":         {withCode(http.StatusForbidden), codes.PermissionDenied},
+		"not found":         {withCode(http.StatusNotFound), codes.NotFound},
+		"conflict":          {withCode(http.StatusConflict), codes.AlreadyExists},
+		"too many requests": {withCode(http.StatusTooManyRequests), codes.ResourceExhausted},
+		"internal":          {withCode(http.StatusInternalServerError), codes.Internal},
+		"unavailable":       {withCode(http.StatusServiceUnavailable), codes.Unavailable},
+		"unmapped code":     {withCode(http.StatusTeapot), codes.Internal},
+		"no code":           {errors.New("plain error"), codes.Internal},
+		"canceled":          {context.Canceled, codes.Canceled},
+		"deadline exceeded": {context.DeadlineExceeded, codes.DeadlineExceeded},
+		"status":            {status.Error(codes.FailedPrecondition, "kept"), codes.FailedPrecondition},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := toStatus(test.err)
+			if got := status.Code(err); got != test.want {
+				t.Errorf("code = %s, want %s", got, test.want)
+			}
+			if s, _ := status.FromError(err); s.Message() != status.Convert(test.err).Message() {
+				t.Errorf("message = %q, want the one of the error", s.Message())
+			}
+		})
+	}
+}
+
+func TestErrorInterceptors(t *testing.T) {
+	serviceErr := myerrors.WithFields(errors.New("conflict"), models.HTTPCode, http.StatusConflict)
+
+	_, err := errorUnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Unary"},
+		func(context.Context, interface{}) (interface{}, error) {
+			return nil, serviceErr
+		})
+	if got := status.Code(err); got != codes.AlreadyExists {
+		t.Errorf("unary code = %s, want %s", got, codes.AlreadyExists)
+	}
+
+	err = errorStreamInterceptor()(nil, &contextServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(interface{}, grpc.ServerStream) error {
+			return serviceErr
+		})
+	if got := status.Code(err); got != codes.AlreadyExists {
+		t.Errorf("stream code = %s, want %s", got, codes.AlreadyExists)
+	}
+}
+
+func TestRecoveryInterceptors(t *testing.T) {
+	resp, err := recoveryUnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Unary"},
+		func(context.Context, interface{}) (interface{}, error) {
+			panic("unary failure")
+		})
+	if resp != nil || status.Code(err) != codes.Internal {
+		t.Errorf("unary got %v, %v, want an Internal error", resp, err)
+	}
+	if s, _ := status.FromError(err); s.Message() != "internal error" {
+		t.Errorf("unary message = %q, the panic must not reach the client", s.Message())
+	}
+
+	err = recoveryStreamInterceptor()(nil, &contextServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(interface{}, grpc.ServerStream) error {
+			panic("stream failure")
+		})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("stream got %v, want an Internal error", err)
+	}
+}
@@ -0,0 +1,405 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package resourcesv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ResourceServiceClient is the client API for ResourceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ResourceServiceClient interface {
+	AddResource(ctx context.Context, in *AddResourceRequest, opts ...grpc.CallOption) (*AddResourceResponse, error)
+	GetResource(ctx context.Context, in *GetResourceRequest, opts ...grpc.CallOption) (*Resource, error)
+	UpdateResource(ctx context.Context, in *UpdateResourceRequest, opts ...grpc.CallOption) (*UpdateResourceResponse, error)
+	DeleteResource(ctx context.Context, in *DeleteResourceRequest, opts ...grpc.CallOption) (*DeleteResourceResponse, error)
+	// ListResourcesByIDs streams the resources with the given ids.
+	ListResourcesByIDs(ctx context.Context, in *ListResourcesByIDsRequest, opts ...grpc.CallOption) (ResourceService_ListResourcesByIDsClient, error)
+	// ListResourcesByCategory streams every resource of the category.
+	ListResourcesByCategory(ctx context.Context, in *ListResourcesByCategoryRequest, opts ...grpc.CallOption) (ResourceService_ListResourcesByCategoryClient, error)
+	// ListCategories streams the categories of the tenant.
+	ListCategories(ctx context.Context, in *ListCategoriesRequest, opts ...grpc.CallOption) (ResourceService_ListCategoriesClient, error)
+}
+
+type resourceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewResourceServiceClient(cc grpc.ClientConnInterface) ResourceServiceClient {
+	return &resourceServiceClient{cc}
+}
+
+func (c *resourceServiceClient) AddResource(ctx context.Context, in *AddResourceRequest, opts ...grpc.CallOption) (*AddResourceResponse, error) {
+	out := new(AddResourceResponse)
+	err := c.cc.Invoke(ctx, "/resources.v1.ResourceService/AddResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) GetResource(ctx context.Context, in *GetResourceRequest, opts ...grpc.CallOption) (*Resource, error) {
+	out := new(Resource)
+	err := c.cc.Invoke(ctx, "/resources.v1.ResourceService/GetResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) UpdateResource(ctx context.Context, in *UpdateResourceRequest, opts ...grpc.CallOption) (*UpdateResourceResponse, error) {
+	out := new(UpdateResourceResponse)
+	err := c.cc.Invoke(ctx, "/resources.v1.ResourceService/UpdateResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) DeleteResource(ctx context.Context, in *DeleteResourceRequest, opts ...grpc.CallOption) (*DeleteResourceResponse, error) {
+	out := new(DeleteResourceResponse)
+	err := c.cc.Invoke(ctx, "/resources.v1.ResourceService/DeleteResource", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceServiceClient) ListResourcesByIDs(ctx context.Context, in *ListResourcesByIDsRequest, opts ...grpc.CallOption) (ResourceService_ListResourcesByIDsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ResourceService_ServiceDesc.Streams[0], "/resources.v1.ResourceService/ListResourcesByIDs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &resourceServiceListResourcesByIDsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ResourceService_ListResourcesByIDsClient interface {
+	Recv() (*Resource, error)
+	grpc.ClientStream
+}
+
+type resourceServiceListResourcesByIDsClient struct {
+	grpc.ClientStream
+}
+
+func (x *resourceServiceListResourcesByIDsClient) Recv() (*Resource, error) {
+	m := new(Resource)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *resourceServiceClient) ListResourcesByCategory(ctx context.Context, in *ListResourcesByCategoryRequest, opts ...grpc.CallOption) (ResourceService_ListResourcesByCategoryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ResourceService_ServiceDesc.Streams[1], "/resources.v1.ResourceService/ListResourcesByCategory", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &resourceServiceListResourcesByCategoryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ResourceService_ListResourcesByCategoryClient interface {
+	Recv() (*Resource, error)
+	grpc.ClientStream
+}
+
+type resourceServiceListResourcesByCategoryClient struct {
+	grpc.ClientStream
+}
+
+func (x *resourceServiceListResourcesByCategoryClient) Recv() (*Resource, error) {
+	m := new(Resource)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *resourceServiceClient) ListCategories(ctx context.Context, in *ListCategoriesRequest, opts ...grpc.CallOption) (ResourceService_ListCategoriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ResourceService_ServiceDesc.Streams[2], "/resources.v1.ResourceService/ListCategories", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &resourceServiceListCategoriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ResourceService_ListCategoriesClient interface {
+	Recv() (*Category, error)
+	grpc.ClientStream
+}
+
+type resourceServiceListCategoriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *resourceServiceListCategoriesClient) Recv() (*Category, error) {
+	m := new(Category)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ResourceServiceServer is the server API for ResourceService service.
+// All implementations must embed UnimplementedResourceServiceServer
+// for forward compatibility
+type ResourceServiceServer interface {
+	AddResource(context.Context, *AddResourceRequest) (*AddResourceResponse, error)
+	GetResource(context.Context, *GetResourceRequest) (*Resource, error)
+	UpdateResource(context.Context, *UpdateResourceRequest) (*UpdateResourceResponse, error)
+	DeleteResource(context.Context, *DeleteResourceRequest) (*DeleteResourceResponse, error)
+	// ListResourcesByIDs streams the resources with the given ids.
+	ListResourcesByIDs(*ListResourcesByIDsRequest, ResourceService_ListResourcesByIDsServer) error
+	// ListResourcesByCategory streams every resource of the category.
+	ListResourcesByCategory(*ListResourcesByCategoryRequest, ResourceService_ListResourcesByCategoryServer) error
+	// ListCategories streams the categories of the tenant.
+	ListCategories(*ListCategoriesRequest, ResourceService_ListCategoriesServer) error
+	mustEmbedUnimplementedResourceServiceServer()
+}
+
+// UnimplementedResourceServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedResourceServiceServer struct {
+}
+
+func (UnimplementedResourceServiceServer) AddResource(context.Context, *AddResourceRequest) (*AddResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddResource not implemented")
+}
+func (UnimplementedResourceServiceServer) GetResource(context.Context, *GetResourceRequest) (*Resource, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResource not implemented")
+}
+func (UnimplementedResourceServiceServer) UpdateResource(context.Context, *UpdateResourceRequest) (*UpdateResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateResource not implemented")
+}
+func (UnimplementedResourceServiceServer) DeleteResource(context.Context, *DeleteResourceRequest) (*DeleteResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteResource not implemented")
+}
+func (UnimplementedResourceServiceServer) ListResourcesByIDs(*ListResourcesByIDsRequest, ResourceService_ListResourcesByIDsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListResourcesByIDs not implemented")
+}
+func (UnimplementedResourceServiceServer) ListResourcesByCategory(*ListResourcesByCategoryRequest, ResourceService_ListResourcesByCategoryServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListResourcesByCategory not implemented")
+}
+func (UnimplementedResourceServiceServer) ListCategories(*ListCategoriesRequest, ResourceService_ListCategoriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListCategories not implemented")
+}
+func (UnimplementedResourceServiceServer) mustEmbedUnimplementedResourceServiceServer() {}
+
+// UnsafeResourceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ResourceServiceServer will
+// result in compilation errors.
+type UnsafeResourceServiceServer interface {
+	mustEmbedUnimplementedResourceServiceServer()
+}
+
+func RegisterResourceServiceServer(s grpc.ServiceRegistrar, srv ResourceServiceServer) {
+	s.RegisterService(&ResourceService_ServiceDesc, srv)
+}
+
+func _ResourceService_AddResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).AddResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/resources.v1.ResourceService/AddResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).AddResource(ctx, req.(*AddResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_GetResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).GetResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/resources.v1.ResourceService/GetResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).GetResource(ctx, req.(*GetResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_UpdateResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).UpdateResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/resources.v1.ResourceService/UpdateResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).UpdateResource(ctx, req.(*UpdateResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_DeleteResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceServiceServer).DeleteResource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/resources.v1.ResourceService/DeleteResource",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceServiceServer).DeleteResource(ctx, req.(*DeleteResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceService_ListResourcesByIDs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListResourcesByIDsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ResourceServiceServer).ListResourcesByIDs(m, &resourceServiceListResourcesByIDsServer{stream})
+}
+
+type ResourceService_ListResourcesByIDsServer interface {
+	Send(*Resource) error
+	grpc.ServerStream
+}
+
+type resourceServiceListResourcesByIDsServer struct {
+	grpc.ServerStream
+}
+
+func (x *resourceServiceListResourcesByIDsServer) Send(m *Resource) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ResourceService_ListResourcesByCategory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListResourcesByCategoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ResourceServiceServer).ListResourcesByCategory(m, &resourceServiceListResourcesByCategoryServer{stream})
+}
+
+type ResourceService_ListResourcesByCategoryServer interface {
+	Send(*Resource) error
+	grpc.ServerStream
+}
+
+type resourceServiceListResourcesByCategoryServer struct {
+	grpc.ServerStream
+}
+
+func (x *resourceServiceListResourcesByCategoryServer) Send(m *Resource) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ResourceService_ListCategories_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListCategoriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ResourceServiceServer).ListCategories(m, &resourceServiceListCategoriesServer{stream})
+}
+
+type ResourceService_ListCategoriesServer interface {
+	Send(*Category) error
+	grpc.ServerStream
+}
+
+type resourceServiceListCategoriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *resourceServiceListCategoriesServer) Send(m *Category) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ResourceService_ServiceDesc is the grpc.ServiceDesc for ResourceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ResourceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "resources.v1.ResourceService",
+	HandlerType: (*ResourceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddResource",
+			Handler:    _ResourceService_AddResource_Handler,
+		},
+		{
+			MethodName: "GetResource",
+			Handler:    _ResourceService_GetResource_Handler,
+		},
+		{
+			MethodName: "UpdateResource",
+			Handler:    _ResourceService_UpdateResource_Handler,
+		},
+		{
+			MethodName: "DeleteResource",
+			Handler:    _ResourceService_DeleteResource_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListResourcesByIDs",
+			Handler:       _ResourceService_ListResourcesByIDs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListResourcesByCategory",
+			Handler:       _ResourceService_ListResourcesByCategory_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListCategories",
+			Handler:       _ResourceService_ListCategories_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "resources/v1/resources.proto",
+}
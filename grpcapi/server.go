@@ -0,0 +1,112 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/grpcapi/resourcesv1"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+	"github.com/artofimagination/mysql-resources-db-go-service/validation"
+)
+
+// Server exposes the service over gRPC, next to the REST server.
+type Server struct {
+	port         int
+	grpcServer   *grpc.Server
+	healthServer *health.Server
+}
+
+// NewServer serves TLS with tlsConfig if it is not nil. With clientCertificates the caller identity is taken from
+// the verified client certificates and the calls without one are rejected, the identity metadata is ignored.
+func NewServer(
+	port int,
+	svc *service.Service,
+	validator *validation.Validator,
+	tenantHeader string,
+	ownerHeader string,
+	tlsConfig *tls.Config,
+	clientCertificates *ClientCertificates,
+) *Server {
+	unaryInterceptors := []grpc.UnaryServerInterceptor{recoveryUnaryInterceptor()}
+	streamInterceptors := []grpc.StreamServerInterceptor{recoveryStreamInterceptor()}
+	if clientCertificates != nil {
+		unaryInterceptors = append(unaryInterceptors, certificateUnaryInterceptor(clientCertificates))
+		streamInterceptors = append(streamInterceptors, certificateStreamInterceptor(clientCertificates))
+	}
+	unaryInterceptors = append(unaryInterceptors, contextUnaryInterceptor(tenantHeader, ownerHeader), errorUnaryInterceptor())
+	streamInterceptors = append(streamInterceptors, contextStreamInterceptor(tenantHeader, ownerHeader), errorStreamInterceptor())
+
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(options...)
+
+	healthServer := health.NewServer()
+	resourcesv1.RegisterResourceServiceServer(grpcServer, newHandler(svc, validator))
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	return &Server{
+		port:         port,
+		grpcServer:   grpcServer,
+		healthServer: healthServer,
+	}
+}
+
+func (s *Server) Start(_ context.Context, errorCh chan<- error) {
+	s.healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	s.healthServer.SetServingStatus(resourcesv1.ResourceService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	go func() {
+		listener, err := net.Listen("tcp", ":"+strconv.Itoa(s.port))
+		if err != nil {
+			errorCh <- errors.Wrap(err, "grpc listen failed")
+			return
+		}
+
+		if err := s.grpcServer.Serve(listener); err != nil {
+			errorCh <- errors.Wrap(err, "grpc server error")
+		}
+	}()
+}
+
+// Drain reports the services not serving to the health checks, the calls are still served until Stop.
+func (s *Server) Drain() {
+	s.healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	s.healthServer.SetServingStatus(resourcesv1.ResourceService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func (s *Server) Stop(timeout time.Duration) error {
+	s.healthServer.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return errors.New("grpc server graceful shutdown timed out")
+	}
+}
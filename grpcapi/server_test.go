@@ -0,0 +1,469 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/proemergotech/log/v3"
+	"github.com/proemergotech/log/v3/zaplog"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	"github.com/artofimagination/mysql-resources-db-go-service/certs"
+	"github.com/artofimagination/mysql-resources-db-go-service/grpcapi/resourcesv1"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+	"github.com/artofimagination/mysql-resources-db-go-service/validation"
+)
+
+const (
+	testTenantHeader = "X-Tenant-ID"
+	testOwnerHeader  = "X-Owner-ID"
+	testTenant       = "grpc-tenant"
+)
+
+type emptyContextMapper struct{}
+
+func (emptyContextMapper) Values(context.Context) map[string]string {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	log.SetGlobalLogger(zaplog.NewLogger(zap.NewNop(), emptyContextMapper{}))
+	os.Exit(m.Run())
+}
+
+// testServer is the gRPC server of a SQLite backed service, served over an in-memory connection.
+type testServer struct {
+	server    *Server
+	resources resourcesv1.ResourceServiceClient
+	health    healthpb.HealthClient
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	server, conn := startTestServer(t, nil, nil)
+	return newTestClients(server, conn(insecure.NewCredentials()))
+}
+
+// startTestServer starts the server and returns how to dial it with the given transport credentials.
+func startTestServer(
+	t *testing.T,
+	tlsConfig *tls.Config,
+	clientCertificates *ClientCertificates,
+) (*Server, func(credentials.TransportCredentials) *grpc.ClientConn) {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "resources.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	st := storage.NewSQLite(db, 0, nil)
+	if err := st.BootstrapSystem(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddTenant(ctx, testTenant, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewService(st, blobs, 1<<20, "", false)
+	server := NewServer(0, svc, validation.NewValidator(validator.New()), testTenantHeader, testOwnerHeader, tlsConfig, clientCertificates)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.grpcServer.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Stop(time.Second)
+	})
+
+	return server, func(creds credentials.TransportCredentials) *grpc.ClientConn {
+		conn, err := grpc.DialContext(ctx, "localhost",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(creds),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+		return conn
+	}
+}
+
+func newTestClients(server *Server, conn *grpc.ClientConn) *testServer {
+	return &testServer{
+		server:    server,
+		resources: resourcesv1.NewResourceServiceClient(conn),
+		health:    healthpb.NewHealthClient(conn),
+	}
+}
+
+// callContext sends the identity in the metadata, like the clients do in the headers over REST.
+func callContext(tenantID string, ownerID string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(),
+		strings.ToLower(testTenantHeader), tenantID,
+		strings.ToLower(testOwnerHeader), ownerID,
+	)
+}
+
+func listCategories(t *testing.T, ts *testServer, ctx context.Context) []*resourcesv1.Category {
+	t.Helper()
+	stream, err := ts.resources.ListCategories(ctx, &resourcesv1.ListCategoriesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var categories []*resourcesv1.Category
+	for {
+		category, err := stream.Recv()
+		if err == io.EOF {
+			return categories
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		categories = append(categories, category)
+	}
+}
+
+func addResource(t *testing.T, ts *testServer, ctx context.Context, category int32) *resourcesv1.Resource {
+	t.Helper()
+	resp, err := ts.resources.AddResource(ctx, &resourcesv1.AddResourceRequest{Resource: &resourcesv1.Resource{
+		Id:       uuid.New().String(),
+		Category: category,
+		Content:  map[string]string{"location": "https://example.com/" + uuid.New().String()},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.GetResource()
+}
+
+func assertCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("code = %s (%v), want %s", got, err, want)
+	}
+}
+
+func TestResourceLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := callContext(testTenant, "owner-1")
+
+	categories := listCategories(t, ts, ctx)
+	if len(categories) == 0 {
+		t.Fatal("the provisioned tenant has no categories")
+	}
+	category := categories[0].GetId()
+
+	added := addResource(t, ts, ctx, category)
+	if added.GetOwnerId() != "owner-1" {
+		t.Errorf("owner = %q, want the one of the metadata", added.GetOwnerId())
+	}
+
+	got, err := ts.resources.GetResource(ctx, &resourcesv1.GetResourceRequest{Id: added.GetId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetCategory() != category || got.GetContent()["location"] != added.GetContent()["location"] {
+		t.Errorf("got %v, want %v", got, added)
+	}
+
+	updated := &resourcesv1.Resource{
+		Id:       added.GetId(),
+		Category: category,
+		Content:  map[string]string{"location": "https://example.com/updated"},
+	}
+	if _, err := ts.resources.UpdateResource(ctx, &resourcesv1.UpdateResourceRequest{Resource: updated}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := ts.resources.ListResourcesByIDs(ctx, &resourcesv1.ListResourcesByIDsRequest{Ids: []string{added.GetId()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed.GetContent()["location"] != "https://example.com/updated" {
+		t.Errorf("listed %v, want the updated content", listed)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("got %v after the only resource, want io.EOF", err)
+	}
+
+	deleteReq := &resourcesv1.DeleteResourceRequest{Id: added.GetId(), Category: category, Content: updated.GetContent()}
+	if _, err := ts.resources.DeleteResource(ctx, deleteReq); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ts.resources.GetResource(ctx, &resourcesv1.GetResourceRequest{Id: added.GetId()})
+	assertCode(t, err, codes.NotFound)
+}
+
+func TestErrorCodes(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := callContext(testTenant, "owner-1")
+	added := addResource(t, ts, ctx, listCategories(t, ts, ctx)[0].GetId())
+
+	t.Run("other tenant", func(t *testing.T) {
+		_, err := ts.resources.GetResource(callContext("default", "owner-1"), &resourcesv1.GetResourceRequest{Id: added.GetId()})
+		assertCode(t, err, codes.NotFound)
+	})
+
+	t.Run("unprovisioned tenant", func(t *testing.T) {
+		_, err := ts.resources.AddResource(callContext("unprovisioned", "owner-1"), &resourcesv1.AddResourceRequest{Resource: &resourcesv1.Resource{
+			Id:       uuid.New().String(),
+			Category: added.GetCategory(),
+			Content:  map[string]string{"location": "https://example.com/unprovisioned"},
+		}})
+		assertCode(t, err, codes.PermissionDenied)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, err := ts.resources.GetResource(ctx, &resourcesv1.GetResourceRequest{Id: "not-an-id"})
+		assertCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("missing resource", func(t *testing.T) {
+		_, err := ts.resources.AddResource(ctx, &resourcesv1.AddResourceRequest{})
+		assertCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("failed validation", func(t *testing.T) {
+		_, err := ts.resources.AddResource(ctx, &resourcesv1.AddResourceRequest{Resource: &resourcesv1.Resource{
+			Id:      uuid.New().String(),
+			Content: map[string]string{"location": "https://example.com/no-category"},
+		}})
+		assertCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		_, err := ts.resources.AddResource(ctx, &resourcesv1.AddResourceRequest{Resource: &resourcesv1.Resource{
+			Id:       uuid.New().String(),
+			Category: 1 << 30,
+			Content:  map[string]string{"location": "https://example.com/unknown-category"},
+		}})
+		assertCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("stream error", func(t *testing.T) {
+		stream, err := ts.resources.ListResourcesByIDs(ctx, &resourcesv1.ListResourcesByIDsRequest{Ids: []string{"not-an-id"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = stream.Recv()
+		assertCode(t, err, codes.InvalidArgument)
+	})
+}
+
+func TestRequestContext(t *testing.T) {
+	ts := newTestServer(t)
+
+	t.Run("request id", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(callContext(testTenant, "owner-1"), requestIDKey, "grpc-request-1")
+		var header metadata.MD
+		if _, err := ts.resources.GetResource(ctx, &resourcesv1.GetResourceRequest{Id: uuid.New().String()}, grpc.Header(&header)); status.Code(err) != codes.NotFound {
+			t.Fatalf("code = %s, want %s", status.Code(err), codes.NotFound)
+		}
+		if got := header.Get(requestIDKey); len(got) != 1 || got[0] != "grpc-request-1" {
+			t.Errorf("request id header = %v, want the one sent", got)
+		}
+	})
+
+	t.Run("generated request id", func(t *testing.T) {
+		stream, err := ts.resources.ListCategories(callContext(testTenant, "owner-1"), &resourcesv1.ListCategoriesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, err := stream.Header()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := header.Get(requestIDKey); len(got) != 1 || got[0] == "" {
+			t.Errorf("request id header = %v, want a generated one", got)
+		}
+	})
+
+	t.Run("identity too long", func(t *testing.T) {
+		_, err := ts.resources.GetResource(callContext(strings.Repeat("t", maxIdentityLength+1), "owner-1"), &resourcesv1.GetResourceRequest{Id: uuid.New().String()})
+		assertCode(t, err, codes.InvalidArgument)
+
+		stream, err := ts.resources.ListCategories(callContext(testTenant, strings.Repeat("o", maxIdentityLength+1)), &resourcesv1.ListCategoriesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = stream.Recv()
+		assertCode(t, err, codes.InvalidArgument)
+	})
+}
+
+func TestDrain(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	resp, err := ts.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %s before the drain, want %s", resp.GetStatus(), healthpb.HealthCheckResponse_SERVING)
+	}
+
+	ts.server.Drain()
+	resp, err = ts.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status = %s after the drain, want %s", resp.GetStatus(), healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	// the calls are still served until the server stops
+	listCategories(t, ts, callContext(testTenant, "owner-1"))
+}
+
+func TestClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newCertificate(t, pkix.Name{CommonName: "ca"}, nil, nil)
+	writePEM(t, filepath.Join(dir, "ca.pem"), ca, nil)
+	serverCert, serverKey := newCertificate(t, pkix.Name{CommonName: "localhost"}, ca, caKey)
+	writePEM(t, filepath.Join(dir, "server.pem"), serverCert, serverKey)
+	clientCert, clientKey := newCertificate(t, pkix.Name{CommonName: "cert-owner", Organization: []string{testTenant}}, ca, caKey)
+
+	// the way the container serves gRPC with a client CA
+	reloader, err := certs.NewReloader(certs.Config{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, conn := startTestServer(t, reloader.TLSConfig(), &ClientCertificates{
+		TenantAttribute: "O",
+		OwnerAttribute:  "CN",
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	withCert := newTestClients(server, conn(credentials.NewTLS(&tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}},
+	})))
+	withoutCert := newTestClients(server, conn(credentials.NewTLS(&tls.Config{RootCAs: roots})))
+
+	t.Run("metadata tenant without a certificate", func(t *testing.T) {
+		_, err := withoutCert.resources.GetResource(callContext(testTenant, "owner-1"), &resourcesv1.GetResourceRequest{Id: uuid.New().String()})
+		assertCode(t, err, codes.Unauthenticated)
+
+		stream, err := withoutCert.resources.ListCategories(callContext(testTenant, "owner-1"), &resourcesv1.ListCategoriesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = stream.Recv()
+		assertCode(t, err, codes.Unauthenticated)
+	})
+
+	t.Run("metadata tenant with a certificate", func(t *testing.T) {
+		// the metadata names the default tenant, the resource is still added for the tenant of the certificate
+		ctx := callContext("default", "metadata-owner")
+		added := addResource(t, withCert, ctx, listCategories(t, withCert, callContext(testTenant, ""))[0].GetId())
+		if added.GetOwnerId() != "cert-owner" {
+			t.Errorf("owner = %q, want the one of the certificate", added.GetOwnerId())
+		}
+		if _, err := withCert.resources.GetResource(ctx, &resourcesv1.GetResourceRequest{Id: added.GetId()}); err != nil {
+			t.Errorf("the resource is not found in the tenant of the certificate: %v", err)
+		}
+	})
+
+	t.Run("health check without a certificate", func(t *testing.T) {
+		if _, err := withoutCert.health.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// newCertificate creates a certificate for localhost signed by parent, a self-signed CA without one.
+func newCertificate(t *testing.T, subject pkix.Name, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, path string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if key != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})...)
+	}
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+// Package health tells whether the service is ready to take requests.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Check reports why a dependency of the service cannot be used, nil if it can.
+type Check func(ctx context.Context) error
+
+// Readiness runs the checks of the dependencies, it fails as soon as the service starts draining before a shutdown.
+type Readiness struct {
+	draining      int32
+	schemaVersion string
+
+	lock   sync.RWMutex
+	names  []string
+	checks map[string]Check
+}
+
+// Report is the result of a readiness check.
+type Report struct {
+	Ready    bool `json:"ready"`
+	Draining bool `json:"draining"`
+	// SchemaVersion is the version of the database migrations built into the service.
+	SchemaVersion string `json:"schema_version,omitempty"`
+	// Checks holds "ok" or the error of every check by name.
+	Checks map[string]string `json:"checks"`
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{checks: make(map[string]Check)}
+}
+
+// AddCheck adds a dependency the service is not ready without.
+func (r *Readiness) AddCheck(name string, check Check) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.names = append(r.names, name)
+	r.checks[name] = check
+}
+
+// SetSchemaVersion sets the schema version reported by the checks.
+func (r *Readiness) SetSchemaVersion(version string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.schemaVersion = version
+}
+
+// Drain makes the readiness fail from now on, so the load balancers stop sending requests before the servers stop.
+func (r *Readiness) Drain() {
+	atomic.StoreInt32(&r.draining, 1)
+}
+
+func (r *Readiness) Draining() bool {
+	return atomic.LoadInt32(&r.draining) == 1
+}
+
+// Check runs all checks, the service is ready if none of them failed and it is not draining.
+func (r *Readiness) Check(ctx context.Context) Report {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	report := Report{
+		Ready:         !r.Draining(),
+		Draining:      r.Draining(),
+		SchemaVersion: r.schemaVersion,
+		Checks:        make(map[string]string, len(r.names)),
+	}
+	for _, name := range r.names {
+		if err := r.checks[name](ctx); err != nil {
+			report.Ready = false
+			report.Checks[name] = err.Error()
+			continue
+		}
+		report.Checks[name] = "ok"
+	}
+	return report
+}
@@ -2,52 +2,121 @@ package initialization
 
 import (
 	"context"
-	stdlog "log"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
 	"github.com/proemergotech/log/v3"
 	"github.com/spf13/viper"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/config"
 )
 
+// configFile is the YAML, TOML or JSON file given with --config, the environment variables override its settings.
+var configFile string
+
+// setLogLevel changes the level of the global logger, it is set by Execute.
+var setLogLevel = func(level string) error { return nil }
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "YAML, TOML or JSON config file, the environment variables override its settings")
+}
+
+// newConfig reads the configuration of the service and applies its log level. It panics on invalid settings.
+func newConfig() *config.Config {
+	cfg := &config.Config{}
+	initConfig(cfg)
+
+	if err := setLogLevel(cfg.LogLevel); err != nil {
+		log.Panic(context.Background(), "invalid log level", "error", err)
+	}
+	return cfg
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig(cfg interface{}) {
+	if err := loadConfig(cfg); err != nil {
+		log.Panic(context.Background(), "invalid configuration", "error", err)
+	}
+}
+
+// loadConfig reads the settings into cfg, from the environment, the config file and the default tags, in this order of precedence.
+// The secrets not set are read from their files or the secret provider.
+// It can be called again to reload the configuration.
+func loadConfig(cfg interface{}) error {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	hasErrors := false
+	known := make(map[string]bool)
 	val := reflect.ValueOf(cfg).Elem()
 	for i := 0; i < val.NumField(); i++ {
 		fieldType := val.Type().Field(i)
 		name := fieldType.Tag.Get("mapstructure")
 		if name == "" {
-			stdlog.Printf("Config error: settings struct field " + fieldType.Name + " has no mapstructure tag")
-			hasErrors = true
-			continue
+			return errors.Errorf("settings struct field %s has no mapstructure tag", fieldType.Name)
 		}
+		known[name] = true
 
 		if err := viper.BindEnv(name); err != nil {
-			stdlog.Printf("config error: " + err.Error())
-			hasErrors = true
-			continue
+			return errors.Wrapf(err, "cannot bind %s", name)
 		}
 
 		if def := fieldType.Tag.Get("default"); def != "" {
 			viper.SetDefault(name, def)
 		}
+
+		if fieldType.Tag.Get("secret") == "true" {
+			known[name+fileSuffix] = true
+			if err := viper.BindEnv(name + fileSuffix); err != nil {
+				return errors.Wrapf(err, "cannot bind %s%s", name, fileSuffix)
+			}
+		}
 	}
 
-	if hasErrors {
-		log.Panic(context.Background(), "config error happened, check the log for details")
+	if configFile != "" {
+		if err := checkConfigFile(known); err != nil {
+			return err
+		}
+		viper.SetConfigFile(configFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return errors.Wrap(err, "cannot read config file")
+		}
 	}
 
-	if err := viper.Unmarshal(&cfg); err != nil {
-		log.Panic(context.Background(), "Unable to marshal config", "error", err)
+	if err := viper.Unmarshal(cfg); err != nil {
+		return errors.Wrap(err, "unable to unmarshal config")
+	}
+
+	if err := resolveSecrets(cfg); err != nil {
+		return errors.Wrap(err, "cannot load secrets")
 	}
 
 	validate := validator.New()
 	if err := validate.Struct(cfg); err != nil {
-		log.Panic(context.Background(), "invalid configuration", "error", err)
+		return errors.Wrap(err, "invalid settings")
+	}
+	return nil
+}
+
+// checkConfigFile rejects the settings of the config file the service does not know, a typo must not go unnoticed.
+func checkConfigFile(known map[string]bool) error {
+	file := viper.New()
+	file.SetConfigFile(configFile)
+	if err := file.ReadInConfig(); err != nil {
+		return errors.Wrap(err, "cannot read config file")
+	}
+
+	unknown := make([]string, 0)
+	for _, key := range file.AllKeys() {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.Errorf("unknown settings in %s: %s", configFile, strings.Join(unknown, ", "))
 	}
+	return nil
 }
@@ -0,0 +1,138 @@
+package initialization
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/config"
+)
+
+// testSettings has a setting for every source loadConfig reads from.
+type testSettings struct {
+	FromEnv     string `mapstructure:"test_from_env" default:"default"`
+	FromFile    int    `mapstructure:"test_from_file" default:"1"`
+	FromDefault string `mapstructure:"test_from_default" default:"default"`
+	Unset       string `mapstructure:"test_unset"`
+	Password    string `mapstructure:"test_password" secret:"true"`
+}
+
+// useConfigFile starts the test with a new viper and the config file, both are reset when the test ends.
+func useConfigFile(t *testing.T, content string) {
+	viper.Reset()
+	configFile = ""
+	t.Cleanup(func() {
+		viper.Reset()
+		configFile = ""
+	})
+
+	if content != "" {
+		configFile = writeFile(t, t.TempDir(), "config.yaml", content)
+	}
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	useConfigFile(t, "test_from_env: file\ntest_from_file: 2\n")
+	t.Setenv("TEST_FROM_ENV", "env")
+
+	cfg := &testSettings{}
+	if err := loadConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := testSettings{FromEnv: "env", FromFile: 2, FromDefault: "default"}
+	if *cfg != want {
+		t.Errorf("loaded %+v, want %+v", *cfg, want)
+	}
+}
+
+func TestLoadConfigSecretFile(t *testing.T) {
+	passwordFile := writeFile(t, t.TempDir(), "password", "hunter2\n")
+
+	t.Run("file", func(t *testing.T) {
+		useConfigFile(t, "test_password_file: "+passwordFile+"\n")
+
+		cfg := &testSettings{}
+		if err := loadConfig(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Password != "hunter2" {
+			t.Errorf("password = %q, want the content of the file without the line break", cfg.Password)
+		}
+	})
+
+	t.Run("both", func(t *testing.T) {
+		useConfigFile(t, "")
+		t.Setenv("TEST_PASSWORD", "hunter3")
+		t.Setenv("TEST_PASSWORD_FILE", passwordFile)
+
+		if err := loadConfig(&testSettings{}); err == nil || !strings.Contains(err.Error(), "both test_password and test_password_file") {
+			t.Errorf("got %v, want the error of the ambiguous secret", err)
+		}
+	})
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	useConfigFile(t, "")
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("SQLITE_DB_PATH", filepath.Join(t.TempDir(), "resources.db"))
+
+	cfg := &config.Config{}
+	if err := loadConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || cfg.TenantHeader != "X-Tenant-ID" || cfg.ShutdownTimeout.String() != "30s" {
+		t.Errorf("loaded %+v, want the defaults", *cfg)
+	}
+
+	t.Setenv("LOG_LEVEL", "verbose")
+	if err := loadConfig(&config.Config{}); err == nil || !strings.Contains(err.Error(), "invalid settings") {
+		t.Errorf("got %v, want the validation error", err)
+	}
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	known := map[string]bool{"test_from_env": true, "test_password": true, "test_password_file": true}
+
+	t.Run("known", func(t *testing.T) {
+		useConfigFile(t, "test_from_env: file\ntest_password_file: /run/secrets/password\n")
+		if err := checkConfigFile(known); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		useConfigFile(t, "test_from_env: file\ntest_form_file: 2\ntest_unknown: x\n")
+		err := checkConfigFile(known)
+		if err == nil || !strings.HasSuffix(err.Error(), ": test_form_file, test_unknown") {
+			t.Errorf("got %v, want the unknown settings listed", err)
+		}
+	})
+
+	t.Run("loaded", func(t *testing.T) {
+		useConfigFile(t, "test_from_file: 2\ntest_form_file: 3\n")
+		if err := loadConfig(&testSettings{}); err == nil || !strings.Contains(err.Error(), "test_form_file") {
+			t.Errorf("got %v, want the config file refused", err)
+		}
+	})
+
+	t.Run("unreadable", func(t *testing.T) {
+		useConfigFile(t, "")
+		configFile = filepath.Join(t.TempDir(), "missing.yaml")
+		if err := checkConfigFile(known); err == nil {
+			t.Error("a missing config file was accepted")
+		}
+	})
+}
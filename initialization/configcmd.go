@@ -0,0 +1,71 @@
+package initialization
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/config"
+)
+
+const redacted = "REDACTED"
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+// configValidateCmd checks the configuration without starting the service
+// and prints the effective settings in the config file format.
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration and print the effective settings with the secrets redacted",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := &config.Config{}
+		if err := loadConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := printConfig(os.Stdout, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot print the configuration: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// printConfig writes the settings as YAML in the order of config.Config, the secret ones are redacted.
+func printConfig(w io.Writer, cfg *config.Config) error {
+	settings := yaml.MapSlice{}
+	val := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		var value interface{} = val.Field(i).Interface()
+		switch v := value.(type) {
+		case time.Duration:
+			value = v.String()
+		case string:
+			if field.Tag.Get("secret") == "true" && v != "" {
+				value = redacted
+			}
+		}
+		settings = append(settings, yaml.MapItem{Key: field.Tag.Get("mapstructure"), Value: value})
+	}
+
+	out, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,50 @@
+package initialization
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/config"
+)
+
+func TestPrintConfig(t *testing.T) {
+	cfg := &config.Config{
+		LogLevel:        "info",
+		DBDialect:       "mysql",
+		MySQLDBPassword: "hunter2",
+		BlobS3SecretKey: "s3-secret",
+		ShutdownTimeout: 30 * time.Second,
+	}
+
+	out := &bytes.Buffer{}
+	if err := printConfig(out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "s3-secret") {
+		t.Fatalf("the secrets were printed:\n%s", out)
+	}
+	if !strings.HasPrefix(out.String(), "log_level: info\n") {
+		t.Errorf("the settings are not in the order of config.Config:\n%s", out)
+	}
+
+	settings := map[string]interface{}{}
+	if err := yaml.Unmarshal(out.Bytes(), &settings); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"mysql_db_password":    redacted,
+		"blob_s3_secret_key":   redacted,
+		"postgres_db_password": "",
+		"shutdown_timeout":     "30s",
+		"db_dialect":           "mysql",
+	}
+	for name, value := range want {
+		if settings[name] != value {
+			t.Errorf("%s = %v, want %v", name, settings[name], value)
+		}
+	}
+}
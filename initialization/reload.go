@@ -0,0 +1,146 @@
+package initialization
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+	"github.com/spf13/viper"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/config"
+	"github.com/artofimagination/mysql-resources-db-go-service/di"
+)
+
+// reloader reads the configuration again and applies the settings tagged reload to the running service.
+// The changes of the other settings are reported, they take effect after a restart.
+type reloader struct {
+	current   *config.Config
+	container *di.Container
+}
+
+func (r *reloader) reload() {
+	ctx := context.Background()
+
+	cfg := &config.Config{}
+	if err := loadConfig(cfg); err != nil {
+		log.Error(ctx, "Config reload failed, the current settings are kept", "error", err)
+		return
+	}
+	if err := r.container.Reload(cfg); err != nil {
+		log.Error(ctx, "Config reload failed, the current settings are kept", "error", err)
+		return
+	}
+	if err := setLogLevel(cfg.LogLevel); err != nil {
+		log.Error(ctx, "Config reload failed, the current settings are kept", "error", err)
+		return
+	}
+
+	current := reflect.ValueOf(r.current).Elem()
+	reloaded := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < current.NumField(); i++ {
+		if reflect.DeepEqual(current.Field(i).Interface(), reloaded.Field(i).Interface()) {
+			continue
+		}
+
+		field := current.Type().Field(i)
+		name := field.Tag.Get("mapstructure")
+		if field.Tag.Get("reload") == "true" {
+			current.Field(i).Set(reloaded.Field(i))
+			log.Info(ctx, "Setting reloaded", "setting", name)
+		} else {
+			log.Warn(ctx, "Setting changed, it takes effect after a restart", "setting", name)
+		}
+	}
+}
+
+// watchedFiles returns the files the settings are read from: the config file, the files of the secrets
+// and the ones named by the settings tagged watch, like the TLS certificates.
+func watchedFiles(cfg interface{}) []string {
+	files := make([]string, 0)
+	if configFile != "" {
+		files = append(files, configFile)
+	}
+
+	val := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		fieldType := val.Type().Field(i)
+		name := fieldType.Tag.Get("mapstructure")
+		if fieldType.Tag.Get("secret") == "true" {
+			if path := viper.GetString(name + fileSuffix); path != "" {
+				files = append(files, path)
+			}
+		}
+		if fieldType.Tag.Get("watch") == "true" && val.Field(i).String() != "" {
+			files = append(files, val.Field(i).String())
+		}
+	}
+	return files
+}
+
+// watchFiles signals changed whenever one of the files is written or replaced, like the config file or a rotated secret.
+// The directories are watched, as editors and Kubernetes replace the files instead of writing them.
+func watchFiles(paths []string, changed chan<- struct{}) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	realPaths := make(map[string]string)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if _, ok := realPaths[path]; ok {
+			continue
+		}
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			_ = watcher.Close()
+			return nil, errors.WithStack(err)
+		}
+		realPaths[path], _ = filepath.EvalSymlinks(path)
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filesChanged(realPaths, filepath.Clean(event.Name), event.Op) {
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warn(context.Background(), "File watch error", "error", err)
+			}
+		}
+	}()
+
+	return func() {
+		_ = watcher.Close()
+	}, nil
+}
+
+// filesChanged tells whether the event wrote one of the files or replaced the target of a symlink among them,
+// the way Kubernetes updates the mounted config maps and secrets.
+func filesChanged(realPaths map[string]string, name string, op fsnotify.Op) bool {
+	changed := false
+	for path, realPath := range realPaths {
+		currentPath, _ := filepath.EvalSymlinks(path)
+		if currentPath != realPath {
+			realPaths[path] = currentPath
+			changed = true
+		}
+		if name == path && op&(fsnotify.Write|fsnotify.Create) != 0 {
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,145 @@
+package initialization
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// mountedSecret lays out a secret the way Kubernetes mounts it: the file is a symlink through ..data,
+// which points to the directory of the current version and is swapped to update it.
+func mountedSecret(t *testing.T, dir string, content string) string {
+	t.Helper()
+	version := filepath.Join(dir, "..2021_07_01")
+	if err := os.Mkdir(version, 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, version, "password", content)
+	if err := os.Symlink(filepath.Base(version), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "password")
+	if err := os.Symlink(filepath.Join("..data", "password"), path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// updateMountedSecret swaps ..data to a new version, like the kubelet does. It returns the name of the replaced link.
+func updateMountedSecret(t *testing.T, dir string, content string) string {
+	t.Helper()
+	version := filepath.Join(dir, "..2021_07_02")
+	if err := os.Mkdir(version, 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, version, "password", content)
+	if err := os.Symlink(filepath.Base(version), filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	data := filepath.Join(dir, "..data")
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func realPathsOf(t *testing.T, paths ...string) map[string]string {
+	t.Helper()
+	realPaths := make(map[string]string)
+	for _, path := range paths {
+		realPath, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		realPaths[path] = realPath
+	}
+	return realPaths
+}
+
+func TestFilesChanged(t *testing.T) {
+	dir := t.TempDir()
+	configPath := writeFile(t, dir, "config.yaml", "log_level: info\n")
+	otherPath := writeFile(t, dir, "other.yaml", "")
+
+	tests := []struct {
+		name string
+		op   fsnotify.Op
+		want bool
+	}{
+		{configPath, fsnotify.Write, true},
+		{configPath, fsnotify.Create, true},
+		{configPath, fsnotify.Chmod, false},
+		{otherPath, fsnotify.Write, false},
+	}
+	for _, test := range tests {
+		if got := filesChanged(realPathsOf(t, configPath), test.name, test.op); got != test.want {
+			t.Errorf("filesChanged(%s, %s) = %v, want %v", filepath.Base(test.name), test.op, got, test.want)
+		}
+	}
+}
+
+func TestFilesChangedSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	path := mountedSecret(t, dir, "hunter2")
+	realPaths := realPathsOf(t, path)
+
+	// the event is about ..data, not about the watched file, only its resolved path tells the change
+	data := updateMountedSecret(t, dir, "hunter3")
+	if !filesChanged(realPaths, data, fsnotify.Create) {
+		t.Fatal("the swap of ..data was not detected")
+	}
+	if want, _ := filepath.EvalSymlinks(path); realPaths[path] != want {
+		t.Errorf("real path = %s, want the new version %s", realPaths[path], want)
+	}
+	if filesChanged(realPaths, data, fsnotify.Remove) {
+		t.Error("the swap was reported again")
+	}
+}
+
+func TestWatchFilesSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	path := mountedSecret(t, dir, "hunter2")
+
+	changed := make(chan struct{}, 1)
+	stop, err := watchFiles([]string{path}, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	updateMountedSecret(t, dir, "hunter3")
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the swap of the secret was not signalled")
+	}
+}
+
+func TestWatchedFiles(t *testing.T) {
+	useConfigFile(t, "test_from_env: file\n")
+	passwordFile := writeFile(t, t.TempDir(), "password", "hunter2")
+	t.Setenv("TEST_PASSWORD_FILE", passwordFile)
+	if err := loadConfig(&testSettings{}); err != nil {
+		t.Fatal(err)
+	}
+
+	type watchedSettings struct {
+		Password string `mapstructure:"test_password" secret:"true"`
+		CertFile string `mapstructure:"test_cert_file" watch:"true"`
+		KeyFile  string `mapstructure:"test_key_file" watch:"true"`
+	}
+	files := watchedFiles(&watchedSettings{CertFile: "/etc/tls/tls.crt"})
+
+	want := []string{configFile, passwordFile, "/etc/tls/tls.crt"}
+	if len(files) != len(want) {
+		t.Fatalf("watched %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("watched %v, want %v", files, want)
+		}
+	}
+}
@@ -2,32 +2,38 @@ package initialization
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/proemergotech/log/v3"
 	"github.com/spf13/cobra"
 
 	"github.com/artofimagination/mysql-resources-db-go-service/config"
+	"github.com/artofimagination/mysql-resources-db-go-service/db/migrations"
 	"github.com/artofimagination/mysql-resources-db-go-service/di"
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use: config.AppName,
+	Use:     config.AppName,
+	Version: version(),
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg := &config.Config{}
-		initConfig(cfg)
+		cfg := newConfig()
 
 		container, err := di.NewContainer(cfg)
 		if err != nil {
 			log.Panic(context.Background(), "Couldn't load container", "error", err)
 		}
-		defer container.Close()
 
 		runner := newRunner()
-		defer runner.stop()
+		workerPolicy := restartPolicy{
+			MaxRestarts: cfg.WorkerRestartAttempts,
+			Backoff:     cfg.WorkerRestartBackoff,
+			StopTimeout: cfg.ShutdownTimeout,
+		}
 
 		//
 		//_, err := restcontrollers.NewRESTController()
@@ -38,20 +44,81 @@ var rootCmd = &cobra.Command{
 		//// Start HTTP server that accepts requests from the offer process to exchange SDP and Candidates
 		//panic(http.ListenAndServe(":8080", nil))
 		runner.start("rest server", container.RestServer.Start, container.RestServer.Stop)
+		if container.GRPCServer != nil {
+			runner.start("grpc server", container.GRPCServer.Start, container.GRPCServer.Stop)
+		}
+		if container.AuditRetention != nil {
+			runner.startRestartable("audit retention", container.AuditRetention.Start, container.AuditRetention.Stop, workerPolicy)
+		}
+		runner.startRestartable("change log sequencer", container.ChangeLog.Start, container.ChangeLog.Stop, workerPolicy)
+		runner.startRestartable("resource scheduler", container.Scheduler.Start, container.Scheduler.Stop, workerPolicy)
+		if container.Verifier != nil {
+			runner.startRestartable("resource verifier", container.Verifier.Start, container.Verifier.Stop, workerPolicy)
+		}
+		runner.startRestartable("outbox relay", container.OutboxRelay.Start, container.OutboxRelay.Stop, workerPolicy)
+		runner.startRestartable("webhook delivery", container.WebhookWorker.Start, container.WebhookWorker.Stop, workerPolicy)
+
+		reloader := &reloader{current: cfg, container: container}
+		changed := make(chan struct{}, 1)
+		if watched := watchedFiles(cfg); len(watched) > 0 {
+			stopWatch, err := watchFiles(watched, changed)
+			if err != nil {
+				log.Panic(context.Background(), "Couldn't watch the config and secret files", "error", err)
+			}
+			defer stopWatch()
+		}
 
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
-		select {
-		case <-sigs:
-		case err := <-runner.errors():
-			log.Panic(context.Background(), err.Error(), "error", err)
+		for {
+			select {
+			case sig := <-sigs:
+				if sig != syscall.SIGHUP {
+					shutdown(cfg, container, runner, sigs)
+					return
+				}
+				reloader.reload()
+			case <-changed:
+				reloader.reload()
+			case err := <-runner.errors():
+				log.Error(context.Background(), err.Error(), "error", err)
+				shutdown(cfg, container, runner, sigs)
+				log.Panic(context.Background(), err.Error(), "error", err)
+			}
 		}
 	},
 }
 
+// version is the version of the service with the schema versions of the migrations built into it, printed by --version.
+func version() string {
+	appVersion := config.AppVersion
+	if appVersion == "" {
+		appVersion = "dev"
+	}
+	return fmt.Sprintf("%s (schema mysql %s, postgres %s, sqlite %s)", appVersion,
+		migrations.Version(migrations.MySQL()), migrations.Version(migrations.PostgreSQL()), migrations.Version(migrations.SQLite()))
+}
+
+// shutdown stops the service gracefully. The readiness fails first for the drain delay, so no new requests are routed to it,
+// then the components are stopped, finishing the requests in flight, and the database is closed last.
+// A second signal cuts the drain delay short.
+func shutdown(cfg *config.Config, container *di.Container, runner *runner, sigs <-chan os.Signal) {
+	container.Drain()
+	log.Info(context.Background(), "Draining before shutdown", "delay", cfg.ShutdownDrainDelay.String())
+	select {
+	case <-time.After(cfg.ShutdownDrainDelay):
+	case <-sigs:
+	}
+
+	runner.stop(cfg.ShutdownTimeout)
+	container.Close()
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
+// setLevel changes the level of the global logger, the log_level setting is applied with it.
+func Execute(setLevel func(level string) error) {
+	setLogLevel = setLevel
 	if err := rootCmd.Execute(); err != nil {
 		log.Panic(context.Background(), err.Error(), "error", err)
 	}
@@ -3,69 +3,190 @@ package initialization
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/proemergotech/log/v3"
 )
 
-type startFn func(chan<- error)
+// restartResetAfter is how long a restarted component has to run to be considered healthy again,
+// its next failure starts the count of the restarts over.
+const restartResetAfter = 10 * time.Minute
+
+// maxRestartBackoff caps the doubling wait before the restarts.
+const maxRestartBackoff = time.Minute
+
+type startFn func(context.Context, chan<- error)
 type stopFn func(time.Duration) error
 
+// restartPolicy restarts a failed component instead of stopping the service.
+type restartPolicy struct {
+	// MaxRestarts is how many times the component is restarted in a row before its failure stops the service.
+	MaxRestarts int
+	// Backoff is the wait before the first restart, it doubles for every further one.
+	Backoff time.Duration
+	// StopTimeout bounds the stop of the failed component before it is started again.
+	StopTimeout time.Duration
+}
+
+// runner starts the components and supervises them until it is stopped.
+// The failure of a component without a restart policy is fatal, it is reported by errors.
 type runner struct {
-	stoppers []stopper
-	errorChs map[string]chan error
+	done       chan struct{}
+	stopOnce   sync.Once
+	components []*component
+	fatal      chan error
+	wg         sync.WaitGroup
+
+	// resetAfter and maxBackoff are restartResetAfter and maxRestartBackoff, the tests shorten them.
+	resetAfter time.Duration
+	maxBackoff time.Duration
 }
 
-type stopper struct {
-	name string
-	stop stopFn
+// component is a started component. Its cancel and running are owned by its supervisor until the supervision ends,
+// running is false while the component waits for its restart, so it is not stopped twice.
+type component struct {
+	name    string
+	start   startFn
+	stop    stopFn
+	policy  *restartPolicy
+	cancel  context.CancelFunc
+	running bool
 }
 
 func newRunner() *runner {
 	return &runner{
-		stoppers: make([]stopper, 0),
-		errorChs: make(map[string]chan error),
+		done:       make(chan struct{}),
+		components: make([]*component, 0),
+		fatal:      make(chan error, 1),
+		resetAfter: restartResetAfter,
+		maxBackoff: maxRestartBackoff,
 	}
 }
 
+// start starts a component whose failure stops the service.
 func (r *runner) start(name string, start startFn, stop stopFn) {
-	errorCh := make(chan error)
-	r.errorChs[name] = errorCh
+	r.startComponent(&component{name: name, start: start, stop: stop})
+}
+
+// startRestartable starts a component that is restarted when it fails, as long as the policy allows.
+func (r *runner) startRestartable(name string, start startFn, stop stopFn, policy restartPolicy) {
+	r.startComponent(&component{name: name, start: start, stop: stop, policy: &policy})
+}
 
-	r.stoppers = append(r.stoppers, stopper{
-		name: name,
-		stop: stop,
-	})
+func (r *runner) startComponent(c *component) {
+	r.components = append(r.components, c)
 
-	start(errorCh)
+	errorCh := r.run(c)
 	fmt.Println("-------------------------------------------------------------")
-	log.Info(context.Background(), name+" started")
+	log.Info(context.Background(), c.name+" started")
 	fmt.Println("-------------------------------------------------------------")
+
+	r.wg.Add(1)
+	go r.supervise(c, errorCh)
+}
+
+// run starts the component with its own context, it is cancelled only when the component is stopped.
+func (r *runner) run(c *component) chan error {
+	ctx, cancel := context.WithCancel(context.Background())
+	errorCh := make(chan error, 1)
+	c.cancel = cancel
+	c.running = true
+	c.start(ctx, errorCh)
+	return errorCh
+}
+
+// supervise waits for the failure of the component and restarts it according to its policy.
+func (r *runner) supervise(c *component, errorCh chan error) {
+	defer r.wg.Done()
+
+	restarts := 0
+	started := time.Now()
+	for {
+		var err error
+		select {
+		case <-r.done:
+			return
+		case err = <-errorCh:
+		}
+		err = errors.Wrap(err, c.name+" failed")
+
+		if c.policy == nil || c.policy.MaxRestarts == 0 {
+			r.fail(errors.Wrap(err, "fatal error"))
+			return
+		}
+		if time.Since(started) > r.resetAfter {
+			restarts = 0
+		}
+		if restarts >= c.policy.MaxRestarts {
+			r.fail(errors.Wrapf(err, "gave up after %d restarts", restarts))
+			return
+		}
+
+		backoff := c.policy.Backoff << uint(restarts)
+		if backoff > r.maxBackoff || backoff <= 0 {
+			backoff = r.maxBackoff
+		}
+		restarts++
+		log.Error(context.Background(), err.Error(), "error", err, "restart", restarts, "backoff", backoff.String())
+
+		c.cancel()
+		if stopErr := c.stop(c.policy.StopTimeout); stopErr != nil {
+			stopErr = errors.Wrap(stopErr, c.name+" stop before restart failed")
+			log.Warn(context.Background(), stopErr.Error(), "error", stopErr)
+		}
+		c.running = false
+
+		select {
+		case <-r.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		errorCh = r.run(c)
+		started = time.Now()
+		log.Info(context.Background(), c.name+" restarted")
+	}
+}
+
+func (r *runner) fail(err error) {
+	select {
+	case r.fatal <- err:
+	default:
+	}
 }
 
-func (r *runner) stop() {
-	for i := len(r.stoppers) - 1; i >= 0; i-- {
-		stopper := r.stoppers[i]
+// stop ends the supervision and stops the components in the reverse order of their start.
+// The context of a component is cancelled right before it is stopped, so the ones started earlier keep running
+// until the later ones, depending on them, are stopped. The components waiting for their restart are already stopped.
+// They share the timeout, the ones left when it is over are given no time to stop gracefully.
+func (r *runner) stop(timeout time.Duration) {
+	r.stopOnce.Do(func() { close(r.done) })
+	r.wg.Wait()
 
-		if err := stopper.stop(5 * time.Second); err != nil {
-			err = errors.Wrap(err, stopper.name+" graceful shutdown failed")
+	deadline := time.Now().Add(timeout)
+	for i := len(r.components) - 1; i >= 0; i-- {
+		c := r.components[i]
+		if !c.running {
+			continue
+		}
+
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.cancel()
+		if err := c.stop(remaining); err != nil {
+			err = errors.Wrap(err, c.name+" graceful shutdown failed")
 			log.Error(context.Background(), err.Error(), "error", err)
 		}
-		log.Info(context.Background(), stopper.name+" shutdown complete")
+		c.running = false
+		log.Info(context.Background(), c.name+" shutdown complete")
 	}
 }
 
+// errors returns the fatal failures of the components.
 func (r *runner) errors() <-chan error {
-	errCollector := make(chan error, len(r.errorChs))
-	for name, errorCh := range r.errorChs {
-		name := name
-		errorCh := errorCh
-		go func() {
-			if err := <-errorCh; err != nil {
-				errCollector <- errors.Wrap(err, name+" fatal error")
-			}
-		}()
-	}
-	return errCollector
+	return r.fatal
 }
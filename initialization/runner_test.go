@@ -0,0 +1,271 @@
+package initialization
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+	"github.com/proemergotech/log/v3/zaplog"
+	"go.uber.org/zap"
+)
+
+type emptyContextMapper struct{}
+
+func (emptyContextMapper) Values(context.Context) map[string]string {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	log.SetGlobalLogger(zaplog.NewLogger(zap.NewNop(), emptyContextMapper{}))
+	os.Exit(m.Run())
+}
+
+// fakeComponent records its starts and stops, the calls of every component of a test go to the shared journal.
+type fakeComponent struct {
+	name    string
+	journal *journal
+
+	mu      sync.Mutex
+	ctx     context.Context
+	errorCh chan<- error
+	starts  int
+	stops   int
+	started chan struct{}
+	stopped chan struct{}
+}
+
+type journal struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (j *journal) add(call string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.calls = append(j.calls, call)
+}
+
+func (j *journal) get() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]string(nil), j.calls...)
+}
+
+func newFakeComponent(name string, j *journal) *fakeComponent {
+	return &fakeComponent{
+		name:    name,
+		journal: j,
+		started: make(chan struct{}, 10),
+		stopped: make(chan struct{}, 10),
+	}
+}
+
+func (f *fakeComponent) Start(ctx context.Context, errorCh chan<- error) {
+	f.mu.Lock()
+	f.ctx = ctx
+	f.errorCh = errorCh
+	f.starts++
+	f.mu.Unlock()
+	f.journal.add("start " + f.name)
+	f.started <- struct{}{}
+}
+
+func (f *fakeComponent) Stop(time.Duration) error {
+	f.mu.Lock()
+	f.stops++
+	f.mu.Unlock()
+	f.journal.add("stop " + f.name)
+	f.stopped <- struct{}{}
+	return nil
+}
+
+func (f *fakeComponent) fail() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errorCh <- errors.New(f.name + " broke")
+}
+
+func (f *fakeComponent) cancelled() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ctx.Err() != nil
+}
+
+func (f *fakeComponent) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.starts, f.stops
+}
+
+func wait(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func expectNoFatal(t *testing.T, r *runner) {
+	t.Helper()
+	select {
+	case err := <-r.errors():
+		t.Fatalf("unexpected fatal error: %v", err)
+	default:
+	}
+}
+
+func TestRunnerRestartsFailedComponent(t *testing.T) {
+	r := newRunner()
+	c := newFakeComponent("worker", &journal{})
+	r.startRestartable(c.name, c.Start, c.Stop, restartPolicy{MaxRestarts: 3, Backoff: time.Millisecond})
+	wait(t, c.started, "start")
+
+	c.mu.Lock()
+	firstCtx := c.ctx
+	c.mu.Unlock()
+	c.fail()
+	wait(t, c.stopped, "stop before restart")
+	wait(t, c.started, "restart")
+
+	if firstCtx.Err() == nil {
+		t.Error("the context of the failed run is not cancelled")
+	}
+	if c.cancelled() {
+		t.Error("the context of the restarted run is cancelled")
+	}
+	expectNoFatal(t, r)
+
+	r.stop(time.Second)
+	if starts, stops := c.counts(); starts != 2 || stops != 2 {
+		t.Errorf("starts, stops = %d, %d, want 2, 2", starts, stops)
+	}
+}
+
+func TestRunnerGivesUpAfterMaxRestarts(t *testing.T) {
+	r := newRunner()
+	c := newFakeComponent("worker", &journal{})
+	r.startRestartable(c.name, c.Start, c.Stop, restartPolicy{MaxRestarts: 1, Backoff: time.Millisecond})
+	wait(t, c.started, "start")
+
+	c.fail()
+	wait(t, c.started, "restart")
+	c.fail()
+
+	select {
+	case err := <-r.errors():
+		if err == nil {
+			t.Fatal("nil fatal error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the runner did not give up")
+	}
+	r.stop(time.Second)
+}
+
+func TestRunnerFailureWithoutPolicyIsFatal(t *testing.T) {
+	r := newRunner()
+	c := newFakeComponent("server", &journal{})
+	r.start(c.name, c.Start, c.Stop)
+	wait(t, c.started, "start")
+
+	c.fail()
+	select {
+	case <-r.errors():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the failure is not fatal")
+	}
+
+	r.stop(time.Second)
+	if starts, stops := c.counts(); starts != 1 || stops != 1 {
+		t.Errorf("starts, stops = %d, %d, want 1, 1", starts, stops)
+	}
+}
+
+func TestRunnerResetsRestartsAfterHealthyRun(t *testing.T) {
+	r := newRunner()
+	r.resetAfter = 20 * time.Millisecond
+	c := newFakeComponent("worker", &journal{})
+	r.startRestartable(c.name, c.Start, c.Stop, restartPolicy{MaxRestarts: 1, Backoff: time.Millisecond})
+	wait(t, c.started, "start")
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * r.resetAfter)
+		c.fail()
+		wait(t, c.started, "restart")
+		expectNoFatal(t, r)
+	}
+	r.stop(time.Second)
+}
+
+func TestRunnerBackoffDoublesUpToTheMax(t *testing.T) {
+	r := newRunner()
+	r.maxBackoff = 80 * time.Millisecond
+	c := newFakeComponent("worker", &journal{})
+	r.startRestartable(c.name, c.Start, c.Stop, restartPolicy{MaxRestarts: 10, Backoff: 20 * time.Millisecond})
+	wait(t, c.started, "start")
+
+	// The waits are 20ms, 40ms, 80ms and 80ms again, capped.
+	for _, want := range []time.Duration{20, 40, 80, 80} {
+		want *= time.Millisecond
+		failed := time.Now()
+		c.fail()
+		wait(t, c.started, "restart")
+		if took := time.Since(failed); took < want || took > want+time.Second {
+			t.Errorf("restarted after %s, want %s", took, want)
+		}
+	}
+	r.stop(time.Second)
+}
+
+func TestRunnerStopsInReverseOrder(t *testing.T) {
+	j := &journal{}
+	r := newRunner()
+	first := newFakeComponent("first", j)
+	second := newFakeComponent("second", j)
+	r.start(first.name, first.Start, first.Stop)
+	r.startRestartable(second.name, second.Start, func(timeout time.Duration) error {
+		if !second.cancelled() {
+			t.Error("second is stopped before its context is cancelled")
+		}
+		if first.cancelled() {
+			t.Error("first is cancelled before second is stopped")
+		}
+		return second.Stop(timeout)
+	}, restartPolicy{MaxRestarts: 1, Backoff: time.Millisecond})
+
+	r.stop(time.Second)
+
+	want := []string{"start first", "start second", "stop second", "stop first"}
+	got := j.get()
+	if len(got) != len(want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", got, want)
+		}
+	}
+	if !first.cancelled() {
+		t.Error("the context of first is not cancelled")
+	}
+}
+
+func TestRunnerDoesNotStopComponentWaitingForRestart(t *testing.T) {
+	r := newRunner()
+	c := newFakeComponent("worker", &journal{})
+	r.startRestartable(c.name, c.Start, c.Stop, restartPolicy{MaxRestarts: 1, Backoff: time.Hour})
+	wait(t, c.started, "start")
+
+	c.fail()
+	wait(t, c.stopped, "stop before restart")
+
+	r.stop(time.Second)
+	if starts, stops := c.counts(); starts != 1 || stops != 1 {
+		t.Errorf("starts, stops = %d, %d, want 1, 1", starts, stops)
+	}
+}
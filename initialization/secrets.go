@@ -0,0 +1,75 @@
+package initialization
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/secret"
+)
+
+// fileSuffix names the variant of a secret setting holding the path of the file the secret is read from.
+const fileSuffix = "_file"
+
+// resolveSecrets fills the secret settings of cfg, that are not set, from the file of their _file variant or from the secret provider.
+// Setting both a secret and its _file variant is an error, it is ambiguous which one is meant.
+func resolveSecrets(cfg interface{}) error {
+	provider, err := newSecretProvider()
+	if err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		fieldType := val.Type().Field(i)
+		if fieldType.Tag.Get("secret") != "true" {
+			continue
+		}
+
+		name := fieldType.Tag.Get("mapstructure")
+		field := val.Field(i)
+		path := viper.GetString(name + fileSuffix)
+		switch {
+		case path != "" && field.String() != "":
+			return errors.Errorf("both %s and %s%s are set", name, name, fileSuffix)
+		case path != "":
+			value, err := secret.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "cannot read %s%s", name, fileSuffix)
+			}
+			field.SetString(value)
+		case field.String() == "" && provider != nil:
+			value, err := provider.Get(context.Background(), name)
+			if errors.Cause(err) == secret.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return errors.Wrapf(err, "cannot get %s from the secret provider", name)
+			}
+			field.SetString(value)
+		}
+	}
+	return nil
+}
+
+// newSecretProvider returns the configured secret provider, nil if there is none.
+func newSecretProvider() (secret.Provider, error) {
+	switch viper.GetString("secrets_provider") {
+	case "encrypted_file":
+		if viper.GetString("secrets_file") == "" || viper.GetString("secrets_key_file") == "" {
+			return nil, errors.New("the encrypted_file secret provider needs secrets_file and secrets_key_file")
+		}
+		key, err := secret.ReadKeyFile(viper.GetString("secrets_key_file"))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read the secrets key")
+		}
+		provider, err := secret.OpenEncryptedFile(viper.GetString("secrets_file"), key)
+		if err != nil {
+			return nil, err
+		}
+		return provider, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,77 @@
+package initialization
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/secret"
+)
+
+var secretsKeyFile string
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the files of the encrypted_file secret provider",
+}
+
+var secretsGenerateKeyCmd = &cobra.Command{
+	Use:   "generate-key",
+	Short: "Print a new base64 encoded key for the secrets_key_file",
+	Run: func(cmd *cobra.Command, args []string) {
+		key := make([]byte, secret.KeySize)
+		if _, err := rand.Read(key); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot generate key: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+	},
+}
+
+// secretsEncryptCmd reads the secrets by setting name as a JSON object, like {"mysql_db_password": "..."},
+// and prints the content of the secrets_file.
+var secretsEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the JSON object of the secrets read from stdin into the secrets_file printed to stdout",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := encryptSecrets(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot encrypt secrets: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	secretsEncryptCmd.Flags().StringVar(&secretsKeyFile, "key-file", "", "file of the base64 encoded key")
+	_ = secretsEncryptCmd.MarkFlagRequired("key-file")
+	secretsCmd.AddCommand(secretsGenerateKeyCmd, secretsEncryptCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+func encryptSecrets() error {
+	key, err := secret.ReadKeyFile(secretsKeyFile)
+	if err != nil {
+		return err
+	}
+
+	plain, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return err
+	}
+
+	sealed, err := secret.Seal(secrets, key)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(sealed)
+	return err
+}
@@ -0,0 +1,60 @@
+package initialization
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/proemergotech/log/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/di"
+)
+
+// tenantsQuota is the resource quota of the added tenants, negative keeps them on the tenant_resource_quota.
+var tenantsQuota int
+
+var tenantsCmd = &cobra.Command{
+	Use:   "tenants",
+	Short: "Manage the tenants, the requests of a tenant are refused until it is added",
+}
+
+var tenantsAddCmd = &cobra.Command{
+	Use:   "add <tenant id>...",
+	Short: "Provision the tenants with the system categories",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := newConfig()
+
+		container, err := di.NewContainer(cfg)
+		if err != nil {
+			log.Panic(context.Background(), "Couldn't load container", "error", err)
+		}
+		defer container.Close()
+
+		var quota *int
+		if tenantsQuota >= 0 {
+			quota = &tenantsQuota
+		}
+
+		failed := false
+		for _, tenantID := range args {
+			if err := container.Service.AddTenant(context.Background(), tenantID, quota); err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot add tenant %s: %v\n", tenantID, err)
+				failed = true
+				continue
+			}
+			fmt.Println(tenantID)
+		}
+		if failed {
+			container.Close()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	tenantsAddCmd.Flags().IntVar(&tenantsQuota, "quota", -1, "resource quota of the tenants, 0 is unlimited, the default is tenant_resource_quota")
+	tenantsCmd.AddCommand(tenantsAddCmd)
+	rootCmd.AddCommand(tenantsCmd)
+}
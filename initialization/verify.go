@@ -0,0 +1,70 @@
+package initialization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/proemergotech/log/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/di"
+)
+
+// verifyCmd checks the files of every resource once, prints the broken ones as JSON lines
+// and exits with a failure if there was any.
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the files of all resources and report the missing or changed ones",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := newConfig()
+
+		container, err := di.NewContainer(cfg)
+		if err != nil {
+			log.Panic(context.Background(), "Couldn't load container", "error", err)
+		}
+		defer container.Close()
+
+		ctx := context.Background()
+		start := time.Now()
+		encoder := json.NewEncoder(os.Stdout)
+		verified, broken, failed := 0, 0, 0
+		for {
+			reports, err := container.Service.VerifyResources(ctx, start, cfg.VerifyBatchSize)
+			if err != nil {
+				log.Panic(ctx, "Verification failed", "error", err)
+			}
+
+			for _, report := range reports {
+				switch {
+				case report.Error != "":
+					failed++
+				case report.Integrity.Broken():
+					broken++
+				default:
+					continue
+				}
+				if err := encoder.Encode(report); err != nil {
+					log.Panic(ctx, "Cannot write report", "error", err)
+				}
+			}
+			verified += len(reports)
+
+			if len(reports) == 0 {
+				break
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "%d resources verified, %d broken, %d could not be verified\n", verified, broken, failed)
+		if broken > 0 || failed > 0 {
+			container.Close()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -12,16 +12,35 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
 	"github.com/artofimagination/mysql-resources-db-go-service/config"
 	"github.com/artofimagination/mysql-resources-db-go-service/initialization"
+	"github.com/artofimagination/mysql-resources-db-go-service/requestinfo"
 )
 
+// contextMapper adds the request and the caller a context belongs to to every entry logged with it,
+// so the entries of a request can be correlated. The contexts of the background workers have none of them.
 type contextMapper struct{}
 
-func (cl contextMapper) Values(_ context.Context) map[string]string {
-	// nil mapper
-	// todo: could add fields to context and log them when necessary just by extract and adding them here to the logger
-	return map[string]string{}
+func (cl contextMapper) Values(ctx context.Context) map[string]string {
+	values := make(map[string]string)
+
+	info := requestinfo.FromContext(ctx)
+	if info.RequestID != "" {
+		values["request_id"] = info.RequestID
+	}
+	if info.Route != "" {
+		values["route"] = info.Route
+	}
+
+	if identity, ok := auth.IdentityFromContext(ctx); ok {
+		values["tenant_id"] = identity.TenantID
+		if identity.OwnerID != "" {
+			values["actor"] = identity.OwnerID
+		}
+	}
+
+	return values
 }
 
 func ContextMapper() log.ContextMapper {
@@ -72,5 +91,7 @@ func main() {
 		}
 	}()
 
-	initialization.Execute()
+	initialization.Execute(func(level string) error {
+		return zapConf.Level.UnmarshalText([]byte(level))
+	})
 }
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditOperationAdd    = "add"
+	AuditOperationUpdate = "update"
+	AuditOperationDelete = "delete"
+)
+
+// AuditEvent records a single mutation of a resource.
+type AuditEvent struct {
+	ID         int64     `json:"id"`
+	Actor      string    `json:"actor"`
+	RequestID  string    `json:"request_id"`
+	ClientIP   string    `json:"client_ip"`
+	Operation  string    `json:"operation"`
+	ResourceID uuid.UUID `json:"resource_id"`
+	Diff       AuditDiff `json:"diff"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuditDiff holds the state of the resource before and after the mutation.
+// Before is nil for additions and After is nil for deletions.
+type AuditDiff struct {
+	Before *Resource `json:"before"`
+	After  *Resource `json:"after"`
+}
+
+func (ad *AuditDiff) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []uint8:
+		return json.Unmarshal(s, ad)
+	case string:
+		return json.Unmarshal([]byte(s), ad)
+	case nil:
+		return nil
+	default:
+		return errors.New("incompatible type for AuditDiff")
+	}
+}
+
+func (ad AuditDiff) Value() (driver.Value, error) {
+	j, err := json.Marshal(ad)
+	if err != nil {
+		return nil, err
+	}
+	return driver.Value(j), nil
+}
+
+// AuditFilter narrows down the audit events returned by a query.
+type AuditFilter struct {
+	ResourceID *uuid.UUID
+	Actor      string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+}
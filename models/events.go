@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AggregateResource = "resource"
+	AggregateCategory = "category"
+)
+
+const (
+	EventResourceCreated = "resource.created"
+	EventResourceUpdated = "resource.updated"
+	EventResourceDeleted = "resource.deleted"
+	// EventResourcePublished and EventResourceExpired are emitted at the scheduled moments of the resources.
+	EventResourcePublished = "resource.published"
+	EventResourceExpired   = "resource.expired"
+	EventCategoryCreated   = "category.created"
+)
+
+// ChangeEvent announces a committed change of a resource or a category to downstream consumers.
+// Payload holds the state after the change, or the last known state for deletions.
+type ChangeEvent struct {
+	ID            int64           `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+
+	// Position is the place of the event in the change log, it follows the order the events were committed in.
+	Position int64 `json:"-"`
+	// Attempts is filled in for the outbox relay only, it counts the failed deliveries of the event.
+	Attempts int `json:"-"`
+}
@@ -1,13 +1,16 @@
 package http
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/artofimagination/mysql-resources-db-go-service/models"
 )
 
+// AddResourceRequest takes the id of the resource from its path, binding already rejects the malformed ids.
 type AddResourceRequest struct {
-	UUID     uuid.UUID        `param:"resource_id" validate:"required,uuid"`
+	UUID     uuid.UUID        `param:"resource_id" validate:"required"`
 	Resource *models.Resource `json:"resource"`
 }
 
@@ -16,16 +19,48 @@ type GetResourceByIDWithQueryRequest struct {
 	UUID uuid.UUID `query:"id" validate:"required"`
 }
 
+// GetResourceByIDRequest selects a resource by its path, binding already rejects the malformed ids.
 type GetResourceByIDRequest struct {
-	UUID uuid.UUID `json:"resource_id" param:"resource_id" validate:"required,uuid"`
+	UUID uuid.UUID `json:"resource_id" param:"resource_id" validate:"required"`
+}
+
+// ResourceBlobRequest selects the resource of a blob upload or download, the blob itself is not bound.
+type ResourceBlobRequest struct {
+	UUID uuid.UUID `param:"resource_id" validate:"required"`
+}
+
+// ResourceListRequest orders and narrows down the resource listings.
+type ResourceListRequest struct {
+	// Sort is created_at or updated_at, prefixed with - for descending order. The default is -created_at.
+	Sort string `query:"sort" validate:"omitempty,oneof=created_at -created_at updated_at -updated_at"`
+	// Since and Until filter on the timestamp selected by Sort.
+	Since *time.Time `query:"since"`
+	Until *time.Time `query:"until"`
+	// IncludeHidden also lists the resources not published yet or already expired.
+	IncludeHidden bool `query:"include_hidden"`
 }
 
 type GetResourcesByCategoryRequest struct {
 	Category int `query:"category" param:"category" validate:"required"`
+	ResourceListRequest
 }
 
+// MaxResourceIDs is the most IDs a GetResourcesByIDsRequest may list, the service refuses the longer lists.
+const MaxResourceIDs = 100
+
 type GetResourcesByIDsRequest struct {
 	UUIDs []uuid.UUID `query:"ids" validate:"required"`
+	ResourceListRequest
+}
+
+// LookupResourcesRequest looks up resources by the IDs sent in the body, so they are not limited by the length of the URL.
+// A lookup returns at most MaxResourceIDs resources, the attachments included.
+type LookupResourcesRequest struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1"`
+	// IncludeAttachments also returns the attachment resources listed in the content of the resources found.
+	IncludeAttachments bool `json:"include_attachments"`
+	// IncludeHidden also returns the resources not published yet or already expired, they are reported missing otherwise.
+	IncludeHidden bool `json:"include_hidden"`
 }
 
 type DeleteResourceRequest struct {
@@ -33,3 +68,46 @@ type DeleteResourceRequest struct {
 	Category int               `json:"category"`
 	Content  models.ContentMap `json:"content"`
 }
+
+type GetAuditEventsRequest struct {
+	ResourceID *uuid.UUID `query:"resource_id"`
+	Actor      string     `query:"actor"`
+	Since      *time.Time `query:"since"`
+	Until      *time.Time `query:"until"`
+	Limit      int        `query:"limit" validate:"omitempty,min=1,max=1000"`
+	Offset     int        `query:"offset" validate:"min=0"`
+}
+
+type StreamResourcesRequest struct {
+	Category    int   `query:"category"`
+	LastEventID int64 `query:"last_event_id" validate:"min=0"`
+	// IncludeHidden also streams the changes of the resources not published yet or already expired.
+	IncludeHidden bool `query:"include_hidden"`
+}
+
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,url,max=2048"`
+	Categories []int    `json:"categories"`
+	EventTypes []string `json:"event_types" validate:"dive,oneof=resource.created resource.updated resource.deleted resource.published resource.expired category.created"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=256"`
+	Active     *bool    `json:"active"`
+}
+
+// UpdateWebhookSubscriptionRequest takes the id of the subscription from the path only, an "id" of the body is ignored.
+type UpdateWebhookSubscriptionRequest struct {
+	ID uuid.UUID `json:"-" param:"subscription_id" validate:"required"`
+	WebhookSubscriptionRequest
+}
+
+type WebhookSubscriptionByIDRequest struct {
+	ID uuid.UUID `param:"subscription_id" validate:"required"`
+}
+
+type GetWebhookDeadLettersRequest struct {
+	SubscriptionID *uuid.UUID `query:"subscription_id"`
+	Limit          int        `query:"limit" validate:"omitempty,min=1,max=1000"`
+}
+
+type ReplayWebhookDeliveryRequest struct {
+	ID int64 `param:"delivery_id" validate:"required"`
+}
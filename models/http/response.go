@@ -1,6 +1,10 @@
 package http
 
-import "github.com/artofimagination/mysql-resources-db-go-service/models"
+import (
+	"github.com/google/uuid"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
 
 type CategoriesResponse struct {
 	Categories []models.Category `json:"categories"`
@@ -10,7 +14,18 @@ type ResourcesResponse struct {
 	Resources []models.Resource `json:"resources"`
 }
 
+// LookupResourcesResponse holds the resources of a lookup in the order of the requested IDs, each of them once.
+type LookupResourcesResponse struct {
+	Resources []models.Resource `json:"resources"`
+	// Missing lists the requested IDs no resource was found with.
+	Missing []uuid.UUID `json:"missing"`
+	// Attachments holds the attachment resources of the resources found by ID, if they were requested.
+	Attachments map[string]models.Resource `json:"attachments,omitempty"`
+}
+
 type ResponseData struct {
 	Error string      `json:"error" validation:"required"`
 	Data  interface{} `json:"data" validation:"required"`
+	// RequestID is sent with the errors, so they can be found in the logs.
+	RequestID string `json:"request_id,omitempty"`
 }
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,8 +15,29 @@ var CategoryContent = "Content"
 
 type Resource struct {
 	ID       uuid.UUID  `json:"id" validate:"required"`
+	OwnerID  string     `json:"owner_id,omitempty"`
 	Category int        `json:"category" validate:"required"`
 	Content  ContentMap `json:"content" validate:"required"`
+	// CreatedAt and UpdatedAt are maintained by the storage, the values sent by the clients are ignored.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// PublishAt and ExpireAt schedule the visibility of the resource, the listings hide it before and after them.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpireAt  *time.Time `json:"expire_at,omitempty"`
+	// Blob describes the file uploaded to the resource, it is maintained by the storage like the timestamps.
+	Blob *Blob `json:"blob,omitempty"`
+	// Checksum, the hex encoded SHA-256 digest, and Size describe the file at the location.
+	// The integrity verification compares the file to them when they are set.
+	Checksum string `json:"checksum,omitempty" validate:"omitempty,len=64,hexadecimal"`
+	Size     *int64 `json:"size,omitempty" validate:"omitempty,min=0"`
+	// Integrity is the result of the last verification of the location, it is maintained by the service.
+	// Clients should hide the broken resources.
+	Integrity *Integrity `json:"integrity,omitempty"`
+}
+
+// Hidden tells whether the schedule of the resource hides it at the given time.
+func (r *Resource) Hidden(at time.Time) bool {
+	return (r.PublishAt != nil && r.PublishAt.After(at)) || (r.ExpireAt != nil && !r.ExpireAt.After(at))
 }
 
 type ContentMap map[string]string
@@ -27,6 +49,8 @@ func (cm *ContentMap) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []uint8:
 		source = []byte(s)
+	case string:
+		source = []byte(s)
 	case nil:
 		return nil
 	default:
@@ -51,10 +75,119 @@ func (cm ContentMap) Value() (driver.Value, error) {
 	return driver.Value([]byte(j)), nil
 }
 
+// Blob is the file uploaded to a resource, the content itself is kept in a blob store.
+type Blob struct {
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	// SHA256 is the hex encoded digest of the content.
+	SHA256 string `json:"sha256"`
+}
+
+func (b *Blob) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []uint8:
+		return json.Unmarshal(s, b)
+	case string:
+		return json.Unmarshal([]byte(s), b)
+	case nil:
+		return nil
+	default:
+		return errors.New("incompatible type for Blob")
+	}
+}
+
+func (b *Blob) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	j, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return driver.Value(j), nil
+}
+
+const (
+	IntegrityOK       = "ok"
+	IntegrityMissing  = "missing"
+	IntegrityMismatch = "mismatch"
+)
+
+// Integrity is the result of the verification of the file a resource points at.
+type Integrity struct {
+	// Status is IntegrityOK, IntegrityMissing or IntegrityMismatch.
+	Status    string    `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Broken tells whether the file of the resource is missing or changed.
+func (i *Integrity) Broken() bool {
+	return i != nil && i.Status != IntegrityOK
+}
+
+func (i *Integrity) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []uint8:
+		return json.Unmarshal(s, i)
+	case string:
+		return json.Unmarshal([]byte(s), i)
+	case nil:
+		return nil
+	default:
+		return errors.New("incompatible type for Integrity")
+	}
+}
+
+func (i *Integrity) Value() (driver.Value, error) {
+	if i == nil {
+		return nil, nil
+	}
+	j, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+	return driver.Value(j), nil
+}
+
+// TenantResource is a resource read across the tenants, by the background jobs.
+type TenantResource struct {
+	TenantID string
+	Resource
+}
+
+// IntegrityReport is the outcome of the verification of a resource, Error is set when it could not be verified.
+type IntegrityReport struct {
+	TenantID   string     `json:"tenant_id"`
+	ResourceID uuid.UUID  `json:"resource_id"`
+	Location   string     `json:"location"`
+	Integrity  *Integrity `json:"integrity,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+const (
+	ResourceSortCreatedAt = "created_at"
+	ResourceSortUpdatedAt = "updated_at"
+)
+
+// ResourceFilter orders and narrows down the resource listings.
+type ResourceFilter struct {
+	// SortBy is the timestamp the resources are ordered by, Since and Until are applied to it as well.
+	// The default is ResourceSortCreatedAt.
+	SortBy    string
+	Ascending bool
+	Since     *time.Time
+	Until     *time.Time
+	// IncludeHidden also lists the resources not published yet or already expired.
+	IncludeHidden bool
+}
+
 type Category struct {
 	ID          int    `json:"id" validate:"required"`
 	Name        string `json:"name" validate:"required"`
 	Description string `json:"description" validate:"required"`
+	// UpdatedAt is the time of the last change, the read endpoints send it as Last-Modified.
+	UpdatedAt time.Time `json:"-"`
 }
 
 func SetField(content ContentMap, keyString string, field string) {
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookSubscription registers a URL that is called back on the changes of the tenant.
+// Empty Categories and EventTypes match every category and event type.
+type WebhookSubscription struct {
+	ID                  uuid.UUID `json:"id"`
+	URL                 string    `json:"url"`
+	Categories          []int     `json:"categories"`
+	EventTypes          []string  `json:"event_types"`
+	Secret              string    `json:"-"`
+	Active              bool      `json:"active"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// Matches tells whether the event has to be delivered to the subscription.
+func (s *WebhookSubscription) Matches(event *ChangeEvent) bool {
+	if len(s.EventTypes) > 0 && !containsString(s.EventTypes, event.Type) {
+		return false
+	}
+
+	if len(s.Categories) == 0 {
+		return true
+	}
+
+	if event.AggregateType != AggregateResource {
+		return false
+	}
+
+	resource := &Resource{}
+	if err := json.Unmarshal(event.Payload, resource); err != nil {
+		return false
+	}
+
+	for _, category := range s.Categories {
+		if category == resource.Category {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a single change event queued for a subscription.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	// URL and Secret are filled in for the delivery worker only.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
@@ -0,0 +1,482 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeEventStream = "text/event-stream"
+	ContentTypeText        = "text/plain"
+	ContentTypeBinary      = "application/octet-stream"
+	ContentTypeMultipart   = "multipart/form-data"
+)
+
+// errorResponseName is the shared response of the failed requests.
+const errorResponseName = "Error"
+
+// Route describes an operation of the API.
+type Route struct {
+	Method string
+	// Path is the echo path of the route, like /api/v1/resources/:resource_id/.
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Request is a value of the request model, its param, query and json tags give the parameters and the body.
+	Request interface{}
+	// RequestBody replaces the body taken from Request, for the bodies that are not JSON like uploads.
+	RequestBody *RequestBody
+	// Parameters are added to the ones taken from Request.
+	Parameters []*Parameter
+	Responses  []RouteResponse
+}
+
+// RouteResponse describes a successful response of a Route.
+type RouteResponse struct {
+	Status      int
+	Description string
+	// ContentType defaults to JSON when Body is set.
+	ContentType string
+	// Body is a value of the response model, nil for empty responses.
+	Body interface{}
+	// Schema replaces the schema of Body, for the bodies that are not JSON like downloads.
+	Schema *Schema
+	// Wrapped tells whether Body is sent in the data field of the response envelope.
+	Wrapped bool
+}
+
+// Builder collects the routes into a Document.
+type Builder struct {
+	doc              *Document
+	envelope         reflect.Type
+	commonParameters []*Parameter
+}
+
+// NewBuilder creates a Builder. envelope is the response wrapper whose data field is replaced by the payload schema
+// and whose error field carries the message of the failed requests.
+func NewBuilder(info Info, envelope interface{}) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas:    make(map[string]*Schema),
+				Parameters: make(map[string]*Parameter),
+				Responses:  make(map[string]*Response),
+			},
+		},
+		envelope: reflect.TypeOf(envelope),
+	}
+
+	b.doc.Components.Responses[errorResponseName] = &Response{
+		Description: "The request failed, the error field holds the reason.",
+		Content: map[string]*MediaType{
+			ContentTypeJSON: {Schema: b.Schema(envelope)},
+		},
+	}
+
+	return b
+}
+
+// AddCommonParameter registers a parameter component that is referenced by every operation, like the identity headers.
+func (b *Builder) AddCommonParameter(key string, parameter *Parameter) {
+	b.doc.Components.Parameters[key] = parameter
+	b.commonParameters = append(b.commonParameters, &Parameter{Ref: "#/components/parameters/" + key})
+}
+
+// AddTag describes a tag used by the routes.
+func (b *Builder) AddTag(name string, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// Document returns the document built so far.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+var echoParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// Path converts an echo path to an OpenAPI path template.
+func Path(echoPath string) string {
+	return echoParam.ReplaceAllString(echoPath, "{$1}")
+}
+
+// Add adds the route to the document.
+func (b *Builder) Add(route Route) {
+	path := Path(route.Path)
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		b.doc.Paths[path] = item
+	}
+
+	op := &Operation{
+		OperationID: operationID(route.Method, route.Path),
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Deprecated:  route.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+	op.Parameters = append(op.Parameters, b.commonParameters...)
+
+	pathParams := make(map[string]bool)
+	for _, m := range echoParam.FindAllStringSubmatch(route.Path, -1) {
+		pathParams[m[1]] = true
+	}
+
+	if route.Request != nil {
+		params, body := b.splitRequest(reflect.TypeOf(route.Request), pathParams)
+		op.Parameters = append(op.Parameters, params...)
+		if body != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]*MediaType{ContentTypeJSON: {Schema: body}},
+			}
+		}
+	}
+	if route.RequestBody != nil {
+		op.RequestBody = route.RequestBody
+	}
+	op.Parameters = append(op.Parameters, route.Parameters...)
+
+	// path parameters the request model does not bind are still part of the path
+	for name := range pathParams {
+		if !hasParameter(op.Parameters, name, "path") {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	for _, resp := range route.Responses {
+		r := &Response{Description: resp.Description}
+		if r.Description == "" {
+			r.Description = http.StatusText(resp.Status)
+		}
+		if resp.Body != nil || resp.Schema != nil {
+			contentType := resp.ContentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+			schema := resp.Schema
+			if schema == nil {
+				schema = b.Schema(resp.Body)
+			}
+			if resp.Wrapped {
+				schema = b.wrap(schema)
+			}
+			r.Content = map[string]*MediaType{contentType: {Schema: schema}}
+		}
+		op.Responses[strconv.Itoa(resp.Status)] = r
+	}
+	op.Responses["default"] = &Response{Ref: "#/components/responses/" + errorResponseName}
+
+	item[strings.ToLower(route.Method)] = op
+}
+
+func hasParameter(params []*Parameter, name string, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+func operationID(method string, path string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '.' }) {
+		part = strings.TrimPrefix(part, ":")
+		if part == "*" {
+			part = "any"
+		}
+		parts = append(parts, strings.Title(strings.Replace(part, "_", " ", -1)))
+	}
+	return strings.Replace(strings.Join(parts, ""), " ", "", -1)
+}
+
+// wrap returns the schema of the envelope carrying data.
+func (b *Builder) wrap(data *Schema) *Schema {
+	envelope := b.structSchema(b.envelope)
+	properties := make(map[string]*Schema, len(envelope.Properties))
+	for name, schema := range envelope.Properties {
+		properties[name] = schema
+		if name == "data" {
+			properties[name] = data
+		}
+	}
+	envelope.Properties = properties
+	return envelope
+}
+
+// splitRequest turns the param and query fields of the request model into parameters and the json fields into the body.
+func (b *Builder) splitRequest(t reflect.Type, pathParams map[string]bool) ([]*Parameter, *Schema) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || isScalar(t) {
+		return nil, b.schema(t)
+	}
+
+	var params []*Parameter
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	bound := false
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+				walk(field.Type)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+
+			required := hasRule(field, "required")
+			if name := tagName(field, "param"); name != "" && pathParams[name] {
+				params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: b.fieldSchema(field)})
+				bound = true
+				continue
+			}
+			if name := tagName(field, "query"); name != "" {
+				p := &Parameter{Name: name, In: "query", Required: required, Schema: b.fieldSchema(field)}
+				if p.Schema.Type == "array" {
+					explode := true
+					p.Explode = &explode
+				}
+				params = append(params, p)
+				bound = true
+				continue
+			}
+			name, ok := jsonName(field)
+			if !ok {
+				continue
+			}
+			body.Properties[name] = b.fieldSchema(field)
+			if required {
+				body.Required = append(body.Required, name)
+			}
+		}
+	}
+	walk(t)
+
+	if len(body.Properties) == 0 {
+		return params, nil
+	}
+	if !bound {
+		return params, b.schema(t)
+	}
+	return params, body
+}
+
+// Schema returns the schema of the type of v. Named structs are added as components and referenced.
+func (b *Builder) Schema(v interface{}) *Schema {
+	return b.schema(reflect.TypeOf(v))
+}
+
+var (
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+func isScalar(t reflect.Type) bool {
+	return t == uuidType || t == timeType
+}
+
+func (b *Builder) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var s *Schema
+	switch {
+	case t == uuidType:
+		s = &Schema{Type: "string", Format: "uuid"}
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		s = &Schema{Description: "Any JSON value."}
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			s = &Schema{Type: "string"}
+		case reflect.Bool:
+			s = &Schema{Type: "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			s = &Schema{Type: "integer", Format: "int32"}
+		case reflect.Int64, reflect.Uint64:
+			s = &Schema{Type: "integer", Format: "int64"}
+		case reflect.Float32, reflect.Float64:
+			s = &Schema{Type: "number"}
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				s = &Schema{Type: "string", Format: "byte"}
+			} else {
+				s = &Schema{Type: "array", Items: b.schema(t.Elem())}
+			}
+		case reflect.Map:
+			s = &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+		case reflect.Struct:
+			if t.Name() == "" {
+				s = b.structSchema(t)
+				break
+			}
+			if _, ok := b.doc.Components.Schemas[t.Name()]; !ok {
+				// registered before it is filled in, so recursive types terminate
+				b.doc.Components.Schemas[t.Name()] = &Schema{}
+				*b.doc.Components.Schemas[t.Name()] = *b.structSchema(t)
+			}
+			s = &Schema{Ref: "#/components/schemas/" + t.Name()}
+		default:
+			s = &Schema{}
+		}
+	}
+
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+				walk(field.Type)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			// fields bound from the path or the query only are not part of the JSON form
+			if field.Tag.Get("json") == "" && (field.Tag.Get("param") != "" || field.Tag.Get("query") != "") {
+				continue
+			}
+			name, ok := jsonName(field)
+			if !ok {
+				continue
+			}
+			s.Properties[name] = b.fieldSchema(field)
+			if hasRule(field, "required") {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	walk(t)
+
+	return s
+}
+
+// fieldSchema returns the schema of a struct field, with the constraints of its validate tag.
+func (b *Builder) fieldSchema(field reflect.StructField) *Schema {
+	s := b.schema(field.Type)
+	if s.Ref != "" {
+		return s
+	}
+
+	target := s
+	for _, rule := range rules(field) {
+		if rule == "dive" && target.Items != nil {
+			target = target.Items
+			continue
+		}
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "min", "max":
+			n, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				continue
+			}
+			applyBound(target, parts[0], n)
+		case "oneof":
+			for _, v := range strings.Fields(parts[1]) {
+				target.Enum = append(target.Enum, v)
+			}
+		}
+	}
+
+	return s
+}
+
+func applyBound(s *Schema, rule string, n float64) {
+	switch s.Type {
+	case "integer", "number":
+		if rule == "min" {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	case "string":
+		length := int(n)
+		if rule == "min" {
+			s.MinLength = &length
+		} else {
+			s.MaxLength = &length
+		}
+	case "array":
+		items := int(n)
+		if rule == "min" {
+			s.MinItems = &items
+		} else {
+			s.MaxItems = &items
+		}
+	}
+}
+
+func rules(field reflect.StructField) []string {
+	tag := field.Tag.Get("validate")
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+func hasRule(field reflect.StructField, rule string) bool {
+	for _, r := range rules(field) {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func tagName(field reflect.StructField, tag string) string {
+	return strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	name := tagName(field, "json")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// FileSink appends every event as a line of NDJSON to a local file.
+// Each line is synced to disk before the event is reported as delivered.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &FileSink{
+		file: file,
+	}, nil
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Publish(_ context.Context, event models.ChangeEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(line); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(s.file.Sync())
+}
+
+func (s *FileSink) Close() error {
+	return errors.WithStack(s.file.Close())
+}
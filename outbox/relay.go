@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// Sink delivers change events to a downstream consumer.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event models.ChangeEvent) error
+}
+
+// Storage is the part of the storage the relay reads the outbox through.
+type Storage interface {
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.ChangeEvent, error)
+	CompleteOutboxEvents(ctx context.Context, ids []int64) error
+	FailOutboxEvent(ctx context.Context, event *models.ChangeEvent, publishErr error, nextAttemptAt time.Time, dead bool) error
+}
+
+// RelayConfig holds the batching and retry policy of the Relay.
+type RelayConfig struct {
+	BatchSize int
+	// Lease is how long a claimed batch is reserved for the relay, it has to outlive the publishing of the whole batch.
+	Lease       time.Duration
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// Relay publishes the events of the transactional outbox to every sink.
+// Delivery is at-least-once: an event is marked as published only after every sink accepted it.
+// The events of an aggregate are published one after the other, a failed event is retried with exponential
+// backoff and holds back the later events of its aggregate until it is published or, after the last attempt,
+// moved to the dead letters.
+type Relay struct {
+	storage Storage
+	sinks   []Sink
+	cfg     RelayConfig
+}
+
+func NewRelay(storage Storage, cfg RelayConfig, sinks ...Sink) *Relay {
+	return &Relay{
+		storage: storage,
+		sinks:   sinks,
+		cfg:     cfg,
+	}
+}
+
+// Run publishes the due events batch by batch until none is left.
+// The failed events wait for their next attempt, so they are not claimed again by the same run.
+func (r *Relay) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		events, err := r.storage.ClaimOutboxEvents(ctx, r.cfg.BatchSize, r.cfg.Lease)
+		if err != nil {
+			return errors.Wrap(err, "cannot claim outbox events")
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := r.publish(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publish delivers a claimed batch, the batch holds at most one event of each aggregate.
+func (r *Relay) publish(ctx context.Context, events []models.ChangeEvent) error {
+	published := make([]int64, 0, len(events))
+
+	for i := range events {
+		event := &events[i]
+		if publishErr := r.publishEvent(ctx, event); publishErr != nil {
+			attempts := event.Attempts + 1
+			dead := attempts >= r.cfg.MaxAttempts
+			if dead {
+				log.Error(ctx, "Outbox event moved to the dead letters", "event_id", event.ID, "attempts", attempts, "error", publishErr)
+			} else {
+				log.Warn(ctx, "Outbox event delivery failed", "event_id", event.ID, "attempts", attempts, "error", publishErr)
+			}
+
+			if err := r.storage.FailOutboxEvent(ctx, event, publishErr, time.Now().Add(r.backoff(attempts)), dead); err != nil {
+				return errors.Wrap(err, "cannot record failed outbox event")
+			}
+			continue
+		}
+
+		published = append(published, event.ID)
+	}
+
+	return errors.Wrap(r.storage.CompleteOutboxEvents(ctx, published), "cannot mark outbox events published")
+}
+
+// backoff returns the wait before the next attempt, doubling with every failed attempt up to the maximum.
+func (r *Relay) backoff(attempts int) time.Duration {
+	wait := r.cfg.BackoffBase
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= r.cfg.BackoffMax {
+			return r.cfg.BackoffMax
+		}
+	}
+	return wait
+}
+
+func (r *Relay) publishEvent(ctx context.Context, event *models.ChangeEvent) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, *event); err != nil {
+			return errors.Wrap(err, sink.Name()+" sink")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+	"github.com/proemergotech/log/v3/zaplog"
+	"go.uber.org/zap"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+type emptyContextMapper struct{}
+
+func (emptyContextMapper) Values(context.Context) map[string]string {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	log.SetGlobalLogger(zaplog.NewLogger(zap.NewNop(), emptyContextMapper{}))
+	os.Exit(m.Run())
+}
+
+// memoryOutbox claims the events like the storage: an event is due if it is not leased or waiting for a retry,
+// and no earlier event of its aggregate is pending.
+type memoryOutbox struct {
+	mu            sync.Mutex
+	events        []models.ChangeEvent
+	nextAttemptAt map[int64]time.Time
+	published     map[int64]bool
+	dead          map[int64]bool
+}
+
+func newMemoryOutbox(events ...models.ChangeEvent) *memoryOutbox {
+	return &memoryOutbox{
+		events:        events,
+		nextAttemptAt: make(map[int64]time.Time),
+		published:     make(map[int64]bool),
+		dead:          make(map[int64]bool),
+	}
+}
+
+func (o *memoryOutbox) ClaimOutboxEvents(_ context.Context, limit int, lease time.Duration) ([]models.ChangeEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	pending := make(map[string]bool)
+	claimed := make([]models.ChangeEvent, 0)
+	for _, event := range o.events {
+		if o.published[event.ID] || o.dead[event.ID] {
+			continue
+		}
+		key := event.AggregateType + "/" + event.AggregateID
+		earlierPending := pending[key]
+		pending[key] = true
+		if earlierPending || o.nextAttemptAt[event.ID].After(now) || len(claimed) == limit {
+			continue
+		}
+		o.nextAttemptAt[event.ID] = now.Add(lease)
+		claimed = append(claimed, event)
+	}
+	return claimed, nil
+}
+
+func (o *memoryOutbox) CompleteOutboxEvents(_ context.Context, ids []int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, id := range ids {
+		o.published[id] = true
+	}
+	return nil
+}
+
+func (o *memoryOutbox) FailOutboxEvent(_ context.Context, event *models.ChangeEvent, _ error, nextAttemptAt time.Time, dead bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i := range o.events {
+		if o.events[i].ID == event.ID {
+			o.events[i].Attempts++
+		}
+	}
+	o.nextAttemptAt[event.ID] = nextAttemptAt
+	o.dead[event.ID] = dead
+	return nil
+}
+
+// recordingSink records the published event IDs and fails the ones in failing.
+type recordingSink struct {
+	failing   map[int64]bool
+	published []int64
+	attempts  map[int64]int
+}
+
+func newRecordingSink(failing ...int64) *recordingSink {
+	sink := &recordingSink{failing: make(map[int64]bool), attempts: make(map[int64]int)}
+	for _, id := range failing {
+		sink.failing[id] = true
+	}
+	return sink
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Publish(_ context.Context, event models.ChangeEvent) error {
+	s.attempts[event.ID]++
+	if s.failing[event.ID] {
+		return errors.New("unreachable")
+	}
+	s.published = append(s.published, event.ID)
+	return nil
+}
+
+func resourceEvent(id int64, resourceID string) models.ChangeEvent {
+	return models.ChangeEvent{ID: id, AggregateType: models.AggregateResource, AggregateID: resourceID, Type: models.EventResourceUpdated}
+}
+
+func TestRelayPublishesInOrder(t *testing.T) {
+	storage := newMemoryOutbox(resourceEvent(1, "a"), resourceEvent(2, "b"), resourceEvent(3, "a"), resourceEvent(4, "a"))
+	sink := newRecordingSink()
+	relay := NewRelay(storage, RelayConfig{BatchSize: 2, Lease: time.Minute, MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: time.Minute}, sink)
+
+	if err := relay.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int64{1, 2, 3, 4}
+	if len(sink.published) != len(expected) {
+		t.Fatalf("expected %v published, got %v", expected, sink.published)
+	}
+	for i := range expected {
+		if sink.published[i] != expected[i] {
+			t.Fatalf("expected %v published, got %v", expected, sink.published)
+		}
+	}
+	for _, id := range expected {
+		if !storage.published[id] {
+			t.Errorf("event %d is not marked published", id)
+		}
+	}
+}
+
+func TestRelayBacksOffFailedEvents(t *testing.T) {
+	storage := newMemoryOutbox(resourceEvent(1, "a"), resourceEvent(2, "b"), resourceEvent(3, "a"))
+	sink := newRecordingSink(1)
+	relay := NewRelay(storage, RelayConfig{BatchSize: 10, Lease: time.Minute, MaxAttempts: 3, BackoffBase: time.Hour, BackoffMax: time.Hour}, sink)
+
+	for i := 0; i < 2; i++ {
+		if err := relay.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if sink.attempts[1] != 1 {
+		t.Fatalf("expected the failed event to wait for its retry, it was tried %d times", sink.attempts[1])
+	}
+	if sink.attempts[3] != 0 {
+		t.Fatal("expected the later event of the failed aggregate held back")
+	}
+	if !storage.published[2] {
+		t.Fatal("expected the event of the other aggregate published")
+	}
+	if storage.dead[1] {
+		t.Fatal("expected the failed event retried before it becomes a dead letter")
+	}
+}
+
+func TestRelayDeadLettersPoisonEvents(t *testing.T) {
+	storage := newMemoryOutbox(resourceEvent(1, "a"), resourceEvent(2, "a"))
+	sink := newRecordingSink(1)
+	relay := NewRelay(storage, RelayConfig{BatchSize: 10, Lease: time.Minute, MaxAttempts: 3, BackoffBase: time.Nanosecond, BackoffMax: time.Nanosecond}, sink)
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		if err := relay.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if sink.attempts[1] != 3 || !storage.dead[1] {
+		t.Fatalf("expected the event moved to the dead letters after 3 attempts, tried %d times", sink.attempts[1])
+	}
+	if !storage.published[2] {
+		t.Fatal("expected the dead letter to let the later event of its aggregate through")
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := NewRelay(nil, RelayConfig{BackoffBase: time.Second, BackoffMax: 5 * time.Second})
+
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if wait := relay.backoff(attempts); wait != expected {
+			t.Errorf("expected %v after %d attempts, got %v", expected, attempts, wait)
+		}
+	}
+}
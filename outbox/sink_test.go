@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+func TestFileSinkAppendsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	for _, id := range []int64{1, 2} {
+		// every sink appends to the same file, like a restarted service
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := resourceEvent(id, "a")
+		event.Attempts = 2
+		if err := sink.Publish(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	ids := make([]int64, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := fields["attempts"]; ok {
+			t.Fatal("expected the delivery state left out of the event")
+		}
+		ids = append(ids, int64(fields["id"].(float64)))
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("expected the events 1 and 2, got %v", ids)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusNoContent
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, server.Client())
+	event := resourceEvent(7, "a")
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Method != http.MethodPost || received.Header.Get("X-Event-ID") != "7" || received.Header.Get("X-Event-Type") != models.EventResourceUpdated {
+		t.Fatalf("unexpected request %s %v", received.Method, received.Header)
+	}
+	published := models.ChangeEvent{}
+	if err := json.Unmarshal(body, &published); err != nil {
+		t.Fatal(err)
+	}
+	if published.ID != 7 || published.AggregateID != "a" {
+		t.Fatalf("unexpected event %s", body)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := sink.Publish(context.Background(), event); err == nil {
+		t.Fatal("expected a failed delivery for a non 2xx answer")
+	}
+}
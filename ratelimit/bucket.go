@@ -0,0 +1,67 @@
+// Package ratelimit provides the token buckets limiting the request rate of the clients.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit is a token bucket holding up to Burst tokens, refilled at Rate tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled tells whether the limit applies, a zero rate disables it.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the state of a bucket after a request tried to take a token from it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long it takes to refill the bucket.
+	Reset time.Duration
+	// RetryAfter is how long a rejected request has to wait for a token.
+	RetryAfter time.Duration
+}
+
+// Bucket is the state of a token bucket. A new bucket is full.
+// It is exported for the Store implementations, it is not safe for concurrent use.
+type Bucket struct {
+	// Missing is the number of tokens taken from the full bucket as of Updated,
+	// so the zero value is a full bucket.
+	Missing float64   `json:"missing"`
+	Updated time.Time `json:"updated"`
+}
+
+// Take refills the bucket up to now and takes a token from it, if there is one.
+func (b *Bucket) Take(limit Limit, now time.Time) Result {
+	if elapsed := now.Sub(b.Updated); elapsed > 0 {
+		b.Missing = math.Max(0, b.Missing-elapsed.Seconds()*limit.Rate)
+	}
+	b.Updated = now
+
+	result := Result{Limit: limit.Burst}
+	if tokens := float64(limit.Burst) - b.Missing; tokens >= 1 {
+		b.Missing++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(float64(limit.Burst) - b.Missing)
+	result.Reset = seconds(b.Missing / limit.Rate)
+	return result
+}
+
+// Full tells whether the bucket is refilled by now, a full bucket can be forgotten.
+func (b *Bucket) Full(limit Limit, now time.Time) bool {
+	return b.Missing <= now.Sub(b.Updated).Seconds()*limit.Rate
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
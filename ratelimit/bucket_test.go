@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+	start := time.Date(2021, 6, 29, 9, 0, 0, 0, time.UTC)
+	bucket := &Bucket{}
+
+	for i := 0; i < 3; i++ {
+		result := bucket.Take(limit, start)
+		if !result.Allowed || result.Remaining != 2-i || result.Limit != 3 {
+			t.Fatalf("take %d: unexpected result %+v", i, result)
+		}
+	}
+
+	result := bucket.Take(limit, start)
+	if result.Allowed || result.Remaining != 0 {
+		t.Fatalf("empty bucket: unexpected result %+v", result)
+	}
+	if result.RetryAfter != 500*time.Millisecond || result.Reset != 1500*time.Millisecond {
+		t.Fatalf("empty bucket: unexpected retry after %s and reset %s", result.RetryAfter, result.Reset)
+	}
+
+	result = bucket.Take(limit, start.Add(500*time.Millisecond))
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("refilled token: unexpected result %+v", result)
+	}
+
+	result = bucket.Take(limit, start.Add(time.Hour))
+	if !result.Allowed || result.Remaining != 2 || result.Reset != 500*time.Millisecond {
+		t.Fatalf("refilled bucket: unexpected result %+v", result)
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 1}
+	start := time.Date(2021, 6, 29, 9, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b"} {
+		if result, _ := m.Take(ctx, key, limit, start); !result.Allowed {
+			t.Fatalf("%s: first take rejected", key)
+		}
+	}
+	if result, _ := m.Take(ctx, "a", limit, start.Add(sweepInterval-500*time.Millisecond)); !result.Allowed {
+		t.Fatal("refilled take rejected")
+	}
+
+	if _, err := m.Take(ctx, "c", limit, start.Add(sweepInterval)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.buckets["b"]; ok {
+		t.Fatal("refilled bucket was kept")
+	}
+	if _, ok := m.buckets["a"]; !ok {
+		t.Fatal("bucket in use was forgotten")
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the buckets by key. Implementations must be safe for concurrent use and take the tokens atomically.
+// Besides the in-process Memory, a store shared by the replicas of the service, like Redis, can be plugged in through it,
+// so the limits hold for the whole deployment.
+type Store interface {
+	// Take takes a token from the bucket of key, a missing bucket is created full.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// sweepInterval is how often Memory forgets the refilled buckets.
+const sweepInterval = time.Minute
+
+// Memory is an in-process Store. The buckets refilled are forgotten periodically,
+// so only the clients active within the refill time are kept.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	limit Limit
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (m *Memory) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{}
+		m.buckets[key] = bucket
+	}
+	bucket.limit = limit
+
+	return bucket.Take(limit, now), nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for key, bucket := range m.buckets {
+		if bucket.Full(bucket.limit, now) {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
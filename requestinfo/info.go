@@ -0,0 +1,46 @@
+package requestinfo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength matches the size of the request_id column of the audit events.
+const maxRequestIDLength = 128
+
+type infoKey struct{}
+
+// Info holds the transport level details of the request a context belongs to.
+type Info struct {
+	RequestID string
+	ClientIP  string
+	// Route is the route template of a REST request or the full method of a gRPC call.
+	Route string
+}
+
+// WithInfo returns a copy of ctx carrying the given request details.
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// FromContext returns the request details stored in ctx.
+// The zero value is returned for contexts that are not bound to a request, like the ones of the background workers.
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(infoKey{}).(Info)
+	return info
+}
+
+// RequestID returns the request ID sent by the client, so the request can be traced across the services.
+// A new ID is generated when none was sent, or the one sent is too long or not printable ASCII.
+func RequestID(sent string) string {
+	if sent == "" || len(sent) > maxRequestIDLength {
+		return uuid.New().String()
+	}
+	for i := 0; i < len(sent); i++ {
+		if sent[i] < ' ' || sent[i] > '~' {
+			return uuid.New().String()
+		}
+	}
+	return sent
+}
@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+)
+
+const resourceBlobPath = "/api/v1/resources/:resource_id/blob"
+
+// blobFormField is the multipart field carrying the file of an upload.
+const blobFormField = "file"
+
+// uploadResourceBlob stores the request body as the blob of the resource. The body is either the file itself,
+// typed by the Content-Type header, or a multipart form with the file in its file field.
+// The body is streamed, only the path is bound.
+func (c *controller) uploadResourceBlob(eCtx echo.Context) error {
+	req, err := blobRequest(eCtx)
+	if err != nil {
+		return err
+	}
+
+	body := io.Reader(eCtx.Request().Body)
+	contentType := eCtx.Request().Header.Get(echo.HeaderContentType)
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == echo.MIMEMultipartForm {
+		reader, err := eCtx.Request().MultipartReader()
+		if err != nil {
+			return myerrors.WithFields(errors.Wrap(err, "invalid multipart form"), models.HTTPCode, http.StatusBadRequest)
+		}
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return myerrors.WithFields(errors.Errorf("the form has no %s field", blobFormField), models.HTTPCode, http.StatusBadRequest)
+			}
+			if err != nil {
+				return myerrors.WithFields(errors.Wrap(err, "invalid multipart form"), models.HTTPCode, http.StatusBadRequest)
+			}
+			if part.FormName() == blobFormField {
+				body = part
+				contentType = part.Header.Get(echo.HeaderContentType)
+				break
+			}
+		}
+	}
+
+	resp, err := c.svc.UploadResourceBlob(eCtx.Request().Context(), req.UUID, body, contentType)
+	if err != nil {
+		return err
+	}
+
+	return eCtx.JSON(http.StatusOK, resp)
+}
+
+// downloadResourceBlob serves the blob of the resource, http.ServeContent answers the range and conditional requests.
+func (c *controller) downloadResourceBlob(eCtx echo.Context) error {
+	req, err := blobRequest(eCtx)
+	if err != nil {
+		return err
+	}
+
+	ctx := eCtx.Request().Context()
+	resource, reader, err := c.svc.OpenResourceBlob(ctx, req.UUID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Warn(ctx, "Cannot close blob", "error", err)
+		}
+	}()
+
+	header := eCtx.Response().Header()
+	header.Set(echo.HeaderContentType, resource.Blob.ContentType)
+	header.Set("ETag", `"`+resource.Blob.SHA256+`"`)
+	if digest, err := hex.DecodeString(resource.Blob.SHA256); err == nil {
+		header.Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(digest))
+	}
+	c.cachePolicies.setHeaders(header, c.cachePolicy(ctx, resource.Category))
+
+	http.ServeContent(eCtx.Response(), eCtx.Request(), "", resource.UpdatedAt, reader)
+	return nil
+}
+
+// blobRequest binds and validates the path of a blob request like the other handlers do.
+// The body is the blob, it is hidden from the binder while the request is bound, so it is left to be streamed.
+func blobRequest(eCtx echo.Context) (*httpModels.ResourceBlobRequest, error) {
+	httpReq := eCtx.Request()
+	body, contentLength := httpReq.Body, httpReq.ContentLength
+	httpReq.Body, httpReq.ContentLength = http.NoBody, 0
+	req := &httpModels.ResourceBlobRequest{}
+	err := eCtx.Bind(req)
+	httpReq.Body, httpReq.ContentLength = body, contentLength
+	if err != nil {
+		// only the path is bound, the error handler would answer the bind errors with 500
+		return nil, myerrors.WithFields(errors.Wrap(err, "invalid resource id"), models.HTTPCode, http.StatusBadRequest)
+	}
+
+	if err := eCtx.Validate(req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
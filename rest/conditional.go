@@ -0,0 +1,187 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// CachePolicies are the Cache-Control values of the resource and category read endpoints.
+// The responses belong to the tenant and the owner of the request, a shared cache must not serve them to anyone else.
+type CachePolicies struct {
+	// Default is sent for the categories and for the responses mixing resources of several categories.
+	Default string
+	// Categories maps the category names to the value sent for their resources.
+	Categories map[string]string
+	// IdentityHeaders are the request headers the tenant and the owner are taken from, the responses vary by them.
+	// Without them the identity comes from the client certificate, which no cache can vary by, so the responses are private.
+	IdentityHeaders []string
+}
+
+// ParseCachePolicies reads the per category policies from a list like "News feed=public, max-age=60; Content=max-age=3600".
+// identityHeaders are the headers the identity of the callers is taken from, none if it comes from their certificates.
+func ParseCachePolicies(defaultPolicy string, categories string, identityHeaders ...string) (*CachePolicies, error) {
+	policies := &CachePolicies{
+		Default:         defaultPolicy,
+		Categories:      make(map[string]string),
+		IdentityHeaders: identityHeaders,
+	}
+
+	for _, entry := range strings.Split(categories, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid cache policy %q, expected <category name>=<Cache-Control value>", entry)
+		}
+		policies.Categories[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return policies, nil
+}
+
+// setHeaders sets the Cache-Control and Vary headers of a response for the caller.
+func (p *CachePolicies) setHeaders(header http.Header, cacheControl string) {
+	if len(p.IdentityHeaders) > 0 {
+		header.Set(echo.HeaderVary, strings.Join(p.IdentityHeaders, ", "))
+	} else {
+		cacheControl = privateCacheControl(cacheControl)
+	}
+	if cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+}
+
+// privateCacheControl keeps the response out of the shared caches: public and the shared cache directives are replaced by private.
+func privateCacheControl(cacheControl string) string {
+	directives := []string{"private"}
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		name := strings.ToLower(strings.SplitN(directive, "=", 2)[0])
+		switch name {
+		case "", "public", "private", "s-maxage", "proxy-revalidate":
+			continue
+		}
+		directives = append(directives, directive)
+	}
+	return strings.Join(directives, ", ")
+}
+
+// resourceJSON sends the resource as a conditional response, the Cache-Control policy is taken from its category.
+func (c *controller) resourceJSON(eCtx echo.Context, body interface{}, resource *models.Resource) error {
+	return c.conditionalJSON(eCtx, body, resource.UpdatedAt, c.cachePolicy(eCtx.Request().Context(), resource.Category))
+}
+
+// resourcesJSON sends the resources as a conditional response, the Cache-Control policy is taken from their category.
+// A listing has no Last-Modified: a deletion or a publication changes it without a newer updated_at,
+// only the ETag of the body tells the changes.
+func (c *controller) resourcesJSON(eCtx echo.Context, body interface{}, resources []models.Resource) error {
+	category := 0
+	for i, resource := range resources {
+		if i == 0 {
+			category = resource.Category
+		} else if category != resource.Category {
+			category = 0
+		}
+	}
+
+	return c.conditionalJSON(eCtx, body, time.Time{}, c.cachePolicy(eCtx.Request().Context(), category))
+}
+
+// categoriesJSON sends the categories as a conditional response with the default Cache-Control policy.
+func (c *controller) categoriesJSON(eCtx echo.Context, body interface{}, categories []models.Category) error {
+	var lastModified time.Time
+	for _, category := range categories {
+		if category.UpdatedAt.After(lastModified) {
+			lastModified = category.UpdatedAt
+		}
+	}
+
+	return c.conditionalJSON(eCtx, body, lastModified, c.cachePolicies.Default)
+}
+
+// conditionalJSON sends body with ETag, Last-Modified and Cache-Control headers,
+// or 304 Not Modified when the validators of the request still match.
+// The ETag hashes the encoded body together with lastModified, so it changes with any visible change.
+func (c *controller) conditionalJSON(eCtx echo.Context, body interface{}, lastModified time.Time, cacheControl string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(lastModified.UTC().Format(time.RFC3339Nano)))
+	_, _ = hash.Write(data)
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+
+	header := eCtx.Response().Header()
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set(echo.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	c.cachePolicies.setHeaders(header, cacheControl)
+
+	if notModified(eCtx.Request(), etag, lastModified) {
+		return eCtx.NoContent(http.StatusNotModified)
+	}
+
+	return eCtx.JSONBlob(http.StatusOK, data)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when the former is missing, as RFC 7232 orders them.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get(echo.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	// the header has a precision of seconds
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// cachePolicy returns the Cache-Control value of the category, 0 selects the default.
+func (c *controller) cachePolicy(ctx context.Context, categoryID int) string {
+	if categoryID == 0 || len(c.cachePolicies.Categories) == 0 {
+		return c.cachePolicies.Default
+	}
+
+	categories, err := c.svc.GetCategories(ctx)
+	if err != nil {
+		log.Warn(ctx, "Cannot resolve the cache policy of the category", "category", categoryID, "error", err)
+		return c.cachePolicies.Default
+	}
+
+	for _, category := range categories {
+		if category.ID == categoryID {
+			if policy, ok := c.cachePolicies.Categories[category.Name]; ok {
+				return policy
+			}
+			break
+		}
+	}
+
+	return c.cachePolicies.Default
+}
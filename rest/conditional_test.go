@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestCachePoliciesKeepTenantsApart checks that a shared cache cannot serve the response of a tenant to another one.
+func TestCachePoliciesKeepTenantsApart(t *testing.T) {
+	tests := []struct {
+		name            string
+		identityHeaders []string
+		policy          string
+		cacheControl    string
+		vary            string
+	}{
+		{
+			name:            "identity headers",
+			identityHeaders: []string{"X-Tenant-ID", "X-Owner-ID"},
+			policy:          "public, max-age=60",
+			cacheControl:    "public, max-age=60",
+			vary:            "X-Tenant-ID, X-Owner-ID",
+		},
+		{
+			name:         "client certificates",
+			policy:       "public, max-age=60, s-maxage=600",
+			cacheControl: "private, max-age=60",
+		},
+		{
+			name:         "client certificates without policy",
+			cacheControl: "private",
+		},
+		{
+			name:         "client certificates with private policy",
+			policy:       "private, no-cache",
+			cacheControl: "private, no-cache",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policies, err := ParseCachePolicies(test.policy, "", test.identityHeaders...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header := http.Header{}
+			policies.setHeaders(header, policies.Default)
+			if cacheControl := header.Get("Cache-Control"); cacheControl != test.cacheControl {
+				t.Errorf("expected Cache-Control %q, got %q", test.cacheControl, cacheControl)
+			}
+			if vary := header.Get("Vary"); vary != test.vary {
+				t.Errorf("expected Vary %q, got %q", test.vary, vary)
+			}
+		})
+	}
+}
@@ -1,41 +1,88 @@
 package rest
 
 import (
+	"context"
+	"expvar"
 	"net/http"
 	"net/http/pprof"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"github.com/proemergotech/log/v3"
 	"github.com/proemergotech/log/v3/echolog"
 
+	"github.com/artofimagination/mysql-resources-db-go-service/gql"
+	"github.com/artofimagination/mysql-resources-db-go-service/health"
 	"github.com/artofimagination/mysql-resources-db-go-service/models"
 	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/openapi"
 	"github.com/artofimagination/mysql-resources-db-go-service/service"
 )
 
+const readinessPath = "/readyz"
+
+// readinessTimeout bounds the checks of the dependencies in a readiness request.
+const readinessTimeout = 2 * time.Second
+
+// streamedPaths are the routes streaming their request or response body, the body recording middleware skips them.
+var streamedPaths = []string{resourceStreamPath, resourceBlobPath}
+
 type controller struct {
-	echoEngine *echo.Echo
-	svc        *service.Service
-	debugPProf bool
+	echoEngine              *echo.Echo
+	svc                     *service.Service
+	graphQL                 *gql.Server
+	readiness               *health.Readiness
+	debugPProf              bool
+	docsUI                  bool
+	cachePolicies           *CachePolicies
+	streamPollInterval      time.Duration
+	streamHeartbeatInterval time.Duration
+
+	done     chan struct{}
+	stopOnce sync.Once
+
+	openAPIOnce     sync.Once
+	openAPIDocument *openapi.Document
 }
 
 func NewController(
 	echoEngine *echo.Echo,
 	svc *service.Service,
+	graphQL *gql.Server,
+	readiness *health.Readiness,
 	debugPProf bool,
+	docsUI bool,
+	cachePolicies *CachePolicies,
+	streamPollInterval time.Duration,
+	streamHeartbeatInterval time.Duration,
 ) Controller {
 	return &controller{
-		echoEngine: echoEngine,
-		svc:        svc,
-		debugPProf: debugPProf,
+		echoEngine:              echoEngine,
+		svc:                     svc,
+		graphQL:                 graphQL,
+		readiness:               readiness,
+		debugPProf:              debugPProf,
+		docsUI:                  docsUI,
+		cachePolicies:           cachePolicies,
+		streamPollInterval:      streamPollInterval,
+		streamHeartbeatInterval: streamHeartbeatInterval,
+		done:                    make(chan struct{}),
 	}
 }
 
+// Stop ends the long running streaming responses, so the server shutdown does not wait for them.
+func (c *controller) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
 func (c *controller) Start() {
 
-	c.echoEngine.Use(echolog.DebugMiddleware(log.GlobalLogger(), true, true))
+	c.echoEngine.Use(skipPaths(streamedPaths, echolog.DebugMiddleware(log.GlobalLogger(), true, true)))
 	if c.debugPProf {
 		runtime.SetBlockProfileRate(1)
 		runtime.SetMutexProfileFraction(5)
@@ -45,6 +92,7 @@ func (c *controller) Start() {
 		c.echoEngine.GET("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
 		c.echoEngine.GET("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
 		c.echoEngine.GET("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+		c.echoEngine.GET("/debug/vars", echo.WrapHandler(expvar.Handler()))
 	}
 
 	c.echoEngine.GET("/", func(eCtx echo.Context) error {
@@ -55,6 +103,18 @@ func (c *controller) Start() {
 		return eCtx.NoContent(http.StatusOK)
 	})
 
+	c.echoEngine.GET(readinessPath, func(eCtx echo.Context) error {
+		ctx, cancel := context.WithTimeout(eCtx.Request().Context(), readinessTimeout)
+		defer cancel()
+
+		report := c.readiness.Check(ctx)
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		return eCtx.JSON(status, httpModels.ResponseData{Data: report})
+	})
+
 	c.echoEngine.POST("/add-resource", func(eCtx echo.Context) error {
 		resource := &models.Resource{}
 		if err := eCtx.Bind(resource); err != nil {
@@ -88,7 +148,7 @@ func (c *controller) Start() {
 			return err
 		}
 
-		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+		return c.resourceJSON(eCtx, httpModels.ResponseData{Data: resp}, resp)
 	})
 
 	c.echoEngine.POST("/update-resource", func(eCtx echo.Context) error {
@@ -133,7 +193,7 @@ func (c *controller) Start() {
 			return err
 		}
 
-		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+		return c.categoriesJSON(eCtx, httpModels.ResponseData{Data: resp}, resp)
 	})
 
 	c.echoEngine.GET("/get-resources-by-ids", func(eCtx echo.Context) error {
@@ -151,7 +211,7 @@ func (c *controller) Start() {
 			return err
 		}
 
-		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+		return c.resourcesJSON(eCtx, httpModels.ResponseData{Data: resp}, resp)
 	})
 
 	c.echoEngine.GET("/get-resources-by-category", func(eCtx echo.Context) error {
@@ -169,14 +229,23 @@ func (c *controller) Start() {
 			return err
 		}
 
-		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+		return c.resourcesJSON(eCtx, httpModels.ResponseData{Data: resp}, resp)
 	})
 
+	c.echoEngine.GET("/graphql", c.graphQLQuery)
+	c.echoEngine.POST("/graphql", c.graphQLQuery)
+
 	// new endpoint format follows REST and CRUD basics
 	apiRoutes := c.echoEngine.Group("/api/v1")
-	apiRoutes.Use(echolog.DebugMiddleware(log.GlobalLogger(), true, true))
+
+	apiRoutes.GET("/openapi.json", c.getOpenAPI)
+	if c.docsUI {
+		apiRoutes.GET("/docs", c.getDocs)
+	}
 
 	resourcesRoutes := apiRoutes.Group("/resources")
+	resourcesRoutes.GET("/stream", c.streamResources)
+
 	resourcesRoutes.GET("/", func(eCtx echo.Context) error {
 		req := &httpModels.GetResourcesByIDsRequest{}
 		if err := eCtx.Bind(req); err != nil {
@@ -192,6 +261,24 @@ func (c *controller) Start() {
 			return err
 		}
 
+		return c.resourcesJSON(eCtx, httpModels.ResponseData{Data: resp}, resp)
+	})
+
+	resourcesRoutes.POST("/lookup", func(eCtx echo.Context) error {
+		req := &httpModels.LookupResourcesRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		resp, err := c.svc.LookupResources(eCtx.Request().Context(), req)
+		if err != nil {
+			return err
+		}
+
 		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
 	})
 
@@ -210,7 +297,7 @@ func (c *controller) Start() {
 			return err
 		}
 
-		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+		return c.resourcesJSON(eCtx, httpModels.ResponseData{Data: resp}, resp)
 	})
 
 	resourcesCRUDRoutes := resourcesRoutes.Group("/:resource_id")
@@ -229,7 +316,7 @@ func (c *controller) Start() {
 			return err
 		}
 
-		return eCtx.JSON(http.StatusOK, resp)
+		return c.resourceJSON(eCtx, resp, resp)
 	})
 
 	resourcesCRUDRoutes.POST("/", func(eCtx echo.Context) error {
@@ -286,6 +373,9 @@ func (c *controller) Start() {
 		return eCtx.NoContent(http.StatusOK)
 	})
 
+	resourcesCRUDRoutes.POST("/blob", c.uploadResourceBlob)
+	resourcesCRUDRoutes.GET("/blob", c.downloadResourceBlob)
+
 	categoryRoutes := apiRoutes.Group("/categories")
 	categoryRoutes.GET("/", func(eCtx echo.Context) error {
 		resp, err := c.svc.GetCategories(eCtx.Request().Context())
@@ -293,6 +383,141 @@ func (c *controller) Start() {
 			return err
 		}
 
+		return c.categoriesJSON(eCtx, httpModels.ResponseData{Data: resp}, resp)
+	})
+
+	apiRoutes.GET("/audit", func(eCtx echo.Context) error {
+		req := &httpModels.GetAuditEventsRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		resp, err := c.svc.GetAuditEvents(eCtx.Request().Context(), req)
+		if err != nil {
+			return err
+		}
+
+		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+	})
+
+	webhookRoutes := apiRoutes.Group("/webhooks")
+	webhookRoutes.GET("/", func(eCtx echo.Context) error {
+		resp, err := c.svc.GetWebhookSubscriptions(eCtx.Request().Context())
+		if err != nil {
+			return err
+		}
+
+		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+	})
+
+	webhookRoutes.POST("/", func(eCtx echo.Context) error {
+		req := &httpModels.WebhookSubscriptionRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		resp, err := c.svc.CreateWebhookSubscription(eCtx.Request().Context(), req)
+		if err != nil {
+			return err
+		}
+
+		return eCtx.JSON(http.StatusCreated, httpModels.ResponseData{Data: resp})
+	})
+
+	webhookRoutes.GET("/dead-letters", func(eCtx echo.Context) error {
+		req := &httpModels.GetWebhookDeadLettersRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		resp, err := c.svc.GetWebhookDeadLetters(eCtx.Request().Context(), req)
+		if err != nil {
+			return err
+		}
+
 		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
 	})
+
+	webhookRoutes.POST("/dead-letters/:delivery_id/replay", func(eCtx echo.Context) error {
+		req := &httpModels.ReplayWebhookDeliveryRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		if err := c.svc.ReplayWebhookDelivery(eCtx.Request().Context(), req.ID); err != nil {
+			return err
+		}
+
+		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: "OK"})
+	})
+
+	webhookCRUDRoutes := webhookRoutes.Group("/:subscription_id")
+	webhookCRUDRoutes.GET("/", func(eCtx echo.Context) error {
+		req := &httpModels.WebhookSubscriptionByIDRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		resp, err := c.svc.GetWebhookSubscription(eCtx.Request().Context(), req.ID)
+		if err != nil {
+			return err
+		}
+
+		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+	})
+
+	webhookCRUDRoutes.PUT("/", func(eCtx echo.Context) error {
+		req := &httpModels.UpdateWebhookSubscriptionRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		resp, err := c.svc.UpdateWebhookSubscription(eCtx.Request().Context(), req)
+		if err != nil {
+			return err
+		}
+
+		return eCtx.JSON(http.StatusOK, httpModels.ResponseData{Data: resp})
+	})
+
+	webhookCRUDRoutes.DELETE("/", func(eCtx echo.Context) error {
+		req := &httpModels.WebhookSubscriptionByIDRequest{}
+		if err := eCtx.Bind(req); err != nil {
+			return err
+		}
+
+		if err := eCtx.Validate(req); err != nil {
+			return err
+		}
+
+		if err := c.svc.DeleteWebhookSubscription(eCtx.Request().Context(), req.ID); err != nil {
+			return err
+		}
+
+		return eCtx.NoContent(http.StatusOK)
+	})
 }
@@ -0,0 +1,210 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/proemergotech/log/v3"
+	"github.com/proemergotech/log/v3/zaplog"
+	"go.uber.org/zap"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	"github.com/artofimagination/mysql-resources-db-go-service/gql"
+	"github.com/artofimagination/mysql-resources-db-go-service/health"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/rest"
+	"github.com/artofimagination/mysql-resources-db-go-service/service"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+	"github.com/artofimagination/mysql-resources-db-go-service/validation"
+)
+
+type emptyContextMapper struct{}
+
+func (emptyContextMapper) Values(context.Context) map[string]string {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	log.SetGlobalLogger(zaplog.NewLogger(zap.NewNop(), emptyContextMapper{}))
+	os.Exit(m.Run())
+}
+
+// newTestHandler serves the routes of the default tenant from a SQLite database.
+func newTestHandler(t *testing.T) (http.Handler, *service.Service) {
+	t.Helper()
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "resources.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	st := storage.NewSQLite(db, 0, nil)
+	if err := st.BootstrapSystem(""); err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewService(st, blobs, 1<<20, "", false)
+
+	graphQL, err := gql.NewServer(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cachePolicies, err := rest.ParseCachePolicies("private, no-cache", "", "X-Tenant-ID", "X-Owner-ID")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(rest.IdentityMiddleware("X-Tenant-ID", "X-Owner-ID"))
+	e.HTTPErrorHandler = rest.DLiveRHTTPErrorHandler
+	e.Validator = validation.NewValidator(validator.New())
+	controller := rest.NewController(e, svc, graphQL, health.NewReadiness(), false, false, cachePolicies, time.Second, time.Second)
+	controller.Start()
+	t.Cleanup(controller.Stop)
+
+	return e, svc
+}
+
+func get(handler http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestListingNotModifiedAfterDelete checks that a listing is not answered with a stale 304 after one of its resources is deleted,
+// the newest updated_at of the listing is the same before and after.
+func TestListingNotModifiedAfterDelete(t *testing.T) {
+	handler, svc := newTestHandler(t)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{TenantID: auth.DefaultTenantID})
+
+	categories, err := svc.GetCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	category := categories[0].ID
+	resources := make([]*models.Resource, 2)
+	for i := range resources {
+		resources[i] = &models.Resource{
+			ID:       uuid.New(),
+			Category: category,
+			Content:  models.ContentMap{models.LocationKey: "https://example.com/" + strconv.Itoa(i)},
+		}
+		if _, err := svc.AddResource(ctx, resources[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := "/api/v1/resources/categories/" + strconv.Itoa(category)
+	listed := get(handler, path, nil)
+	if listed.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", listed.Code, listed.Body)
+	}
+	if lastModified := listed.Header().Get(echo.HeaderLastModified); lastModified != "" {
+		t.Errorf("the listing has Last-Modified %s, it cannot tell the deletions", lastModified)
+	}
+	etag := listed.Header().Get("ETag")
+
+	// the oldest resource is deleted, the newest updated_at of the listing does not change
+	err = svc.DeleteResource(ctx, &httpModels.DeleteResourceRequest{ID: resources[0].ID, Category: category, Content: resources[0].Content})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	since := http.Header{echo.HeaderIfModifiedSince: {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}
+	if rec := get(handler, path, since); rec.Code != http.StatusOK {
+		t.Errorf("status = %d with If-Modified-Since after the delete, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := get(handler, path, http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusOK {
+		t.Errorf("status = %d with the ETag before the delete, want %d", rec.Code, http.StatusOK)
+	}
+
+	// a single resource keeps its Last-Modified
+	rec := get(handler, "/api/v1/resources/"+resources[1].ID.String()+"/", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get(echo.HeaderLastModified) == "" {
+		t.Errorf("status = %d, Last-Modified %q, want the updated_at of the resource", rec.Code, rec.Header().Get(echo.HeaderLastModified))
+	}
+}
+
+// TestBlobRequestBinding checks that binding the path of a blob request leaves the body to be streamed.
+func TestBlobRequestBinding(t *testing.T) {
+	handler, svc := newTestHandler(t)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{TenantID: auth.DefaultTenantID})
+
+	categories, err := svc.GetCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := &models.Resource{
+		ID:       uuid.New(),
+		Category: categories[0].ID,
+		Content:  models.ContentMap{models.LocationKey: "https://example.com/blob"},
+	}
+	if _, err := svc.AddResource(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/v1/resources/" + resource.ID.String() + "/blob"
+
+	form := &bytes.Buffer{}
+	writer := multipart.NewWriter(form)
+	part, err := writer.CreateFormFile("file", "blob.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write([]byte("multipart blob"))
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	uploads := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{"raw", "text/plain", []byte("raw blob"), "raw blob"},
+		{"multipart", writer.FormDataContentType(), form.Bytes(), "multipart blob"},
+	}
+	for _, upload := range uploads {
+		t.Run(upload.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(upload.body))
+			req.Header.Set(echo.HeaderContentType, upload.contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+
+			rec = get(handler, path, nil)
+			if rec.Code != http.StatusOK || rec.Body.String() != upload.want {
+				t.Errorf("downloaded %d %q, want %q", rec.Code, rec.Body, upload.want)
+			}
+		})
+	}
+
+	t.Run("invalid id", func(t *testing.T) {
+		if rec := get(handler, "/api/v1/resources/not-an-id/blob", nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package rest
+
+// docsPage renders the OpenAPI document in the browser. It is self-contained, so it works without internet access.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>API documentation</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 1100px; color: #222; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: .3em; margin-top: 2em; }
+details { border: 1px solid #ddd; border-radius: 4px; margin: .5em 0; }
+summary { cursor: pointer; padding: .5em; font-family: monospace; font-size: 1.05em; }
+summary .method { display: inline-block; width: 5em; font-weight: bold; }
+.get { color: #0a6ebd; } .post { color: #2d8a34; } .put { color: #b57a00; } .delete { color: #c0392b; }
+.deprecated { text-decoration: line-through; color: #888; }
+.body { padding: 0 1em 1em; }
+table { border-collapse: collapse; width: 100%; }
+td, th { border: 1px solid #eee; padding: .3em .5em; text-align: left; vertical-align: top; font-size: .9em; }
+pre { background: #f6f8fa; padding: .5em; overflow: auto; font-size: .85em; }
+</style>
+</head>
+<body>
+<h1 id="title">API documentation</h1>
+<p id="description"></p>
+<p><a href="openapi.json">openapi.json</a></p>
+<div id="operations"></div>
+<script>
+(function () {
+  var doc;
+
+  function resolve(ref) {
+    return ref.replace('#/', '').split('/').reduce(function (node, key) { return node[key]; }, doc);
+  }
+
+  function example(schema, depth) {
+    if (!schema) { return null; }
+    if (schema.$ref) {
+      if (depth > 4) { return schema.$ref.split('/').pop(); }
+      return example(resolve(schema.$ref), depth + 1);
+    }
+    if (schema.enum) { return schema.enum.join(' | '); }
+    switch (schema.type) {
+    case 'object':
+      if (schema.properties) {
+        var out = {};
+        Object.keys(schema.properties).forEach(function (key) { out[key] = example(schema.properties[key], depth + 1); });
+        return out;
+      }
+      if (schema.additionalProperties) { return { '<key>': example(schema.additionalProperties, depth + 1) }; }
+      return {};
+    case 'array':
+      return [example(schema.items, depth + 1)];
+    case 'string':
+      return schema.format ? 'string (' + schema.format + ')' : 'string';
+    case undefined:
+      return 'any';
+    default:
+      return schema.type + (schema.format ? ' (' + schema.format + ')' : '');
+    }
+  }
+
+  function el(tag, attrs, text) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) { node.setAttribute(key, attrs[key]); });
+    if (text !== undefined) { node.textContent = text; }
+    return node;
+  }
+
+  function schemaBlock(parent, title, content) {
+    Object.keys(content || {}).forEach(function (type) {
+      parent.appendChild(el('p', {}, title + ' (' + type + ')'));
+      parent.appendChild(el('pre', {}, JSON.stringify(example(content[type].schema, 0), null, 2)));
+    });
+  }
+
+  function render() {
+    document.getElementById('title').textContent = doc.info.title + ' ' + doc.info.version;
+    document.getElementById('description').textContent = doc.info.description || '';
+
+    var byTag = {};
+    Object.keys(doc.paths).sort().forEach(function (path) {
+      Object.keys(doc.paths[path]).forEach(function (method) {
+        var op = doc.paths[path][method];
+        var tag = (op.tags || ['other'])[0];
+        (byTag[tag] = byTag[tag] || []).push({ path: path, method: method, op: op });
+      });
+    });
+
+    var container = document.getElementById('operations');
+    (doc.tags || []).map(function (t) { return t.name; }).concat(Object.keys(byTag)).forEach(function (tag) {
+      if (!byTag[tag]) { return; }
+      container.appendChild(el('h2', {}, tag));
+      byTag[tag].forEach(function (entry) {
+        var op = entry.op;
+        var details = el('details');
+        var summary = el('summary', { 'class': op.deprecated ? 'deprecated' : '' });
+        summary.appendChild(el('span', { 'class': 'method ' + entry.method }, entry.method.toUpperCase()));
+        summary.appendChild(document.createTextNode(entry.path + '  ' + (op.summary || '')));
+        details.appendChild(summary);
+
+        var body = el('div', { 'class': 'body' });
+        if (op.description) { body.appendChild(el('p', {}, op.description)); }
+
+        var params = (op.parameters || []).map(function (p) { return p.$ref ? resolve(p.$ref) : p; });
+        if (params.length) {
+          var table = el('table');
+          var head = el('tr');
+          ['name', 'in', 'required', 'type', 'description'].forEach(function (h) { head.appendChild(el('th', {}, h)); });
+          table.appendChild(head);
+          params.forEach(function (p) {
+            var row = el('tr');
+            [p.name, p.in, p.required ? 'yes' : '', JSON.stringify(example(p.schema, 0)), p.description || ''].forEach(function (v) {
+              row.appendChild(el('td', {}, v));
+            });
+            table.appendChild(row);
+          });
+          body.appendChild(table);
+        }
+
+        if (op.requestBody) { schemaBlock(body, 'Request body', op.requestBody.content); }
+        Object.keys(op.responses).forEach(function (status) {
+          var resp = op.responses[status];
+          if (resp.$ref) { resp = resolve(resp.$ref); }
+          body.appendChild(el('p', {}, 'Response ' + status + ': ' + (resp.description || '')));
+          schemaBlock(body, 'Body', resp.content);
+        });
+
+        details.appendChild(body);
+        container.appendChild(details);
+      });
+    });
+  }
+
+  fetch('openapi.json').then(function (resp) { return resp.json(); }).then(function (json) {
+    doc = json;
+    render();
+  });
+})();
+</script>
+</body>
+</html>
+`
@@ -10,6 +10,7 @@ import (
 	"github.com/artofimagination/mysql-resources-db-go-service/models"
 	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
 	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/requestinfo"
 )
 
 func DLiveRHTTPErrorHandler(err error, eCtx echo.Context) {
@@ -42,6 +43,7 @@ func DLiveRHTTPErrorHandler(err error, eCtx echo.Context) {
 	}
 
 	_ = eCtx.JSON(statusCode, httpModels.ResponseData{
-		Error: errors.WithStack(err).Error(),
+		Error:     errors.WithStack(err).Error(),
+		RequestID: requestinfo.FromContext(eCtx.Request().Context()).RequestID,
 	})
 }
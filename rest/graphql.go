@@ -0,0 +1,35 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/gql"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+)
+
+// graphQLQuery executes a GraphQL request. GET requests carry the variables as a JSON encoded query parameter.
+// Following the GraphQL over HTTP conventions the response is not wrapped in ResponseData
+// and the field errors are reported in its errors list with status 200.
+func (c *controller) graphQLQuery(eCtx echo.Context) error {
+	req := &gql.Request{}
+	if err := eCtx.Bind(req); err != nil {
+		return err
+	}
+
+	if variables := eCtx.QueryParam("variables"); eCtx.Request().Method == http.MethodGet && variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			return myerrors.WithFields(errors.Wrap(err, "invalid variables"), models.HTTPCode, http.StatusBadRequest)
+		}
+	}
+
+	if err := eCtx.Validate(req); err != nil {
+		return err
+	}
+
+	return eCtx.JSON(http.StatusOK, c.graphQL.Exec(eCtx.Request().Context(), req))
+}
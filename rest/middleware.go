@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/requestinfo"
+)
+
+// maxIdentityLength matches the size of the tenant_id and owner_id columns.
+const maxIdentityLength = 64
+
+var ErrClientCertificateRequired = errors.New("A client certificate is required")
+
+// clientCertificateSkippedPaths are served without a client certificate, the health checks come from the infrastructure.
+var clientCertificateSkippedPaths = map[string]bool{"/healthcheck": true, readinessPath: true}
+
+// ClientCertificateMiddleware attaches the identity of the verified client certificate to the request context,
+// the tenant and the owner are the given attributes of the certificate subject, see auth.CertificateIdentity.
+// The requests without a certificate are rejected with 401 Unauthorized.
+func ClientCertificateMiddleware(tenantAttribute string, ownerAttribute string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(eCtx echo.Context) error {
+			if clientCertificateSkippedPaths[eCtx.Path()] {
+				return next(eCtx)
+			}
+
+			req := eCtx.Request()
+			if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+				return myerrors.WithFields(errors.WithStack(ErrClientCertificateRequired), models.HTTPCode, http.StatusUnauthorized)
+			}
+
+			identity := auth.CertificateIdentity(req.TLS.VerifiedChains[0][0], tenantAttribute, ownerAttribute)
+			if len(identity.TenantID) > maxIdentityLength || len(identity.OwnerID) > maxIdentityLength {
+				return myerrors.WithFields(errors.New("tenant or owner identifier is too long"), models.HTTPCode, http.StatusBadRequest)
+			}
+
+			eCtx.SetRequest(req.WithContext(auth.WithIdentity(req.Context(), identity)))
+			return next(eCtx)
+		}
+	}
+}
+
+// IdentityMiddleware attaches the caller identity to the request context.
+// An identity already placed on the context by an authentication layer always wins,
+// otherwise the tenant and owner are taken from the trusted headers.
+// Requests without a tenant header are executed on behalf of the default tenant.
+func IdentityMiddleware(tenantHeader string, ownerHeader string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(eCtx echo.Context) error {
+			req := eCtx.Request()
+			if _, ok := auth.IdentityFromContext(req.Context()); ok {
+				return next(eCtx)
+			}
+
+			identity := auth.Identity{
+				TenantID: req.Header.Get(tenantHeader),
+				OwnerID:  req.Header.Get(ownerHeader),
+			}
+			if identity.TenantID == "" {
+				identity.TenantID = auth.DefaultTenantID
+			}
+
+			if len(identity.TenantID) > maxIdentityLength || len(identity.OwnerID) > maxIdentityLength {
+				return myerrors.WithFields(errors.New("tenant or owner identifier is too long"), models.HTTPCode, http.StatusBadRequest)
+			}
+
+			eCtx.SetRequest(req.WithContext(auth.WithIdentity(req.Context(), identity)))
+			return next(eCtx)
+		}
+	}
+}
+
+// RequestInfoMiddleware attaches the request ID, the client address and the route to the request context.
+// The X-Request-ID sent by the client is kept, a new one is generated otherwise, and it is echoed in the response.
+func RequestInfoMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(eCtx echo.Context) error {
+			req := eCtx.Request()
+			info := requestinfo.Info{
+				RequestID: requestinfo.RequestID(req.Header.Get(echo.HeaderXRequestID)),
+				ClientIP:  eCtx.RealIP(),
+				Route:     eCtx.Path(),
+			}
+			eCtx.Response().Header().Set(echo.HeaderXRequestID, info.RequestID)
+
+			eCtx.SetRequest(req.WithContext(requestinfo.WithInfo(req.Context(), info)))
+			return next(eCtx)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/config"
+	"github.com/artofimagination/mysql-resources-db-go-service/gql"
+	"github.com/artofimagination/mysql-resources-db-go-service/health"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/openapi"
+)
+
+const (
+	openAPIPath = "/api/v1/openapi.json"
+	docsPath    = "/api/v1/docs"
+)
+
+const (
+	tagLegacy    = "legacy"
+	tagResources = "resources"
+	tagCategory  = "categories"
+	tagAudit     = "audit"
+	tagWebhooks  = "webhooks"
+	tagGraphQL   = "graphql"
+	tagSystem    = "system"
+)
+
+// apiDocument returns the OpenAPI document of every route registered by the controller.
+func (c *controller) apiDocument() *openapi.Document {
+	c.openAPIOnce.Do(func() {
+		version := config.AppVersion
+		if version == "" {
+			version = "dev"
+		}
+
+		b := openapi.NewBuilder(openapi.Info{
+			Title: config.AppName,
+			Description: "Resource and category store. The legacy routes answer 202 Accepted with an error message " +
+				"for missing resources, the /api/v1 routes are the supported ones. Clients over their rate limit are answered " +
+				"429 Too Many Requests with a Retry-After header.",
+			Version: version,
+		}, httpModels.ResponseData{})
+
+		b.AddTag(tagLegacy, "Deprecated routes kept for the existing clients.")
+		b.AddTag(tagResources, "Resources and their attachments.")
+		b.AddTag(tagCategory, "Resource categories.")
+		b.AddTag(tagAudit, "Audit log of the resource mutations.")
+		b.AddTag(tagWebhooks, "Webhook subscriptions and their deliveries.")
+		b.AddTag(tagGraphQL, "GraphQL graph of the resources, categories and attachments.")
+		b.AddTag(tagSystem, "Health and documentation.")
+
+		b.AddCommonParameter("TenantID", &openapi.Parameter{
+			Name:        "X-Tenant-ID",
+			In:          "header",
+			Description: "Tenant of the request, the default tenant is used when missing. The header name is configurable.",
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+		b.AddCommonParameter("OwnerID", &openapi.Parameter{
+			Name:        "X-Owner-ID",
+			In:          "header",
+			Description: "Owner recorded on the created resources. The header name is configurable.",
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+
+		for _, route := range c.apiRoutes() {
+			b.Add(route)
+		}
+		c.openAPIDocument = b.Document()
+	})
+
+	return c.openAPIDocument
+}
+
+// graphQLResponse documents the shape of graphql.Response.
+type graphQLResponse struct {
+	Errors []struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path,omitempty"`
+	} `json:"errors,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+func ok(body interface{}, wrapped bool) []openapi.RouteResponse {
+	return []openapi.RouteResponse{{Status: http.StatusOK, Body: body, Wrapped: wrapped}}
+}
+
+// conditionalParameters are the request headers of the conditional read endpoints.
+var conditionalParameters = []*openapi.Parameter{
+	{Name: "If-None-Match", In: "header", Schema: &openapi.Schema{Type: "string"}, Description: "ETag of a previous response."},
+	{Name: "If-Modified-Since", In: "header", Schema: &openapi.Schema{Type: "string"}, Description: "Last-Modified of a previous response, ignored with If-None-Match. The resource listings have no Last-Modified."},
+}
+
+// conditional documents a read endpoint answering with ETag, Cache-Control and, but for the resource listings, Last-Modified headers.
+func conditional(body interface{}, wrapped bool) []openapi.RouteResponse {
+	return append(ok(body, wrapped), openapi.RouteResponse{Status: http.StatusNotModified, Description: "The cached response is still valid."})
+}
+
+// binarySchema is the schema of the uploaded and downloaded files.
+var binarySchema = &openapi.Schema{Type: "string", Format: "binary"}
+
+// apiRoutes describes the routes registered in Start. Keep the two in sync, the route coverage test fails otherwise.
+func (c *controller) apiRoutes() []openapi.Route {
+	routes := []openapi.Route{
+		{
+			Method: http.MethodGet, Path: "/", Tags: []string{tagSystem}, Summary: "Greeting",
+			Responses: []openapi.RouteResponse{{Status: http.StatusOK, ContentType: openapi.ContentTypeText, Body: ""}},
+		},
+		{
+			Method: http.MethodGet, Path: "/healthcheck", Tags: []string{tagSystem}, Summary: "Liveness check",
+			Responses: []openapi.RouteResponse{{Status: http.StatusOK}},
+		},
+		{
+			Method: http.MethodGet, Path: readinessPath, Tags: []string{tagSystem}, Summary: "Readiness check",
+			Description: "Fails while a dependency is unavailable or the service is draining before a shutdown.",
+			Responses: []openapi.RouteResponse{
+				{Status: http.StatusOK, Body: health.Report{}, Wrapped: true},
+				{Status: http.StatusServiceUnavailable, Description: "The service is not ready.", Body: health.Report{}, Wrapped: true},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: openAPIPath, Tags: []string{tagSystem}, Summary: "This OpenAPI document",
+			Responses: ok(map[string]interface{}{}, false),
+		},
+
+		{
+			Method: http.MethodGet, Path: "/graphql", Tags: []string{tagGraphQL}, Summary: "Run a GraphQL query",
+			Description: "The variables are passed as a JSON encoded query parameter.",
+			Request:     gql.Request{},
+			Parameters: []*openapi.Parameter{{
+				Name: "variables", In: "query", Schema: &openapi.Schema{Type: "string"}, Description: "JSON object of the variables.",
+			}},
+			Responses: ok(graphQLResponse{}, false),
+		},
+		{
+			Method: http.MethodPost, Path: "/graphql", Tags: []string{tagGraphQL}, Summary: "Run a GraphQL query",
+			Request: gql.Request{}, Responses: ok(graphQLResponse{}, false),
+		},
+
+		// legacy routes
+		{
+			Method: http.MethodPost, Path: "/add-resource", Tags: []string{tagLegacy}, Deprecated: true,
+			Summary: "Add a resource", Request: models.Resource{},
+			Responses: []openapi.RouteResponse{{Status: http.StatusCreated, Body: "", Wrapped: true}},
+		},
+		{
+			Method: http.MethodGet, Path: "/get-resource-by-id", Tags: []string{tagLegacy}, Deprecated: true,
+			Summary: "Get a resource", Request: httpModels.GetResourceByIDWithQueryRequest{},
+			Parameters: conditionalParameters, Responses: conditional(models.Resource{}, true),
+		},
+		{
+			Method: http.MethodPost, Path: "/update-resource", Tags: []string{tagLegacy}, Deprecated: true,
+			Summary: "Update a resource", Request: models.Resource{},
+			Responses: []openapi.RouteResponse{{Status: http.StatusCreated, Body: "", Wrapped: true}},
+		},
+		{
+			Method: http.MethodPost, Path: "/delete-resource", Tags: []string{tagLegacy}, Deprecated: true,
+			Summary: "Delete a resource and its attachments", Request: httpModels.DeleteResourceRequest{},
+			Responses: ok("", true),
+		},
+		{
+			Method: http.MethodGet, Path: "/get-categories", Tags: []string{tagLegacy}, Deprecated: true,
+			Summary: "List the categories", Parameters: conditionalParameters,
+			Responses: conditional([]models.Category{}, true),
+		},
+		{
+			Method: http.MethodGet, Path: "/get-resources-by-ids", Tags: []string{tagLegacy}, Deprecated: true,
+			Summary: "Get resources by id", Request: httpModels.GetResourcesByIDsRequest{},
+			Parameters: conditionalParameters, Responses: conditional([]models.Resource{}, true),
+		},
+		{
+			Method: http.MethodGet, Path: "/get-resources-by-category", Tags: []string{tagLegacy}, Deprecated: true,
+			Summary: "List the resources of a category", Request: httpModels.GetResourcesByCategoryRequest{},
+			Parameters: conditionalParameters, Responses: conditional([]models.Resource{}, true),
+		},
+
+		// resources
+		{
+			Method: http.MethodGet, Path: "/api/v1/resources/", Tags: []string{tagResources},
+			Summary: "Get resources by id", Request: httpModels.GetResourcesByIDsRequest{},
+			Parameters: conditionalParameters, Responses: conditional([]models.Resource{}, true),
+		},
+		{
+			Method: http.MethodGet, Path: resourceStreamPath, Tags: []string{tagResources},
+			Summary:     "Stream the resource changes",
+			Description: "Server-sent events of the resource changes in the order they were committed. The id of each event is its position in the change log, it can be sent back in the Last-Event-ID header to resume the stream. The changes of the resources hidden by their schedule are left out unless include_hidden is set.",
+			Request:     httpModels.StreamResourcesRequest{},
+			Parameters: []*openapi.Parameter{{
+				Name: "Last-Event-ID", In: "header", Schema: &openapi.Schema{Type: "integer", Format: "int64"},
+				Description: "Resume after this event, takes precedence over last_event_id.",
+			}},
+			Responses: []openapi.RouteResponse{{
+				Status: http.StatusOK, ContentType: openapi.ContentTypeEventStream, Body: "",
+				Description: "Events named resource.created, resource.updated, resource.deleted, resource.published or resource.expired, with the resource as data.",
+			}},
+		},
+		{
+			Method: http.MethodPost, Path: resourceLookupPath, Tags: []string{tagResources},
+			Summary:     "Look up resources by id",
+			Description: "Returns the resources in the order of the requested ids and lists the ids not found, optionally with the attachments of the resources.",
+			Request:     httpModels.LookupResourcesRequest{},
+			Responses:   ok(httpModels.LookupResourcesResponse{}, true),
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/resources/categories/:category", Tags: []string{tagResources},
+			Summary: "List the resources of a category", Request: httpModels.GetResourcesByCategoryRequest{},
+			Parameters: conditionalParameters, Responses: conditional([]models.Resource{}, true),
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/resources/:resource_id/", Tags: []string{tagResources},
+			Summary: "Get a resource", Request: httpModels.GetResourceByIDRequest{},
+			Parameters: conditionalParameters, Responses: conditional(models.Resource{}, false),
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/resources/:resource_id/", Tags: []string{tagResources},
+			Summary: "Add a resource", Request: httpModels.AddResourceRequest{},
+			Responses: ok(models.Resource{}, false),
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/resources/:resource_id/", Tags: []string{tagResources},
+			Summary: "Update a resource", Request: models.Resource{},
+			Responses: []openapi.RouteResponse{{Status: http.StatusCreated}},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/resources/:resource_id/", Tags: []string{tagResources},
+			Summary: "Delete a resource and its attachments", Request: httpModels.DeleteResourceRequest{},
+			Responses: []openapi.RouteResponse{{Status: http.StatusOK}},
+		},
+		{
+			Method: http.MethodPost, Path: resourceBlobPath, Tags: []string{tagResources},
+			Summary: "Upload the blob of a resource",
+			Description: "Stores the body as the blob of the resource, replacing the previous one. The body is the file itself, " +
+				"typed by the Content-Type header, or a multipart form with the file in the file field. " +
+				"The size, content type and SHA-256 digest are recorded in the resource and its location is set to the download route.",
+			Request: httpModels.ResourceBlobRequest{},
+			RequestBody: &openapi.RequestBody{
+				Required: true,
+				Content: map[string]*openapi.MediaType{
+					openapi.ContentTypeBinary: {Schema: binarySchema},
+					openapi.ContentTypeMultipart: {Schema: &openapi.Schema{
+						Type:       "object",
+						Properties: map[string]*openapi.Schema{blobFormField: binarySchema},
+						Required:   []string{blobFormField},
+					}},
+				},
+			},
+			Responses: ok(models.Resource{}, false),
+		},
+		{
+			Method: http.MethodGet, Path: resourceBlobPath, Tags: []string{tagResources},
+			Summary:     "Download the blob of a resource",
+			Description: "Serves the blob with its recorded content type. Range, If-Range and the conditional headers are supported, the ETag is the SHA-256 digest of the blob.",
+			Request:     httpModels.ResourceBlobRequest{},
+			Parameters: append([]*openapi.Parameter{
+				{Name: "Range", In: "header", Description: "Byte ranges to send, like bytes=0-1023.", Schema: &openapi.Schema{Type: "string"}},
+				{Name: "If-Range", In: "header", Description: "Sends the ranges only if the blob still has this ETag.", Schema: &openapi.Schema{Type: "string"}},
+			}, conditionalParameters...),
+			Responses: []openapi.RouteResponse{
+				{Status: http.StatusOK, ContentType: openapi.ContentTypeBinary, Schema: binarySchema},
+				{Status: http.StatusPartialContent, Description: "The requested ranges of the blob.", ContentType: openapi.ContentTypeBinary, Schema: binarySchema},
+				{Status: http.StatusNotModified, Description: "The blob has not changed since the version the client has."},
+				{Status: http.StatusRequestedRangeNotSatisfiable, Description: "None of the ranges overlap the blob."},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/categories/", Tags: []string{tagCategory},
+			Summary: "List the categories", Parameters: conditionalParameters,
+			Responses: conditional([]models.Category{}, true),
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/audit", Tags: []string{tagAudit},
+			Summary: "Query the audit log", Request: httpModels.GetAuditEventsRequest{},
+			Responses: ok([]models.AuditEvent{}, true),
+		},
+
+		// webhooks
+		{
+			Method: http.MethodGet, Path: "/api/v1/webhooks/", Tags: []string{tagWebhooks},
+			Summary: "List the webhook subscriptions", Responses: ok([]models.WebhookSubscription{}, true),
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/webhooks/", Tags: []string{tagWebhooks},
+			Summary: "Create a webhook subscription", Description: "The URL has to be an http or https URL of a public address.",
+			Request:   httpModels.WebhookSubscriptionRequest{},
+			Responses: []openapi.RouteResponse{{Status: http.StatusCreated, Body: models.WebhookSubscription{}, Wrapped: true}},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/webhooks/dead-letters", Tags: []string{tagWebhooks},
+			Summary: "List the dead webhook deliveries", Request: httpModels.GetWebhookDeadLettersRequest{},
+			Responses: ok([]models.WebhookDelivery{}, true),
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/webhooks/dead-letters/:delivery_id/replay", Tags: []string{tagWebhooks},
+			Summary: "Replay a dead webhook delivery", Request: httpModels.ReplayWebhookDeliveryRequest{},
+			Responses: ok("", true),
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/webhooks/:subscription_id/", Tags: []string{tagWebhooks},
+			Summary: "Get a webhook subscription", Request: httpModels.WebhookSubscriptionByIDRequest{},
+			Responses: ok(models.WebhookSubscription{}, true),
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/webhooks/:subscription_id/", Tags: []string{tagWebhooks},
+			Summary: "Update a webhook subscription", Description: "An empty secret keeps the current one. The URL has to be an http or https URL of a public address.",
+			Request:   httpModels.UpdateWebhookSubscriptionRequest{},
+			Responses: ok(models.WebhookSubscription{}, true),
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/webhooks/:subscription_id/", Tags: []string{tagWebhooks},
+			Summary: "Delete a webhook subscription", Request: httpModels.WebhookSubscriptionByIDRequest{},
+			Responses: []openapi.RouteResponse{{Status: http.StatusOK}},
+		},
+	}
+
+	if c.docsUI {
+		routes = append(routes, openapi.Route{
+			Method: http.MethodGet, Path: docsPath, Tags: []string{tagSystem}, Summary: "API documentation page",
+			Responses: []openapi.RouteResponse{{Status: http.StatusOK, ContentType: echo.MIMETextHTML, Body: ""}},
+		})
+	}
+
+	if c.debugPProf {
+		for _, path := range []string{"/debug/pprof/*", "/debug/pprof/cmdline", "/debug/pprof/profile", "/debug/pprof/symbol", "/debug/pprof/trace"} {
+			routes = append(routes, openapi.Route{
+				Method: http.MethodGet, Path: path, Tags: []string{tagSystem}, Summary: "Go runtime profiling",
+				Responses: []openapi.RouteResponse{{Status: http.StatusOK}},
+			})
+		}
+		routes = append(routes, openapi.Route{
+			Method: http.MethodGet, Path: "/debug/vars", Tags: []string{tagSystem}, Summary: "Runtime and cache counters",
+			Responses: []openapi.RouteResponse{{Status: http.StatusOK}},
+		})
+	}
+
+	return routes
+}
+
+func (c *controller) getOpenAPI(eCtx echo.Context) error {
+	return eCtx.JSON(http.StatusOK, c.apiDocument())
+}
+
+func (c *controller) getDocs(eCtx echo.Context) error {
+	return eCtx.HTML(http.StatusOK, docsPage)
+}
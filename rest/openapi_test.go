@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/health"
+	"github.com/artofimagination/mysql-resources-db-go-service/openapi"
+)
+
+// TestOpenAPICoversRoutes fails when a route registered by the controller is missing from the OpenAPI document,
+// or when the document describes a route that is not registered.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	for _, optional := range []bool{false, true} {
+		echoEngine := echo.New()
+		c := NewController(echoEngine, nil, nil, health.NewReadiness(), optional, optional, &CachePolicies{}, time.Second, time.Second).(*controller)
+		c.Start()
+
+		// the catch-all routes added by Group.Use are not part of the API
+		notFound := runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+
+		doc := c.apiDocument()
+		registered := make(map[string]bool)
+		for _, route := range echoEngine.Routes() {
+			if route.Name == notFound {
+				continue
+			}
+
+			path := openapi.Path(route.Path)
+			method := strings.ToLower(route.Method)
+			registered[method+" "+path] = true
+
+			if _, ok := doc.Paths[path][method]; !ok {
+				t.Errorf("route %s %s has no OpenAPI entry", route.Method, route.Path)
+			}
+		}
+
+		for path, item := range doc.Paths {
+			for method := range item {
+				if !registered[method+" "+path] {
+					t.Errorf("OpenAPI entry %s %s has no registered route", strings.ToUpper(method), path)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/ratelimit"
+)
+
+var ErrRateLimited = errors.New("The rate limit is exceeded")
+
+// The client identifiers the rate limits can be kept by. All of them are verified, a client cannot change them at will
+// to start over with a full bucket: the api_key is an issued API key, the jwt_subject is the sub claim of a bearer token
+// whose signature is verified, the certificate is the verified client certificate of the connection,
+// the ip is the address of the connection, or the one forwarded by the trusted proxies in front of the service.
+const (
+	RateLimitKeyAPIKey      = "api_key"
+	RateLimitKeyJWTSubject  = "jwt_subject"
+	RateLimitKeyCertificate = "certificate"
+	RateLimitKeyIP          = "ip"
+)
+
+// RateLimitConfig configures RateLimitMiddleware.
+type RateLimitConfig struct {
+	// Read limits the GET, HEAD and OPTIONS requests and the GraphQL queries, Write the rest. A zero Limit disables them.
+	Read  ratelimit.Limit
+	Write ratelimit.Limit
+	// Keys are the client identifiers in order of preference, the first one found and verified in the request is limited.
+	// Requests without any of them are not limited.
+	Keys []string
+	// APIKeyHeader is the header of the api_key identifier, APIKeys authenticates it.
+	APIKeyHeader string
+	APIKeys      *auth.APIKeys
+	// JWT verifies the bearer tokens of the jwt_subject identifier.
+	JWT *auth.JWTVerifier
+	// TrustedProxies are the networks of the proxies whose X-Forwarded-For header tells the address of the client.
+	TrustedProxies []*net.IPNet
+}
+
+// ParseRateLimitKeys reads the identifiers from a comma separated list like "api_key,jwt_subject,certificate,ip".
+func ParseRateLimitKeys(keys string) ([]string, error) {
+	parsed := make([]string, 0)
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		switch key {
+		case "":
+			continue
+		case RateLimitKeyAPIKey, RateLimitKeyJWTSubject, RateLimitKeyCertificate, RateLimitKeyIP:
+			parsed = append(parsed, key)
+		default:
+			return nil, errors.Errorf("invalid rate limit key %q, expected %s, %s, %s or %s",
+				key, RateLimitKeyAPIKey, RateLimitKeyJWTSubject, RateLimitKeyCertificate, RateLimitKeyIP)
+		}
+	}
+	return parsed, nil
+}
+
+// ParseTrustedProxies reads the networks from a comma separated list of addresses and CIDRs like "10.0.0.0/8,192.0.2.1".
+func ParseTrustedProxies(proxies string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0)
+	for _, proxy := range strings.Split(proxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			parsed = append(parsed, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.Errorf("invalid trusted proxy %q", proxy)
+		}
+		parsed = append(parsed, network)
+	}
+	return parsed, nil
+}
+
+// RateLimits holds the RateLimitConfig of the middleware, it can be replaced while the server is running.
+type RateLimits struct {
+	config atomic.Value
+}
+
+func NewRateLimits(cfg RateLimitConfig) *RateLimits {
+	limits := &RateLimits{}
+	limits.Set(cfg)
+	return limits
+}
+
+// Set replaces the limits, the buckets of the clients are kept.
+func (l *RateLimits) Set(cfg RateLimitConfig) {
+	l.config.Store(cfg)
+}
+
+func (l *RateLimits) get() RateLimitConfig {
+	return l.config.Load().(RateLimitConfig)
+}
+
+// rateLimitSkippedPaths are never limited, the health checks come from the infrastructure.
+var rateLimitSkippedPaths = map[string]bool{"/healthcheck": true, readinessPath: true}
+
+// RateLimitMiddleware takes a token from the read or write bucket of the client for every request,
+// and rejects the request with 429 Too Many Requests when the bucket is empty.
+// The state of the bucket is sent in the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// a rejection tells when to try again in Retry-After. The limits are not enforced while the store fails.
+func RateLimitMiddleware(store ratelimit.Store, limits *RateLimits) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(eCtx echo.Context) error {
+			if rateLimitSkippedPaths[eCtx.Path()] {
+				return next(eCtx)
+			}
+
+			cfg := limits.get()
+
+			bucket, limit := "write", cfg.Write
+			if isReadRequest(eCtx) {
+				bucket, limit = "read", cfg.Read
+			}
+			if !limit.Enabled() {
+				return next(eCtx)
+			}
+
+			client := rateLimitClient(eCtx, cfg)
+			if client == "" {
+				return next(eCtx)
+			}
+
+			ctx := eCtx.Request().Context()
+			result, err := store.Take(ctx, bucket+":"+client, limit, time.Now())
+			if err != nil {
+				log.Warn(ctx, "Rate limit store error", "error", err)
+				return next(eCtx)
+			}
+
+			header := eCtx.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+			if !result.Allowed {
+				header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+				return myerrors.WithFields(errors.WithStack(ErrRateLimited), models.HTTPCode, http.StatusTooManyRequests)
+			}
+
+			return next(eCtx)
+		}
+	}
+}
+
+// isReadRequest tells whether the request only reads, the GraphQL schema has no mutations.
+func isReadRequest(eCtx echo.Context) bool {
+	switch eCtx.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return eCtx.Path() == "/graphql" || eCtx.Path() == resourceLookupPath
+	}
+	return false
+}
+
+// rateLimitClient returns the first configured identifier of the client found in the request, hashed.
+// The headers sent by the client are never used without being verified, an API key that is not issued
+// or a token whose signature is invalid falls through to the next identifier, see also clientIP.
+func rateLimitClient(eCtx echo.Context, cfg RateLimitConfig) string {
+	req := eCtx.Request()
+	for _, key := range cfg.Keys {
+		value := ""
+		switch key {
+		case RateLimitKeyAPIKey:
+			if apiKey := req.Header.Get(cfg.APIKeyHeader); cfg.APIKeys != nil && cfg.APIKeys.Authenticate(apiKey) {
+				value = apiKey
+			}
+		case RateLimitKeyJWTSubject:
+			if token := bearerToken(req); cfg.JWT != nil && token != "" {
+				if subject, err := cfg.JWT.Subject(token, time.Now()); err == nil {
+					value = subject
+				}
+			}
+		case RateLimitKeyCertificate:
+			if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+				sum := sha256.Sum256(req.TLS.VerifiedChains[0][0].Raw)
+				value = hex.EncodeToString(sum[:])
+			}
+		case RateLimitKeyIP:
+			value = clientIP(req, cfg.TrustedProxies)
+		}
+		if value != "" {
+			sum := sha256.Sum256([]byte(key + ":" + value))
+			return hex.EncodeToString(sum[:])
+		}
+	}
+	return ""
+}
+
+// bearerToken returns the token of the Authorization header, if it is a bearer token.
+func bearerToken(req *http.Request) string {
+	const prefix = "Bearer "
+	authorization := req.Header.Get(echo.HeaderAuthorization)
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return ""
+	}
+	return authorization[len(prefix):]
+}
+
+// clientIP returns the address the request comes from. When the connection comes from a trusted proxy,
+// the X-Forwarded-For header is read from the right, the first address not of a trusted proxy is the client.
+// The addresses left of it are sent by the client, they are never believed.
+func clientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(req.Header.Values(echo.HeaderXForwardedFor), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && isTrustedProxy(ip, trustedProxies); i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+	}
+	return ip.String()
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,214 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/ratelimit"
+)
+
+// TestRateLimitIgnoresClientHeaders checks that a client cannot get a new bucket by changing the headers it sends.
+func TestRateLimitIgnoresClientHeaders(t *testing.T) {
+	keys, err := ParseRateLimitKeys("certificate,ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := NewRateLimits(RateLimitConfig{Read: ratelimit.Limit{Rate: 0.001, Burst: 2}, Keys: keys})
+	handler := RateLimitMiddleware(ratelimit.NewMemory(), limits)(func(echo.Context) error { return nil })
+
+	e := echo.New()
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/", nil)
+		req.RemoteAddr = "192.0.2.10:40000"
+		req.Header.Set(echo.HeaderXForwardedFor, "203.0.113."+strconv.Itoa(i))
+		req.Header.Set(echo.HeaderXRealIP, "203.0.113."+strconv.Itoa(i))
+		req.Header.Set("X-API-Key", "key-"+strconv.Itoa(i))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer token-"+strconv.Itoa(i))
+
+		err := handler(e.NewContext(req, httptest.NewRecorder()))
+		if i < 2 && err != nil {
+			t.Fatalf("request %d is limited: %v", i, err)
+		}
+		if i == 2 && err == nil {
+			t.Fatal("changing the headers reset the rate limit")
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.5:1000", expected: "203.0.113.5"},
+		{name: "untrusted forwarder", remoteAddr: "203.0.113.5:1000", forwarded: "198.51.100.1", expected: "203.0.113.5"},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:1000", forwarded: "198.51.100.1", expected: "198.51.100.1"},
+		{name: "trusted address", remoteAddr: "192.0.2.1:1000", forwarded: "198.51.100.1", expected: "198.51.100.1"},
+		{name: "spoofed by the client", remoteAddr: "10.1.2.3:1000", forwarded: "1.2.3.4, 198.51.100.1, 10.0.0.2", expected: "198.51.100.1"},
+		{name: "trusted proxy without header", remoteAddr: "10.1.2.3:1000", expected: "10.1.2.3"},
+		{name: "invalid forwarded address", remoteAddr: "10.1.2.3:1000", forwarded: "garbage", expected: "10.1.2.3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.forwarded != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, test.forwarded)
+			}
+			if ip := clientIP(req, trustedProxies); ip != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, ip)
+			}
+		})
+	}
+}
+
+func TestParseRateLimitKeys(t *testing.T) {
+	keys, err := ParseRateLimitKeys("api_key, jwt_subject,certificate,ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 4 {
+		t.Errorf("parsed %v, want the four keys", keys)
+	}
+	if _, err := ParseRateLimitKeys("ip,cookie"); err == nil {
+		t.Error("an unknown key is accepted")
+	}
+}
+
+// TestRateLimitVerifiedCredentials checks that the API keys and the tokens only keep a bucket of their own once verified,
+// the others are limited by the address of the client.
+func TestRateLimitVerifiedCredentials(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "api_keys")
+	sum := sha256.Sum256([]byte("issued-key"))
+	if err := ioutil.WriteFile(keysFile, []byte("# issued keys\n"+hex.EncodeToString(sum[:])+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	apiKeys, err := auth.LoadAPIKeys(keysFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt, err := auth.NewJWTVerifier("jwt-secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseRateLimitKeys("api_key,jwt_subject,ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := RateLimitConfig{
+		Read:         ratelimit.Limit{Rate: 0.001, Burst: 2},
+		Keys:         keys,
+		APIKeyHeader: "X-API-Key",
+		APIKeys:      apiKeys,
+		JWT:          jwt,
+	}
+
+	tests := []struct {
+		name string
+		// request sets the credential of the i-th request, sent from a new address every time
+		request func(req *http.Request, i int)
+	}{
+		{
+			name: "issued API key",
+			request: func(req *http.Request, _ int) {
+				req.Header.Set("X-API-Key", "issued-key")
+			},
+		},
+		{
+			name: "verified token",
+			request: func(req *http.Request, _ int) {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+signHS256(t, "jwt-secret", `{"sub":"client-1"}`))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := RateLimitMiddleware(ratelimit.NewMemory(), NewRateLimits(cfg))(func(echo.Context) error { return nil })
+			e := echo.New()
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/", nil)
+				req.RemoteAddr = "192.0.2." + strconv.Itoa(i+1) + ":40000"
+				test.request(req, i)
+
+				err := handler(e.NewContext(req, httptest.NewRecorder()))
+				if i < 2 && err != nil {
+					t.Fatalf("request %d is limited: %v", i, err)
+				}
+				if i == 2 && err == nil {
+					t.Fatal("changing the address reset the rate limit of the credential")
+				}
+			}
+		})
+	}
+
+	unverified := []struct {
+		name    string
+		request func(req *http.Request, i int)
+	}{
+		{
+			name: "unknown API key",
+			request: func(req *http.Request, i int) {
+				req.Header.Set("X-API-Key", "key-"+strconv.Itoa(i))
+			},
+		},
+		{
+			name: "forged token",
+			request: func(req *http.Request, i int) {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+signHS256(t, "other-secret", `{"sub":"client-`+strconv.Itoa(i)+`"}`))
+			},
+		},
+		{
+			name: "expired token",
+			request: func(req *http.Request, i int) {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+signHS256(t, "jwt-secret", `{"sub":"client-`+strconv.Itoa(i)+`","exp":1}`))
+			},
+		},
+	}
+	for _, test := range unverified {
+		t.Run(test.name, func(t *testing.T) {
+			handler := RateLimitMiddleware(ratelimit.NewMemory(), NewRateLimits(cfg))(func(echo.Context) error { return nil })
+			e := echo.New()
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/", nil)
+				req.RemoteAddr = "192.0.2.10:40000"
+				test.request(req, i)
+
+				err := handler(e.NewContext(req, httptest.NewRecorder()))
+				if i < 2 && err != nil {
+					t.Fatalf("request %d is limited: %v", i, err)
+				}
+				if i == 2 && err == nil {
+					t.Fatal("changing the unverified credential reset the rate limit of the address")
+				}
+			}
+		})
+	}
+}
+
+func signHS256(t *testing.T, secret string, claims string) string {
+	t.Helper()
+	signed := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
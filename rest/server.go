@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -10,6 +11,7 @@ import (
 
 type Controller interface {
 	Start()
+	Stop()
 }
 
 type Server struct {
@@ -27,11 +29,11 @@ func NewServer(
 	}
 }
 
-func (s *Server) Start(errorCh chan<- error) {
+func (s *Server) Start(_ context.Context, errorCh chan<- error) {
 	s.controller.Start()
 
 	go func() {
-		if err := s.echoEngine.StartServer(s.echoEngine.Server); err != nil {
+		if err := s.echoEngine.StartServer(s.echoEngine.Server); err != nil && err != http.ErrServerClosed {
 			errorCh <- errors.Wrap(err, "http server error")
 		}
 	}()
@@ -41,6 +43,8 @@ func (s *Server) Stop(timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	s.controller.Stop()
+
 	if err := s.echoEngine.Shutdown(ctx); err != nil {
 		return errors.Wrap(err, "server graceful shutdown failed")
 	}
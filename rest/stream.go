@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+)
+
+const resourceStreamPath = "/api/v1/resources/stream"
+
+// resourceLookupPath looks up the resources with a POST, it is a read nevertheless.
+const resourceLookupPath = "/api/v1/resources/lookup"
+
+// streamResources sends the resource change events of the tenant as server-sent events until the client disconnects
+// or the server stops. Clients resume through the Last-Event-ID header or the last_event_id query parameter.
+// The changes of the resources hidden by their schedule are only sent with include_hidden.
+func (c *controller) streamResources(eCtx echo.Context) error {
+	req := &httpModels.StreamResourcesRequest{}
+	if err := eCtx.Bind(req); err != nil {
+		return err
+	}
+
+	if err := eCtx.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := eCtx.Request().Context()
+
+	lastEventID := req.LastEventID
+	if header := eCtx.Request().Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			return myerrors.WithFields(errors.New("invalid Last-Event-ID header"), models.HTTPCode, http.StatusBadRequest)
+		}
+		lastEventID = id
+	}
+
+	if lastEventID == 0 {
+		latest, err := c.svc.GetLatestChangeLogPosition(ctx)
+		if err != nil {
+			return err
+		}
+		lastEventID = latest
+	}
+
+	w := eCtx.Response()
+	flusher, ok := w.Writer.(http.Flusher)
+	if !ok {
+		return myerrors.WithFields(errors.New("streaming is not supported by the connection"), models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(c.streamPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(c.streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.done:
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			flusher.Flush()
+		case <-poll.C:
+			var events []models.ChangeEvent
+			var err error
+			events, lastEventID, err = c.svc.GetResourceChangeEvents(ctx, lastEventID, req.Category, req.IncludeHidden)
+			if err != nil {
+				// the response has already been started, so the error can only be logged
+				log.Error(ctx, "Resource stream failed", "error", err)
+				return nil
+			}
+
+			for _, event := range events {
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Position, event.Type, event.Payload); err != nil {
+					return nil
+				}
+			}
+			if len(events) > 0 {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// skipPaths disables middleware for the given routes, like the body recording ones on streamed bodies.
+func skipPaths(paths []string, middleware echo.MiddlewareFunc) echo.MiddlewareFunc {
+	skipped := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		skipped[path] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		wrapped := middleware(next)
+		return func(eCtx echo.Context) error {
+			if skipped[eCtx.Path()] {
+				return next(eCtx)
+			}
+			return wrapped(eCtx)
+		}
+	}
+}
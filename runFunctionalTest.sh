@@ -11,4 +11,11 @@ status=$?;
 if [[ $status != 0 ]]; then 
   exit $status; 
 fi
+
+./tests/provisionTenants.sh
+status=$?;
+if [[ $status != 0 ]]; then
+  exit $status;
+fi
+
 python3 -m pytest -v tests/functional
\ No newline at end of file
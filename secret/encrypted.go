@@ -0,0 +1,104 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// KeySize is the size of the AES-256 keys of the encrypted files.
+const KeySize = 32
+
+// EncryptedFile holds the secrets of a local file encrypted with AES-256-GCM.
+// The file is the nonce followed by the sealed JSON object of the secrets by name.
+type EncryptedFile struct {
+	secrets map[string]string
+}
+
+// OpenEncryptedFile decrypts the secrets of the file at path. The file is read once, it is opened again to pick up a rotation.
+func OpenEncryptedFile(path string, key []byte) (*EncryptedFile, error) {
+	sealed, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.Errorf("the encrypted secrets file %s is truncated", path)
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decrypt %s, the key does not match", path)
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, errors.Wrapf(err, "invalid secrets in %s", path)
+	}
+	return &EncryptedFile{secrets: secrets}, nil
+}
+
+func (f *EncryptedFile) Get(ctx context.Context, name string) (string, error) {
+	value, ok := f.secrets[name]
+	if !ok {
+		return "", errors.WithStack(ErrNotFound)
+	}
+	return value, nil
+}
+
+// Seal encrypts the secrets into the content of an encrypted file.
+func Seal(secrets map[string]string, key []byte) ([]byte, error) {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+// ReadKeyFile reads a base64 encoded key, like the output of "head -c 32 /dev/urandom | base64".
+func ReadKeyFile(path string) ([]byte, error) {
+	encoded, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.Wrapf(err, "the key in %s is not base64 encoded", path)
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errors.Errorf("the key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,68 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestEncryptedFile(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, KeySize)
+
+	sealed, err := Seal(map[string]string{"mysql_db_password": "secret"}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "secrets")
+	if err := ioutil.WriteFile(path, sealed, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	readKey, err := ReadKeyFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := OpenEncryptedFile(path, readKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := provider.Get(context.Background(), "mysql_db_password"); err != nil || value != "secret" {
+		t.Fatalf("expected the secret, got %q, %v", value, err)
+	}
+	if _, err := provider.Get(context.Background(), "blob_s3_secret_key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing secret, got %v", err)
+	}
+
+	if _, err := OpenEncryptedFile(path, bytes.Repeat([]byte{8}, KeySize)); err == nil {
+		t.Fatal("expected an error for a wrong key")
+	}
+	if _, err := OpenEncryptedFile(path, key[:16]); err == nil {
+		t.Fatal("expected an error for a short key")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := ioutil.WriteFile(path, []byte("p@ss word\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "p@ss word" {
+		t.Fatalf("expected the secret without the line break, got %q", value)
+	}
+}
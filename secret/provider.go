@@ -0,0 +1,28 @@
+// Package secret looks up the credentials of the service outside of its configuration.
+package secret
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNotFound = errors.New("The secret not found")
+
+// Provider looks up the secrets by the name of their setting, like mysql_db_password.
+type Provider interface {
+	// Get returns ErrNotFound when the provider does not hold the secret.
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// ReadFile reads a secret stored in a file, like the Docker and Kubernetes secrets.
+// The line break at the end of the file is not part of the secret.
+func ReadFile(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+)
+
+// defaultAuditEventsLimit is the page size used when the request does not set one.
+const defaultAuditEventsLimit = 100
+
+func (s *Service) GetAuditEvents(ctx context.Context, req *httpModels.GetAuditEventsRequest) ([]models.AuditEvent, error) {
+	log.Debug(ctx, "Getting audit events")
+
+	filter := &models.AuditFilter{
+		ResourceID: req.ResourceID,
+		Actor:      req.Actor,
+		Since:      req.Since,
+		Until:      req.Until,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditEventsLimit
+	}
+
+	events, err := s.mySQLStorage.GetAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return events, nil
+}
+
+// PruneAuditEvents removes the audit events older than the retention period.
+func (s *Service) PruneAuditEvents(ctx context.Context, retention time.Duration) error {
+	deleted, err := s.mySQLStorage.DeleteAuditEventsBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Info(ctx, "Audit events pruned", "count", deleted)
+	}
+
+	return nil
+}
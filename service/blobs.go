@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+)
+
+var ErrBlobNotFound = errors.New("The resource has no blob")
+var ErrBlobTooLarge = errors.New("The blob exceeds the size limit")
+
+// blobLocationFormat is the download route of the blobs, uploads set it as the location of the resource.
+const blobLocationFormat = "/api/v1/resources/%s/blob"
+
+// blobKey returns the key of the content in the blob store. The digest makes every version a separate blob,
+// so an upload never overwrites the blob the stored resource still refers to.
+func blobKey(tenantID string, resourceID uuid.UUID, sha256Hex string) string {
+	return url.PathEscape(tenantID) + "/" + resourceID.String() + "/" + sha256Hex
+}
+
+// UploadResourceBlob stores the content read from r as the blob of the resource and returns the updated resource.
+// The content is spooled to a temporary file first, its size and checksum have to be known before it is stored.
+func (s *Service) UploadResourceBlob(ctx context.Context, resourceID uuid.UUID, r io.Reader, contentType string) (*models.Resource, error) {
+	log.Debug(ctx, "Uploading resource blob")
+
+	current, err := s.GetResourceByID(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ioutil.TempFile("", "resource-blob-")
+	if err != nil {
+		return nil, myerrors.WithFields(errors.WithStack(err), models.HTTPCode, http.StatusInternalServerError)
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(r, s.maxBlobSize+1))
+	if err != nil {
+		return nil, myerrors.WithFields(errors.Wrap(err, "cannot read the upload"), models.HTTPCode, http.StatusBadRequest)
+	}
+	if size > s.maxBlobSize {
+		return nil, myerrors.WithFields(errors.WithStack(ErrBlobTooLarge), models.HTTPCode, http.StatusRequestEntityTooLarge)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, myerrors.WithFields(errors.WithStack(err), models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	newBlob := &models.Blob{
+		Size:        size,
+		ContentType: contentType,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}
+
+	tenantID := auth.TenantID(ctx)
+	key := blobKey(tenantID, resourceID, newBlob.SHA256)
+	if err := s.blobs.Put(ctx, key, file, newBlob.Size, newBlob.ContentType, newBlob.SHA256); err != nil {
+		return nil, myerrors.WithFields(errors.Wrap(err, "blob store error"), models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	before, after, err := s.mySQLStorage.SetResourceBlob(ctx, resourceID, newBlob, fmt.Sprintf(blobLocationFormat, resourceID))
+	if err != nil {
+		// the same content may already be the blob of the resource, that one must stay
+		if current.Blob == nil || current.Blob.SHA256 != newBlob.SHA256 {
+			s.deleteBlob(ctx, key)
+		}
+		if err.Error() == storage.ErrResourceNotFound.Error() {
+			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusAccepted)
+		}
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	if before.Blob != nil && before.Blob.SHA256 != newBlob.SHA256 {
+		s.deleteBlob(ctx, blobKey(tenantID, resourceID, before.Blob.SHA256))
+	}
+
+	return after, nil
+}
+
+// OpenResourceBlob returns the resource with a reader of its blob, the reader has to be closed.
+func (s *Service) OpenResourceBlob(ctx context.Context, resourceID uuid.UUID) (*models.Resource, *blob.Reader, error) {
+	log.Debug(ctx, "Opening resource blob")
+
+	resource, err := s.GetResourceByID(ctx, resourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resource.Blob == nil {
+		return nil, nil, myerrors.WithFields(errors.WithStack(ErrBlobNotFound), models.HTTPCode, http.StatusNotFound)
+	}
+
+	key := blobKey(auth.TenantID(ctx), resourceID, resource.Blob.SHA256)
+	reader := blob.NewReader(ctx, s.blobs, key, resource.Blob.Size)
+	if err := reader.Open(); err != nil {
+		return nil, nil, myerrors.WithFields(errors.Wrap(err, "blob store error"), models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return resource, reader, nil
+}
+
+// deleteResourceBlobs removes the blobs of the deleted resources.
+func (s *Service) deleteResourceBlobs(ctx context.Context, resources []models.Resource) {
+	tenantID := auth.TenantID(ctx)
+	for _, resource := range resources {
+		if resource.Blob != nil {
+			s.deleteBlob(ctx, blobKey(tenantID, resource.ID, resource.Blob.SHA256))
+		}
+	}
+}
+
+// deleteBlob removes a blob no resource refers to. A failure leaves an orphaned blob behind, it is only logged.
+func (s *Service) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		log.Warn(ctx, "Cannot delete blob", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+const fileScheme = "file://"
+
+// VerifyResources checks the files of up to limit resources not verified since checkedBefore and records the results.
+// The blobs are read from the blob store, other locations are verified as local files under the configured root.
+// Resources that cannot be verified are only marked as checked, so a run always moves on to the next ones.
+func (s *Service) VerifyResources(ctx context.Context, checkedBefore time.Time, limit int) ([]models.IntegrityReport, error) {
+	resources, err := s.mySQLStorage.GetResourcesToVerify(ctx, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]models.IntegrityReport, 0, len(resources))
+	for i := range resources {
+		resource := &resources[i]
+		tenantCtx := auth.WithIdentity(ctx, auth.Identity{TenantID: resource.TenantID})
+
+		report := models.IntegrityReport{
+			TenantID:   resource.TenantID,
+			ResourceID: resource.ID,
+			Location:   resource.Content[models.LocationKey],
+			Integrity:  resource.Integrity,
+		}
+
+		integrity, err := s.verifyResource(tenantCtx, &resource.Resource)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return reports, ctx.Err()
+			}
+			report.Error = err.Error()
+		case integrity != nil:
+			report.Integrity = integrity
+		}
+
+		if err := s.mySQLStorage.SetResourceIntegrity(tenantCtx, resource.ID, resource.UpdatedAt, report.Integrity, time.Now()); err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// verifyResource returns the integrity of the file of the resource, or nil if it has no file that can be verified.
+func (s *Service) verifyResource(ctx context.Context, resource *models.Resource) (*models.Integrity, error) {
+	if resource.Blob != nil {
+		body, err := s.blobs.Open(ctx, blobKey(auth.TenantID(ctx), resource.ID, resource.Blob.SHA256), 0)
+		if err != nil {
+			if errors.Cause(err) == blob.ErrNotFound {
+				return newIntegrity(models.IntegrityMissing, "the blob is missing from the blob store"), nil
+			}
+			return nil, errors.Wrap(err, "blob store error")
+		}
+		defer func() {
+			_ = body.Close()
+		}()
+		return checkContent(body, resource.Blob.SHA256, &resource.Blob.Size)
+	}
+
+	path, ok := s.localPath(resource.Content[models.LocationKey])
+	if !ok {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newIntegrity(models.IntegrityMissing, "the file does not exist"), nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return checkContent(file, resource.Checksum, resource.Size)
+}
+
+// localPath returns the local file of the location, locations with a URL scheme other than file://
+// and paths outside of the root cannot be verified.
+func (s *Service) localPath(location string) (string, bool) {
+	if s.localFileRoot == "" || location == "" {
+		return "", false
+	}
+
+	location = strings.TrimPrefix(location, fileScheme)
+	if strings.Contains(location, "://") {
+		return "", false
+	}
+
+	path := filepath.Join(s.localFileRoot, filepath.FromSlash(location))
+	rel, err := filepath.Rel(s.localFileRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, true
+}
+
+// checkContent compares the content read from r with the expected checksum and size, either of them may be unknown.
+func checkContent(r io.Reader, checksum string, size *int64) (*models.Integrity, error) {
+	hash := sha256.New()
+	n, err := io.Copy(hash, r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if size != nil && n != *size {
+		return newIntegrity(models.IntegrityMismatch, fmt.Sprintf("the size is %d instead of %d", n, *size)), nil
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); checksum != "" && !strings.EqualFold(sum, checksum) {
+		return newIntegrity(models.IntegrityMismatch, fmt.Sprintf("the checksum is %s instead of %s", sum, strings.ToLower(checksum))), nil
+	}
+	return newIntegrity(models.IntegrityOK, ""), nil
+}
+
+func newIntegrity(status string, detail string) *models.Integrity {
+	return &models.Integrity{
+		Status:    status,
+		Detail:    detail,
+		CheckedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// logIntegrityReports logs the resources found broken or not verifiable.
+func logIntegrityReports(ctx context.Context, reports []models.IntegrityReport) {
+	for _, report := range reports {
+		switch {
+		case report.Error != "":
+			log.Warn(ctx, "Cannot verify resource", "tenant_id", report.TenantID, "resource_id", report.ResourceID, "error", report.Error)
+		case report.Integrity.Broken():
+			log.Warn(ctx, "Broken resource", "tenant_id", report.TenantID, "resource_id", report.ResourceID,
+				"location", report.Location, "status", report.Integrity.Status, "detail", report.Integrity.Detail)
+		}
+	}
+}
+
+// VerifyOutdatedResources verifies the resources not verified within maxAge, batchSize at a time,
+// and logs the broken ones. It is the job of the background verifier.
+func (s *Service) VerifyOutdatedResources(ctx context.Context, maxAge time.Duration, batchSize int) error {
+	checkedBefore := time.Now().Add(-maxAge)
+	for {
+		reports, err := s.VerifyResources(ctx, checkedBefore, batchSize)
+		logIntegrityReports(ctx, reports)
+		if err != nil {
+			return err
+		}
+
+		if len(reports) > 0 {
+			log.Debug(ctx, "Resources verified", "count", len(reports))
+		}
+
+		if len(reports) < batchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
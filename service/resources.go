@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -16,7 +17,13 @@ import (
 
 func (s *Service) AddResource(ctx context.Context, resource *models.Resource) (*models.Resource, error) {
 	// Execute function
-	if err := s.mySQLStorage.AddResource(resource); err != nil {
+	if err := s.mySQLStorage.AddResource(ctx, resource); err != nil {
+		switch err.Error() {
+		case storage.ErrTenantQuotaExceeded.Error(), storage.ErrTenantNotFound.Error():
+			return nil, myerrors.WithFields(errors.Wrap(err, "mysql error"), models.HTTPCode, http.StatusForbidden)
+		case storage.ErrCategoryNotFound.Error(), storage.ErrInvalidSchedule.Error():
+			return nil, myerrors.WithFields(errors.Wrap(err, "mysql error"), models.HTTPCode, http.StatusBadRequest)
+		}
 		return nil, myerrors.WithFields(errors.Wrap(err, "mysql error"), models.HTTPCode, http.StatusInternalServerError)
 	}
 
@@ -26,7 +33,7 @@ func (s *Service) AddResource(ctx context.Context, resource *models.Resource) (*
 func (s *Service) GetResourceByID(ctx context.Context, resourceID uuid.UUID) (*models.Resource, error) {
 	log.Debug(ctx, "Getting resource by id")
 
-	resource, err := s.mySQLStorage.GetResourceByID(resourceID)
+	resource, err := s.mySQLStorage.GetResourceByID(ctx, resourceID)
 	if err != nil {
 		if err.Error() == storage.ErrResourceNotFound.Error() {
 			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusAccepted)
@@ -40,9 +47,14 @@ func (s *Service) GetResourceByID(ctx context.Context, resourceID uuid.UUID) (*m
 func (s *Service) UpdateResource(ctx context.Context, resource *models.Resource) error {
 	log.Debug(ctx, "Updating resource")
 
-	if err := s.mySQLStorage.UpdateResource(resource); err != nil {
-		if err.Error() == storage.ErrResourceNotFound.Error() {
+	if err := s.mySQLStorage.UpdateResource(ctx, resource); err != nil {
+		switch err.Error() {
+		case storage.ErrResourceNotFound.Error():
 			return myerrors.WithFields(err, models.HTTPCode, http.StatusAccepted)
+		case storage.ErrTenantQuotaExceeded.Error(), storage.ErrTenantNotFound.Error():
+			return myerrors.WithFields(err, models.HTTPCode, http.StatusForbidden)
+		case storage.ErrCategoryNotFound.Error(), storage.ErrInvalidSchedule.Error():
+			return myerrors.WithFields(err, models.HTTPCode, http.StatusBadRequest)
 		}
 		return myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
 	}
@@ -53,14 +65,16 @@ func (s *Service) UpdateResource(ctx context.Context, resource *models.Resource)
 func (s *Service) DeleteResource(ctx context.Context, req *httpModels.DeleteResourceRequest) error {
 	log.Debug(ctx, "Deleting resource")
 
-	if err := s.mySQLStorage.DeleteResource(req.ID, req.Content); err != nil {
+	deleted, err := s.mySQLStorage.DeleteResource(ctx, req.ID, req.Content)
+	if err != nil {
 		if err.Error() == storage.ErrResourceNotFound.Error() {
 			return myerrors.WithFields(err, models.HTTPCode, http.StatusAccepted)
 		}
 		return myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
 	}
+	s.deleteResourceBlobs(ctx, deleted)
 
-	_, err := s.mySQLStorage.GetResourceByID(req.ID)
+	_, err = s.mySQLStorage.GetResourceByID(ctx, req.ID)
 	if err != nil && err.Error() != storage.ErrResourceNotFound.Error() {
 		return myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
 	}
@@ -71,8 +85,11 @@ func (s *Service) DeleteResource(ctx context.Context, req *httpModels.DeleteReso
 func (s *Service) GetCategories(ctx context.Context) ([]models.Category, error) {
 	log.Debug(ctx, "Getting categories")
 
-	categories, err := s.mySQLStorage.GetCategories()
+	categories, err := s.mySQLStorage.GetCategories(ctx)
 	if err != nil {
+		if err.Error() == storage.ErrTenantNotFound.Error() {
+			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusForbidden)
+		}
 		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
 	}
 
@@ -82,7 +99,7 @@ func (s *Service) GetCategories(ctx context.Context) ([]models.Category, error)
 func (s *Service) GetResourcesByCategory(ctx context.Context, req *httpModels.GetResourcesByCategoryRequest) ([]models.Resource, error) {
 	log.Debug(ctx, "Getting multiple resources by category")
 
-	resources, err := s.mySQLStorage.GetResourcesByCategory(req.Category)
+	resources, err := s.mySQLStorage.GetResourcesByCategory(ctx, req.Category, resourceFilter(&req.ResourceListRequest))
 	if err != nil {
 		if err.Error() == storage.ErrResourceNotFound.Error() {
 			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusAccepted)
@@ -93,8 +110,12 @@ func (s *Service) GetResourcesByCategory(ctx context.Context, req *httpModels.Ge
 	return resources, nil
 }
 
-func (s *Service) GetResourcesByIDs(_ context.Context, req *httpModels.GetResourcesByIDsRequest) ([]models.Resource, error) {
-	resources, err := s.mySQLStorage.GetResourcesByIDs(req.UUIDs)
+func (s *Service) GetResourcesByIDs(ctx context.Context, req *httpModels.GetResourcesByIDsRequest) ([]models.Resource, error) {
+	if len(req.UUIDs) > httpModels.MaxResourceIDs {
+		return nil, myerrors.WithFields(errors.WithStack(ErrLookupTooLarge), models.HTTPCode, http.StatusBadRequest)
+	}
+
+	resources, err := s.mySQLStorage.GetResourcesByIDs(ctx, req.UUIDs, resourceFilter(&req.ResourceListRequest))
 	if err != nil {
 		if err.Error() == storage.ErrResourceNotFound.Error() {
 			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusAccepted)
@@ -104,3 +125,108 @@ func (s *Service) GetResourcesByIDs(_ context.Context, req *httpModels.GetResour
 
 	return resources, nil
 }
+
+// ErrLookupTooLarge is returned for the lookups of more than httpModels.MaxResourceIDs resources,
+// by id or with the attachments included.
+var ErrLookupTooLarge = errors.New("The lookup exceeds the maximum number of resources")
+
+// LookupResources returns the resources with the requested IDs in their order and the IDs not found,
+// with the attachments of the resources found if they are requested.
+func (s *Service) LookupResources(ctx context.Context, req *httpModels.LookupResourcesRequest) (*httpModels.LookupResourcesResponse, error) {
+	if len(req.IDs) > httpModels.MaxResourceIDs {
+		return nil, myerrors.WithFields(errors.WithStack(ErrLookupTooLarge), models.HTTPCode, http.StatusBadRequest)
+	}
+
+	filter := &models.ResourceFilter{IncludeHidden: req.IncludeHidden}
+	ids := uniqueIDs(req.IDs)
+	found, err := s.lookupResources(ctx, ids, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &httpModels.LookupResourcesResponse{
+		Resources: make([]models.Resource, 0, len(found)),
+		Missing:   make([]uuid.UUID, 0),
+	}
+	for _, id := range ids {
+		if resource, ok := found[id]; ok {
+			resp.Resources = append(resp.Resources, resource)
+			continue
+		}
+		resp.Missing = append(resp.Missing, id)
+	}
+	if !req.IncludeAttachments {
+		return resp, nil
+	}
+
+	attachmentIDs := make([]uuid.UUID, 0)
+	for _, resource := range resp.Resources {
+		for key := range resource.Content {
+			if key == models.LocationKey {
+				continue
+			}
+			if id, err := uuid.Parse(key); err == nil {
+				attachmentIDs = append(attachmentIDs, id)
+			}
+		}
+	}
+	attachmentIDs = uniqueIDs(attachmentIDs)
+	if len(ids)+len(attachmentIDs) > httpModels.MaxResourceIDs {
+		return nil, myerrors.WithFields(errors.WithStack(ErrLookupTooLarge), models.HTTPCode, http.StatusBadRequest)
+	}
+	attachments, err := s.lookupResources(ctx, attachmentIDs, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Attachments = make(map[string]models.Resource, len(attachments))
+	for id, attachment := range attachments {
+		resp.Attachments[id.String()] = attachment
+	}
+	return resp, nil
+}
+
+// lookupResources returns the resources found with the ids by their ID.
+func (s *Service) lookupResources(ctx context.Context, ids []uuid.UUID, filter *models.ResourceFilter) (map[uuid.UUID]models.Resource, error) {
+	found := make(map[uuid.UUID]models.Resource, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	resources, err := s.mySQLStorage.GetResourcesByIDs(ctx, ids, filter)
+	if err != nil {
+		if err.Error() == storage.ErrResourceNotFound.Error() {
+			return found, nil
+		}
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	for _, resource := range resources {
+		found[resource.ID] = resource
+	}
+	return found, nil
+}
+
+// uniqueIDs returns the ids without the repeated ones, in the order they first appear.
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func resourceFilter(req *httpModels.ResourceListRequest) *models.ResourceFilter {
+	return &models.ResourceFilter{
+		SortBy:    strings.TrimPrefix(req.Sort, "-"),
+		Ascending: req.Sort != "" && !strings.HasPrefix(req.Sort, "-"),
+		Since:     req.Since,
+		Until:     req.Until,
+
+		IncludeHidden: req.IncludeHidden,
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/proemergotech/log/v3"
+)
+
+// EmitScheduledResourceEvents announces the resources published or expired since the last run, batchSize at a time.
+func (s *Service) EmitScheduledResourceEvents(ctx context.Context, batchSize int) error {
+	for {
+		processed, err := s.mySQLStorage.ProcessScheduledResources(ctx, time.Now(), batchSize)
+		if err != nil {
+			return err
+		}
+
+		if processed > 0 {
+			log.Debug(ctx, "Scheduled resource events emitted", "count", processed)
+		}
+
+		if processed == 0 || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
@@ -1,15 +1,26 @@
 package service
 
 import (
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
 	"github.com/artofimagination/mysql-resources-db-go-service/storage"
 )
 
 type Service struct {
-	mySQLStorage *storage.MySQL
+	mySQLStorage storage.Storage
+	blobs        blob.Store
+	maxBlobSize  int64
+	// localFileRoot is the directory the local file locations are verified in, empty skips them.
+	localFileRoot string
+	// allowPrivateWebhooks accepts the webhook subscriptions to loopback, link-local and private addresses.
+	allowPrivateWebhooks bool
 }
 
-func NewService(mySQLStorage *storage.MySQL) *Service {
+func NewService(mySQLStorage storage.Storage, blobs blob.Store, maxBlobSize int64, localFileRoot string, allowPrivateWebhooks bool) *Service {
 	return &Service{
-		mySQLStorage: mySQLStorage,
+		mySQLStorage:         mySQLStorage,
+		blobs:                blobs,
+		maxBlobSize:          maxBlobSize,
+		localFileRoot:        localFileRoot,
+		allowPrivateWebhooks: allowPrivateWebhooks,
 	}
 }
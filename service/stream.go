@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+)
+
+const changeEventsBatchSize = 100
+
+// SequenceChangeEvents appends the committed change events to the change log, the readers see them from then on.
+func (s *Service) SequenceChangeEvents(ctx context.Context) error {
+	for {
+		sequenced, err := s.mySQLStorage.SequenceChangeEvents(ctx, changeEventsBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if sequenced > 0 {
+			log.Debug(ctx, "Change events sequenced", "count", sequenced)
+		}
+
+		if sequenced < changeEventsBatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// GetLatestChangeLogPosition returns the position of the newest change event. The committed events are sequenced first,
+// so a reader starting here skips every change committed before it asked.
+func (s *Service) GetLatestChangeLogPosition(ctx context.Context) (int64, error) {
+	if err := s.SequenceChangeEvents(ctx); err != nil {
+		return 0, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	position, err := s.mySQLStorage.GetLatestChangeLogPosition(ctx)
+	if err != nil {
+		return 0, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return position, nil
+}
+
+// scheduledEventTypes are the change events of a resource that are hidden with it by its schedule,
+// the resource.published event announces the resource once it is visible.
+var scheduledEventTypes = map[string]bool{
+	models.EventResourceCreated: true,
+	models.EventResourceUpdated: true,
+	models.EventResourceDeleted: true,
+}
+
+// GetResourceChangeEvents returns the resource change events following afterPosition, limited to category unless it is 0.
+// The events of the resources the schedule hides when they are read are left out, unless includeHidden is set.
+// The second return value is the position the next read has to continue after, it moves past the filtered out events as well.
+func (s *Service) GetResourceChangeEvents(ctx context.Context, afterPosition int64, category int, includeHidden bool) ([]models.ChangeEvent, int64, error) {
+	events, err := s.mySQLStorage.GetChangeEvents(ctx, models.AggregateResource, afterPosition, changeEventsBatchSize)
+	if err != nil {
+		return nil, afterPosition, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	filtered := make([]models.ChangeEvent, 0, len(events))
+	for _, event := range events {
+		afterPosition = event.Position
+
+		resource := &models.Resource{}
+		if err := json.Unmarshal(event.Payload, resource); err != nil {
+			return nil, afterPosition, myerrors.WithFields(errors.Wrap(err, "invalid change event payload"), models.HTTPCode, http.StatusInternalServerError)
+		}
+		if category != 0 && resource.Category != category {
+			continue
+		}
+		if !includeHidden && scheduledEventTypes[event.Type] && resource.Hidden(now) {
+			continue
+		}
+
+		filtered = append(filtered, event)
+	}
+
+	return filtered, afterPosition, nil
+}
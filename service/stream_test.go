@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proemergotech/log/v3"
+	"github.com/proemergotech/log/v3/zaplog"
+	"go.uber.org/zap"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/blob"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+)
+
+type emptyContextMapper struct{}
+
+func (emptyContextMapper) Values(context.Context) map[string]string {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	log.SetGlobalLogger(zaplog.NewLogger(zap.NewNop(), emptyContextMapper{}))
+	os.Exit(m.Run())
+}
+
+func TestGetResourceChangeEventsSchedule(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{TenantID: auth.DefaultTenantID})
+
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "resources.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	st := storage.NewSQLite(db, 0, nil)
+	if err := st.BootstrapSystem(""); err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService(st, blobs, 1<<20, "", false)
+
+	start, err := svc.GetLatestChangeLogPosition(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	categories, err := svc.GetCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publishAt := time.Now().Add(time.Hour).UTC()
+	embargoed := &models.Resource{
+		ID:        uuid.New(),
+		Category:  categories[0].ID,
+		Content:   models.ContentMap{models.LocationKey: "https://example.com/embargoed"},
+		PublishAt: &publishAt,
+	}
+	if _, err := svc.AddResource(ctx, embargoed); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SequenceChangeEvents(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	events, position, err := svc.GetResourceChangeEvents(ctx, start, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %v, want the change of the embargoed resource left out", events)
+	}
+	if position <= start {
+		t.Errorf("position = %d, want past the left out event after %d", position, start)
+	}
+
+	events, _, err = svc.GetResourceChangeEvents(ctx, start, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != models.EventResourceCreated || events[0].AggregateID != embargoed.ID.String() {
+		t.Errorf("events = %v, want the creation of the embargoed resource with include_hidden", events)
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+)
+
+// maxTenantIDLength is the size of the tenant id columns.
+const maxTenantIDLength = 64
+
+var ErrInvalidTenantID = errors.New("The tenant id has to be 1 to 64 characters long")
+
+// AddTenant provisions a tenant, the requests of a tenant are refused until it is added.
+// A nil resourceQuota leaves the tenant on the default quota.
+func (s *Service) AddTenant(ctx context.Context, tenantID string, resourceQuota *int) error {
+	log.Debug(ctx, "Adding tenant", "tenant_id", tenantID)
+
+	if tenantID == "" || len(tenantID) > maxTenantIDLength {
+		return myerrors.WithFields(errors.WithStack(ErrInvalidTenantID), models.HTTPCode, http.StatusBadRequest)
+	}
+
+	if err := s.mySQLStorage.AddTenant(ctx, tenantID, resourceQuota); err != nil {
+		if err.Error() == storage.ErrTenantAlreadyExists.Error() {
+			return myerrors.WithFields(err, models.HTTPCode, http.StatusConflict)
+		}
+		return myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	httpModels "github.com/artofimagination/mysql-resources-db-go-service/models/http"
+	"github.com/artofimagination/mysql-resources-db-go-service/models/myerrors"
+	"github.com/artofimagination/mysql-resources-db-go-service/storage"
+	"github.com/artofimagination/mysql-resources-db-go-service/webhook"
+)
+
+// defaultDeadLettersLimit is the page size used when the request does not set one.
+const defaultDeadLettersLimit = 100
+
+var ErrWebhookSecretRequired = errors.New("The webhook secret is required")
+
+func (s *Service) CreateWebhookSubscription(ctx context.Context, req *httpModels.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	log.Debug(ctx, "Creating webhook subscription")
+
+	if req.Secret == "" {
+		return nil, myerrors.WithFields(ErrWebhookSecretRequired, models.HTTPCode, http.StatusBadRequest)
+	}
+	if err := s.checkWebhookTarget(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        req.URL,
+		Categories: req.Categories,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+		Active:     req.Active == nil || *req.Active,
+	}
+
+	if err := s.mySQLStorage.AddWebhookSubscription(ctx, subscription); err != nil {
+		if err.Error() == storage.ErrTenantNotFound.Error() {
+			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusForbidden)
+		}
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return subscription, nil
+}
+
+// checkWebhookTarget rejects the subscription URLs of loopback, link-local and private addresses, unless they are allowed.
+// They would let the subscribers reach the services behind the firewall through the deliveries.
+func (s *Service) checkWebhookTarget(ctx context.Context, url string) error {
+	if s.allowPrivateWebhooks {
+		return nil
+	}
+	if err := webhook.CheckTarget(ctx, net.DefaultResolver, url); err != nil {
+		return myerrors.WithFields(err, models.HTTPCode, http.StatusBadRequest)
+	}
+	return nil
+}
+
+func (s *Service) GetWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	log.Debug(ctx, "Getting webhook subscriptions")
+
+	subscriptions, err := s.mySQLStorage.GetWebhookSubscriptions(ctx, false)
+	if err != nil {
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return subscriptions, nil
+}
+
+func (s *Service) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	log.Debug(ctx, "Getting webhook subscription")
+
+	subscription, err := s.mySQLStorage.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		if err.Error() == storage.ErrWebhookSubscriptionNotFound.Error() {
+			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusNotFound)
+		}
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return subscription, nil
+}
+
+// UpdateWebhookSubscription replaces the settings of a subscription, the secret is kept if the request has none.
+func (s *Service) UpdateWebhookSubscription(ctx context.Context, req *httpModels.UpdateWebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	log.Debug(ctx, "Updating webhook subscription")
+
+	subscription, err := s.GetWebhookSubscription(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWebhookTarget(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	subscription.URL = req.URL
+	subscription.Categories = req.Categories
+	subscription.EventTypes = req.EventTypes
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	if err := s.mySQLStorage.UpdateWebhookSubscription(ctx, subscription); err != nil {
+		if err.Error() == storage.ErrWebhookSubscriptionNotFound.Error() {
+			return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusNotFound)
+		}
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return s.GetWebhookSubscription(ctx, req.ID)
+}
+
+func (s *Service) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	log.Debug(ctx, "Deleting webhook subscription")
+
+	if err := s.mySQLStorage.DeleteWebhookSubscription(ctx, id); err != nil {
+		if err.Error() == storage.ErrWebhookSubscriptionNotFound.Error() {
+			return myerrors.WithFields(err, models.HTTPCode, http.StatusNotFound)
+		}
+		return myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (s *Service) GetWebhookDeadLetters(ctx context.Context, req *httpModels.GetWebhookDeadLettersRequest) ([]models.WebhookDelivery, error) {
+	log.Debug(ctx, "Getting webhook dead letters")
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDeadLettersLimit
+	}
+
+	deliveries, err := s.mySQLStorage.GetDeadWebhookDeliveries(ctx, req.SubscriptionID, limit)
+	if err != nil {
+		return nil, myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Service) ReplayWebhookDelivery(ctx context.Context, id int64) error {
+	log.Debug(ctx, "Replaying webhook delivery")
+
+	if err := s.mySQLStorage.ReplayWebhookDelivery(ctx, id); err != nil {
+		if err.Error() == storage.ErrWebhookDeliveryNotFound.Error() {
+			return myerrors.WithFields(err, models.HTTPCode, http.StatusNotFound)
+		}
+		return myerrors.WithFields(err, models.HTTPCode, http.StatusInternalServerError)
+	}
+
+	return nil
+}
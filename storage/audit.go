@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+	"github.com/artofimagination/mysql-resources-db-go-service/requestinfo"
+)
+
+const addAuditEventQuery = `
+	INSERT INTO
+	audit_events(tenant_id, actor, request_id, client_ip, operation, resource_id, diff, created_at)
+	VALUES
+	(?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, UUID_TO_BIN(?), CAST(CONVERT(? USING utf8) AS JSON), ?)
+`
+
+// addAuditEvent records the mutation of a resource in the transaction of the mutation itself,
+// so an audit event exists if and only if the change was committed.
+func addAuditEvent(ctx context.Context, tenantID string, operation string, before *models.Resource, after *models.Resource, tx *transaction) error {
+	resource := after
+	if resource == nil {
+		resource = before
+	}
+
+	info := requestinfo.FromContext(ctx)
+	diff := models.AuditDiff{
+		Before: before,
+		After:  after,
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		addAuditEventQuery,
+		tenantID,
+		ownerFromContext(ctx),
+		info.RequestID,
+		info.ClientIP,
+		operation,
+		resource.ID,
+		diff,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	return nil
+}
+
+const getAuditEventsQuery = `
+	SELECT id, COALESCE(actor, ''), COALESCE(request_id, ''), COALESCE(client_ip, ''), operation, BIN_TO_UUID(resource_id), diff, created_at
+	FROM audit_events
+	WHERE tenant_id = ?
+`
+
+func (mySQL *MySQL) GetAuditEvents(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEvent, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var query strings.Builder
+	query.WriteString(getAuditEventsQuery)
+	args := []interface{}{tenantID}
+
+	if filter.ResourceID != nil {
+		query.WriteString(" AND resource_id = UUID_TO_BIN(?)")
+		args = append(args, *filter.ResourceID)
+	}
+	if filter.Actor != "" {
+		query.WriteString(" AND actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Since != nil {
+		query.WriteString(" AND created_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		query.WriteString(" AND created_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+	query.WriteString(" ORDER BY id DESC LIMIT ? OFFSET ?")
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := mySQL.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		event := models.AuditEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.Actor,
+			&event.RequestID,
+			&event.ClientIP,
+			&event.Operation,
+			&event.ResourceID,
+			&event.Diff,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		events = append(events, event)
+	}
+
+	return events, errors.WithStack(rows.Err())
+}
+
+const deleteAuditEventsQuery = `
+	DELETE FROM audit_events
+	WHERE created_at < ?
+	LIMIT ?
+`
+
+// auditDeleteBatchSize keeps the retention cleanup from locking the table for long.
+const auditDeleteBatchSize = 1000
+
+// DeleteAuditEventsBefore removes the audit events of every tenant that were recorded before the given time.
+// This is the only way audit events are ever removed.
+func (mySQL *MySQL) DeleteAuditEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for {
+		result, err := mySQL.db.ExecContext(ctx, deleteAuditEventsQuery, before.UTC(), auditDeleteBatchSize)
+		if err != nil {
+			return deleted, errors.WithStack(err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return deleted, errors.WithStack(err)
+		}
+
+		deleted += affected
+		if affected < auditDeleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
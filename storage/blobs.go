@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// SetResourceBlob records the blob uploaded to the resource and sets its location to where the blob is served.
+// It returns the resource before and after the change, the blob of the former is the one replaced by the upload.
+func (mySQL *MySQL) SetResourceBlob(ctx context.Context, id uuid.UUID, blob *models.Blob, location string) (*models.Resource, *models.Resource, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before, err := getResourceForUpdate(ctx, tenantID, id.String(), tx)
+	if err != nil {
+		if err == ErrResourcesMissing {
+			return nil, nil, ErrResourceNotFound
+		}
+		return nil, nil, err
+	}
+
+	after := *before
+	after.Blob = blob
+	after.Content = make(models.ContentMap, len(before.Content)+1)
+	for k, v := range before.Content {
+		after.Content[k] = v
+	}
+	after.Content[models.LocationKey] = location
+	// the new blob is verified again
+	after.Integrity = nil
+
+	if err := updateResource(ctx, tenantID, &after, tx); err != nil {
+		if err == ErrResourcesMissing {
+			return nil, nil, ErrResourceNotFound
+		}
+		return nil, nil, err
+	}
+
+	if err := recordResourceChange(ctx, tenantID, models.AuditOperationUpdate, before, &after, tx); err != nil {
+		return nil, nil, err
+	}
+
+	return before, &after, tx.Commit()
+}
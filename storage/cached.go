@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/cache"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// Cached serves the single resource lookups from the cache, every other call goes to the wrapped storage.
+// Lists are not cached, a change of any member would have to invalidate every list containing it.
+type Cached struct {
+	Storage
+	cache *cache.Cache
+}
+
+// NewCached wraps next with the read-through cache c.
+func NewCached(next Storage, c *cache.Cache) *Cached {
+	return &Cached{
+		Storage: next,
+		cache:   c,
+	}
+}
+
+func resourceCacheKey(tenantID string, id string) string {
+	return "resource:" + tenantID + ":" + id
+}
+
+func (c *Cached) GetResourceByID(ctx context.Context, ID uuid.UUID) (*models.Resource, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &models.Resource{}
+	err = c.cache.Fetch(ctx, resourceCacheKey(tenantID, ID.String()), resource, func(ctx context.Context) (interface{}, error) {
+		return c.Storage.GetResourceByID(ctx, ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (c *Cached) AddResource(ctx context.Context, resource *models.Resource) error {
+	defer c.invalidate(ctx, resource.ID, resource.Content)
+	return c.Storage.AddResource(ctx, resource)
+}
+
+func (c *Cached) UpdateResource(ctx context.Context, resource *models.Resource) error {
+	defer c.invalidate(ctx, resource.ID, resource.Content)
+	return c.Storage.UpdateResource(ctx, resource)
+}
+
+func (c *Cached) DeleteResource(ctx context.Context, id uuid.UUID, content models.ContentMap) ([]models.Resource, error) {
+	defer c.invalidate(ctx, id, content)
+	return c.Storage.DeleteResource(ctx, id, content)
+}
+
+func (c *Cached) SetResourceBlob(ctx context.Context, id uuid.UUID, blob *models.Blob, location string) (*models.Resource, *models.Resource, error) {
+	defer c.invalidate(ctx, id, nil)
+	return c.Storage.SetResourceBlob(ctx, id, blob, location)
+}
+
+func (c *Cached) SetResourceIntegrity(ctx context.Context, id uuid.UUID, updatedAt time.Time, integrity *models.Integrity, checkedAt time.Time) error {
+	defer c.invalidate(ctx, id, nil)
+	return c.Storage.SetResourceIntegrity(ctx, id, updatedAt, integrity, checkedAt)
+}
+
+// invalidate drops the resource and the attachments listed in its content.
+// It runs after the write, failed writes included, since a failure may still have been committed.
+func (c *Cached) invalidate(ctx context.Context, id uuid.UUID, content models.ContentMap) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	keys := make([]string, 0, len(content)+1)
+	keys = append(keys, resourceCacheKey(tenantID, id.String()))
+	for k := range content {
+		if k != models.LocationKey {
+			keys = append(keys, resourceCacheKey(tenantID, k))
+		}
+	}
+	c.cache.Invalidate(ctx, keys...)
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// cachedCategory keeps the fields of the category hidden from its JSON encoding.
+type cachedCategory struct {
+	models.Category
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func categoriesCacheKey(tenantID string) string {
+	return "categories:" + tenantID
+}
+
+// tenantCategories returns the categories of the tenant, the ones AddTenant provisions it with.
+// A tenant not provisioned yet has none, the failure keeps it out of the cache.
+// Categories are never changed through the API, so the cached list is only refreshed when it expires.
+func (mySQL *MySQL) tenantCategories(ctx context.Context, tenantID string) ([]models.Category, error) {
+	load := func(ctx context.Context) ([]models.Category, error) {
+		return mySQL.getCategories(ctx, tenantID)
+	}
+
+	if mySQL.categoryCache == nil {
+		return load(ctx)
+	}
+
+	var cached []cachedCategory
+	err := mySQL.categoryCache.Fetch(ctx, categoriesCacheKey(tenantID), &cached, func(ctx context.Context) (interface{}, error) {
+		categories, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cached := make([]cachedCategory, len(categories))
+		for i := range categories {
+			cached[i] = cachedCategory{Category: categories[i], UpdatedAt: categories[i].UpdatedAt}
+		}
+		return cached, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make([]models.Category, len(cached))
+	for i := range cached {
+		categories[i] = cached[i].Category
+		categories[i].UpdatedAt = cached[i].UpdatedAt
+	}
+	return categories, nil
+}
+
+func (mySQL *MySQL) getCategoryByName(ctx context.Context, tenantID string, name string) (*models.Category, error) {
+	categories, err := mySQL.tenantCategories(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range categories {
+		if categories[i].Name == name {
+			return &categories[i], nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
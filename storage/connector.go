@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Connector opens the MySQL connections of a pool, its password can be rotated while the pool is in use.
+type Connector struct {
+	lock       sync.Mutex
+	config     *mysql.Config
+	generation generation
+}
+
+func NewConnector(config *mysql.Config) *Connector {
+	return &Connector{config: config}
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.lock.Lock()
+	config := c.config.Clone()
+	opened := c.generation.current()
+	c.lock.Unlock()
+
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	conn, err := connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.generation.wrap(conn, opened), nil
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return &mysql.MySQLDriver{}
+}
+
+// SetPassword changes the password the new connections log in with, it reports whether it has changed.
+// The connections logged in with the old password are discarded when they are returned to the pool,
+// the idle ones have to be closed by recycling the pool.
+func (c *Connector) SetPassword(password string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.config.Passwd == password {
+		return false
+	}
+	c.config.Passwd = password
+	c.generation.next()
+	return true
+}
+
+// PostgreSQLConfig holds the connection settings of a PostgreSQL database.
+type PostgreSQLConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+	SSLMode  string
+}
+
+// dsn returns the connection string of the settings. The session runs in UTC, the PostgreSQL storage expects it.
+func (c *PostgreSQLConfig) dsn() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	settings := []string{
+		"host='" + quote.Replace(c.Host) + "'",
+		"port=" + strconv.Itoa(c.Port),
+		"user='" + quote.Replace(c.User) + "'",
+		"password='" + quote.Replace(c.Password) + "'",
+		"dbname='" + quote.Replace(c.DBName) + "'",
+		"sslmode='" + quote.Replace(c.SSLMode) + "'",
+		"timezone=UTC",
+	}
+	return strings.Join(settings, " ")
+}
+
+// PostgreSQLConnector opens the PostgreSQL connections of a pool, its password can be rotated while the pool is in use.
+type PostgreSQLConnector struct {
+	lock       sync.Mutex
+	config     PostgreSQLConfig
+	generation generation
+}
+
+func NewPostgreSQLConnector(config PostgreSQLConfig) *PostgreSQLConnector {
+	return &PostgreSQLConnector{config: config}
+}
+
+func (c *PostgreSQLConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.lock.Lock()
+	dsn := c.config.dsn()
+	opened := c.generation.current()
+	c.lock.Unlock()
+
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	conn, err := connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.generation.wrap(conn, opened), nil
+}
+
+func (c *PostgreSQLConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// SetPassword changes the password the new connections log in with, it reports whether it has changed.
+// The connections logged in with the old password are discarded when they are returned to the pool,
+// the idle ones have to be closed by recycling the pool.
+func (c *PostgreSQLConnector) SetPassword(password string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.config.Password == password {
+		return false
+	}
+	c.config.Password = password
+	c.generation.next()
+	return true
+}
+
+// generation counts the password rotations of a connector. The connections remember the generation they are opened in,
+// database/sql asks them whether they are still valid when they are returned to the pool, the ones of an older generation are not.
+type generation struct {
+	value uint64
+}
+
+func (g *generation) current() uint64 {
+	return atomic.LoadUint64(&g.value)
+}
+
+func (g *generation) next() {
+	atomic.AddUint64(&g.value, 1)
+}
+
+func (g *generation) wrap(conn driver.Conn, opened uint64) driver.Conn {
+	return &generationConn{Conn: conn, generation: g, opened: opened}
+}
+
+// generationConn is a connection of a generation. The optional interfaces of the driver connection are passed through,
+// the ones it does not implement fall back to what database/sql does without them.
+type generationConn struct {
+	driver.Conn
+	generation *generation
+	opened     uint64
+}
+
+// IsValid implements driver.Validator.
+func (c *generationConn) IsValid() bool {
+	if c.generation.current() != c.opened {
+		return false
+	}
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *generationConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *generationConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("the driver does not support transaction options")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin()
+}
+
+func (c *generationConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *generationConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *generationConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *generationConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *generationConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
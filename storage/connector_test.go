@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+
+	"modernc.org/sqlite"
+)
+
+// sqliteGenerationConnector opens SQLite connections of generations, like the MySQL and PostgreSQL connectors do.
+type sqliteGenerationConnector struct {
+	dsn        string
+	generation generation
+	opened     int
+}
+
+func (c *sqliteGenerationConnector) Connect(context.Context) (driver.Conn, error) {
+	opened := c.generation.current()
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.opened++
+	return c.generation.wrap(conn, opened), nil
+}
+
+func (c *sqliteGenerationConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// TestGenerationConnDiscardedAfterRotation checks that a connection in use while the password is rotated
+// is not put back to the pool, and that the statements work through the wrapped connections.
+func TestGenerationConnDiscardedAfterRotation(t *testing.T) {
+	ctx := context.Background()
+	connector := &sqliteGenerationConnector{dsn: "file:" + filepath.Join(t.TempDir(), "generation.db")}
+	db := sql.OpenDB(connector)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO items (id, name) VALUES (?, ?)", 1, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	connector.generation.next()
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if open := db.Stats().OpenConnections; open != 0 {
+		t.Fatalf("open connections = %d after the rotation, want 0", open)
+	}
+
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM items WHERE id = ?", 1).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "first" {
+		t.Errorf("name = %q, want first", name)
+	}
+	if connector.opened != 2 {
+		t.Errorf("opened %d connections, want 2", connector.opened)
+	}
+	if open := db.Stats().OpenConnections; open != 1 {
+		t.Errorf("open connections = %d, want the one of the new generation", open)
+	}
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/db/migrations"
+)
+
+// postgresDuplicateKeySubString and sqliteUniqueSubString are the parts of the unique violation errors
+// the duplicates are recognized by.
+const postgresDuplicateKeySubString = "duplicate key value"
+const sqliteUniqueSubString = "UNIQUE constraint failed"
+
+// dialect translates the queries of the storage, written for MySQL, to the SQL of the database it runs on.
+type dialect interface {
+	// migrationDialect is the name of the database in sql-migrate.
+	migrationDialect() string
+	rewrite(query string) string
+	isDuplicateEntry(err error) bool
+	// txOptions returns the options of a transaction supported by the database.
+	txOptions(opts *sql.TxOptions) *sql.TxOptions
+	// embeddedMigrations returns the migrations built into the binary.
+	embeddedMigrations() fs.FS
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) migrationDialect() string {
+	return "mysql"
+}
+
+func (mysqlDialect) rewrite(query string) string {
+	return query
+}
+
+func (mysqlDialect) isDuplicateEntry(err error) bool {
+	return strings.Contains(err.Error(), ErrDuplicateEntrySubString)
+}
+
+func (mysqlDialect) txOptions(opts *sql.TxOptions) *sql.TxOptions {
+	return opts
+}
+
+func (mysqlDialect) embeddedMigrations() fs.FS {
+	return migrations.MySQL()
+}
+
+// translations keeps the translated queries of a dialect, the queries are run over and over.
+type translations struct {
+	queries sync.Map
+}
+
+func (t *translations) get(query string, translate func(string) string) string {
+	if translated, ok := t.queries.Load(query); ok {
+		return translated.(string)
+	}
+
+	translated := translate(query)
+	t.queries.Store(query, translated)
+	return translated
+}
+
+// deleteAuditEventsLimitedQuery is deleteAuditEventsQuery for the databases that cannot limit a DELETE.
+const deleteAuditEventsLimitedQuery = `
+	DELETE FROM audit_events
+	WHERE id IN (SELECT id FROM audit_events WHERE created_at < ? LIMIT ?)
+`
+
+// postgresQueries replaces the queries MySQL and PostgreSQL have no common syntax for.
+// They are translated like the other queries, so they keep the MySQL placeholders and UUID functions.
+var postgresQueries = map[string]string{
+	addWebhookDeliveryQuery: `
+		INSERT INTO
+		webhook_deliveries(subscription_id, tenant_id, event_id, payload, status, next_attempt_at, created_at, updated_at)
+		VALUES
+		(UUID_TO_BIN(?), ?, ?, CAST(CONVERT(? USING utf8) AS JSON), ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`,
+	deleteAuditEventsQuery: deleteAuditEventsLimitedQuery,
+	// MySQL sorts the nulls first, the resources never verified are the ones waiting longest
+	getResourcesToVerifyQuery: `
+		SELECT BIN_TO_UUID(id), tenant_id, COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity
+		FROM resources
+		WHERE verified_at IS NULL OR verified_at < ?
+		ORDER BY verified_at NULLS FIRST, id
+		LIMIT ?
+	`,
+}
+
+var binToUUIDPattern = regexp.MustCompile(`BIN_TO_UUID\(([\w.]+)\)`)
+
+var postgresReplacer = strings.NewReplacer(
+	"UUID_TO_BIN(?)", "CAST(? AS uuid)",
+	"CAST(CONVERT(? USING utf8) AS JSON)", "CAST(? AS jsonb)",
+)
+
+// postgresDialect runs the queries on the native uuid and jsonb columns of PostgreSQL.
+type postgresDialect struct {
+	translations translations
+}
+
+func (d *postgresDialect) migrationDialect() string {
+	return "postgres"
+}
+
+func (d *postgresDialect) rewrite(query string) string {
+	return d.translations.get(query, func(query string) string {
+		if override, ok := postgresQueries[query]; ok {
+			query = override
+		}
+		// the ids are stored as uuid, there is nothing to convert when they are read
+		query = binToUUIDPattern.ReplaceAllString(query, "$1")
+		query = postgresReplacer.Replace(query)
+		return sqlx.Rebind(sqlx.DOLLAR, query)
+	})
+}
+
+func (d *postgresDialect) isDuplicateEntry(err error) bool {
+	return strings.Contains(err.Error(), postgresDuplicateKeySubString)
+}
+
+func (d *postgresDialect) txOptions(opts *sql.TxOptions) *sql.TxOptions {
+	return opts
+}
+
+func (d *postgresDialect) embeddedMigrations() fs.FS {
+	return migrations.PostgreSQL()
+}
+
+var forUpdatePattern = regexp.MustCompile(`\s+FOR UPDATE( OF \w+)?( SKIP LOCKED)?`)
+
+var sqliteReplacer = strings.NewReplacer(
+	"INSERT IGNORE", "INSERT OR IGNORE",
+	"UUID_TO_BIN(?)", "lower(?)",
+	"CAST(CONVERT(? USING utf8) AS JSON)", "json(CAST(? AS TEXT))",
+)
+
+// sqliteDialect keeps the ids as lower case text and the documents as JSON text.
+// SQLite has a single writer, the transactions take its lock when they begin instead of locking the rows they read.
+type sqliteDialect struct {
+	translations translations
+}
+
+func (d *sqliteDialect) migrationDialect() string {
+	return "sqlite3"
+}
+
+func (d *sqliteDialect) rewrite(query string) string {
+	return d.translations.get(query, func(query string) string {
+		if query == deleteAuditEventsQuery {
+			query = deleteAuditEventsLimitedQuery
+		}
+		query = binToUUIDPattern.ReplaceAllString(query, "$1")
+		query = forUpdatePattern.ReplaceAllString(query, "")
+		return sqliteReplacer.Replace(query)
+	})
+}
+
+func (d *sqliteDialect) isDuplicateEntry(err error) bool {
+	return strings.Contains(err.Error(), sqliteUniqueSubString)
+}
+
+// txOptions drops the isolation level, the transactions of SQLite are always serializable.
+func (d *sqliteDialect) txOptions(opts *sql.TxOptions) *sql.TxOptions {
+	return nil
+}
+
+func (d *sqliteDialect) embeddedMigrations() fs.FS {
+	return migrations.SQLite()
+}
+
+// database runs the queries of the storage on a database of the dialect.
+type database struct {
+	*sql.DB
+	dialect dialect
+}
+
+func (db *database) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, db.dialect.rewrite(query), args...)
+}
+
+func (db *database) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.QueryContext(ctx, db.dialect.rewrite(query), args...)
+}
+
+func (db *database) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRowContext(ctx, db.dialect.rewrite(query), args...)
+}
+
+func (db *database) BeginTx(ctx context.Context, opts *sql.TxOptions) (*transaction, error) {
+	tx, err := db.DB.BeginTx(ctx, db.dialect.txOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{Tx: tx, dialect: db.dialect}, nil
+}
+
+// transaction runs the queries of the storage in a transaction on a database of the dialect.
+type transaction struct {
+	*sql.Tx
+	dialect dialect
+}
+
+func (tx *transaction) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.ExecContext(ctx, tx.dialect.rewrite(query), args...)
+}
+
+func (tx *transaction) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(ctx, tx.dialect.rewrite(query), args...)
+}
+
+func (tx *transaction) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRowContext(ctx, tx.dialect.rewrite(query), args...)
+}
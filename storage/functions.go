@@ -1,19 +1,22 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
 	"github.com/artofimagination/mysql-resources-db-go-service/models"
 )
 
 var ErrResourcesMissing = errors.New("This resources is missing or old value is the same as new")
 
-func rollbackWithErrorStack(tx *sql.Tx, errorStack error) error {
+func rollbackWithErrorStack(tx *transaction, errorStack error) error {
 	if err := tx.Rollback(); err != nil {
 		errorString := fmt.Sprintf("%s\n%s\n", errorStack.Error(), err.Error())
 		return errors.Wrap(errors.WithStack(errors.New(errorString)), "Failed to rollback changes")
@@ -21,16 +24,104 @@ func rollbackWithErrorStack(tx *sql.Tx, errorStack error) error {
 	return errorStack
 }
 
+// tenantFromContext returns the tenant every query of the request has to be scoped by.
+func tenantFromContext(ctx context.Context) (string, error) {
+	tenantID := auth.TenantID(ctx)
+	if tenantID == "" {
+		return "", errors.WithStack(ErrTenantMissing)
+	}
+	return tenantID, nil
+}
+
+// ownerFromContext returns the owner recorded on the resources created by the request.
+func ownerFromContext(ctx context.Context) string {
+	identity, _ := auth.IdentityFromContext(ctx)
+	return identity.OwnerID
+}
+
+const getTenantQuotaQuery = `
+	SELECT resource_quota
+	FROM tenants
+	WHERE id = ?
+	FOR UPDATE
+`
+
+const countResourcesQuery = `
+	SELECT COUNT(*)
+	FROM resources
+	WHERE tenant_id = ?
+`
+
+// checkResourceQuota fails if adding newItems resources would exceed the quota of the tenant, or if the tenant is not provisioned.
+// The tenant row is locked until the end of the transaction so concurrent writers cannot overshoot the quota.
+func checkResourceQuota(ctx context.Context, tenantID string, newItems int, defaultQuota int, tx *transaction) error {
+	var quota sql.NullInt64
+	if err := tx.QueryRowContext(ctx, getTenantQuotaQuery, tenantID).Scan(&quota); err != nil {
+		if err == sql.ErrNoRows {
+			return rollbackWithErrorStack(tx, errors.WithStack(ErrTenantNotFound))
+		}
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	limit := int64(defaultQuota)
+	if quota.Valid {
+		limit = quota.Int64
+	}
+
+	if limit <= 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.QueryRowContext(ctx, countResourcesQuery, tenantID).Scan(&count); err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if count+int64(newItems) > limit {
+		return rollbackWithErrorStack(tx, errors.WithStack(ErrTenantQuotaExceeded))
+	}
+
+	return nil
+}
+
+const categoryExistsQuery = `
+	SELECT COUNT(*)
+	FROM categories
+	WHERE id = ? AND tenant_id = ?
+`
+
+func categoryExists(ctx context.Context, tenantID string, categoryID int, tx *transaction) error {
+	var count int
+	if err := tx.QueryRowContext(ctx, categoryExistsQuery, categoryID, tenantID).Scan(&count); err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if count == 0 {
+		return rollbackWithErrorStack(tx, errors.WithStack(ErrCategoryNotFound))
+	}
+
+	return nil
+}
+
 const addResourceQuery = `
-	INSERT INTO 
-	resources(id, category, content) 
-	VALUES 
-	(UUID_TO_BIN(?), ?, CAST(CONVERT(? USING utf8) AS JSON))
+	INSERT INTO
+	resources(id, tenant_id, owner_id, category, content, created_at, updated_at, publish_at, expire_at, publish_event_pending, expire_event_pending,
+		checksum, size)
+	VALUES
+	(UUID_TO_BIN(?), ?, NULLIF(?, ''), ?, CAST(CONVERT(? USING utf8) AS JSON), ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)
 `
 
-func addResource(resource *models.Resource, tx *sql.Tx) error {
+func addResource(ctx context.Context, tenantID string, resource *models.Resource, tx *transaction) error {
+	resource.CreatedAt = now()
+	resource.UpdatedAt = resource.CreatedAt
+	// blobs are only attached by uploads to existing resources, the integrity is set by the verification
+	resource.Blob = nil
+	resource.Integrity = nil
+	resource.Checksum = strings.ToLower(resource.Checksum)
 	// Execute transaction
-	_, err := tx.Exec(addResourceQuery, resource.ID, resource.Category, resource.Content)
+	publishPending, expirePending := schedulePending(resource, resource.CreatedAt)
+	_, err := tx.ExecContext(ctx, addResourceQuery, resource.ID, tenantID, resource.OwnerID, resource.Category, resource.Content, resource.CreatedAt, resource.UpdatedAt,
+		utcOrNil(resource.PublishAt), utcOrNil(resource.ExpireAt), publishPending, expirePending, resource.Checksum, resource.Size)
 	if err != nil {
 		return rollbackWithErrorStack(tx, errors.WithStack(err))
 	}
@@ -39,13 +130,23 @@ func addResource(resource *models.Resource, tx *sql.Tx) error {
 }
 
 const updateResourceQuery = `
-	UPDATE resources 
-	SET content = CAST(CONVERT(? USING utf8) AS JSON), category = ? 
-	WHERE id = UUID_TO_BIN(?)
+	UPDATE resources
+	SET content = CAST(CONVERT(? USING utf8) AS JSON), category = ?, updated_at = ?,
+		publish_at = ?, expire_at = ?, publish_event_pending = ?, expire_event_pending = ?,
+		blob_meta = CAST(CONVERT(? USING utf8) AS JSON), checksum = NULLIF(?, ''), size = ?,
+		integrity = CAST(CONVERT(? USING utf8) AS JSON), verified_at = CASE WHEN ? THEN verified_at ELSE NULL END
+	WHERE id = UUID_TO_BIN(?) AND tenant_id = ?
 `
 
-func updateResource(resource *models.Resource, tx *sql.Tx) error {
-	result, err := tx.Exec(updateResourceQuery, resource.Content, resource.Category, resource.ID)
+// updateResource writes the resource. A resource without integrity is queued for verification,
+// the callers drop the integrity when the change invalidates it.
+func updateResource(ctx context.Context, tenantID string, resource *models.Resource, tx *transaction) error {
+	resource.UpdatedAt = now()
+	resource.Checksum = strings.ToLower(resource.Checksum)
+	publishPending, expirePending := schedulePending(resource, resource.UpdatedAt)
+	result, err := tx.ExecContext(ctx, updateResourceQuery, resource.Content, resource.Category, resource.UpdatedAt,
+		utcOrNil(resource.PublishAt), utcOrNil(resource.ExpireAt), publishPending, expirePending, resource.Blob,
+		resource.Checksum, resource.Size, resource.Integrity, resource.Integrity != nil, resource.ID, tenantID)
 	if err != nil {
 		return rollbackWithErrorStack(tx, errors.WithStack(err))
 	}
@@ -63,22 +164,23 @@ func updateResource(resource *models.Resource, tx *sql.Tx) error {
 }
 
 const getResourceByIDQuery = `
-	SELECT BIN_TO_UUID(id), category, content 
-	FROM resources 
-	WHERE id = UUID_TO_BIN(?)
+	SELECT BIN_TO_UUID(id), COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity
+	FROM resources
+	WHERE id = UUID_TO_BIN(?) AND tenant_id = ?
 `
 
-func (mySQL *MySQL) getResourceByID(resourceID uuid.UUID) (*models.Resource, error) {
+func (mySQL *MySQL) getResourceByID(ctx context.Context, tenantID string, resourceID uuid.UUID) (*models.Resource, error) {
 	resource := &models.Resource{}
 
-	tx, err := mySQL.db.Begin()
+	tx, err := mySQL.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	result := tx.QueryRow(getResourceByIDQuery, resourceID)
+	result := tx.QueryRowContext(ctx, getResourceByIDQuery, resourceID, tenantID)
 
-	err = result.Scan(&resource.ID, &resource.Category, &resource.Content)
+	err = result.Scan(&resource.ID, &resource.OwnerID, &resource.Category, &resource.Content, &resource.CreatedAt, &resource.UpdatedAt, &resource.PublishAt, &resource.ExpireAt, &resource.Blob,
+		&resource.Checksum, &resource.Size, &resource.Integrity)
 	switch {
 	case err == sql.ErrNoRows:
 		if errRb := tx.Commit(); errRb != nil {
@@ -93,13 +195,39 @@ func (mySQL *MySQL) getResourceByID(resourceID uuid.UUID) (*models.Resource, err
 	return resource, tx.Commit()
 }
 
+const getResourceForUpdateQuery = `
+	SELECT BIN_TO_UUID(id), COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity
+	FROM resources
+	WHERE id = UUID_TO_BIN(?) AND tenant_id = ?
+	FOR UPDATE
+`
+
+// getResourceForUpdate reads the resource inside tx and locks it until the transaction ends.
+func getResourceForUpdate(ctx context.Context, tenantID string, resourceID string, tx *transaction) (*models.Resource, error) {
+	resource := &models.Resource{}
+
+	result := tx.QueryRowContext(ctx, getResourceForUpdateQuery, resourceID, tenantID)
+
+	err := result.Scan(&resource.ID, &resource.OwnerID, &resource.Category, &resource.Content, &resource.CreatedAt, &resource.UpdatedAt, &resource.PublishAt, &resource.ExpireAt, &resource.Blob,
+		&resource.Checksum, &resource.Size, &resource.Integrity)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, rollbackWithErrorStack(tx, ErrResourcesMissing)
+	case err != nil:
+		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+	default:
+	}
+
+	return resource, nil
+}
+
 const deleteResourceQuery = `
-	DELETE FROM resources 
-	WHERE id=UUID_TO_BIN(?)
+	DELETE FROM resources
+	WHERE id=UUID_TO_BIN(?) AND tenant_id = ?
 `
 
-func deleteResource(resourceID string, tx *sql.Tx) error {
-	result, err := tx.Exec(deleteResourceQuery, resourceID)
+func deleteResource(ctx context.Context, tenantID string, resourceID string, tx *transaction) error {
+	result, err := tx.ExecContext(ctx, deleteResourceQuery, resourceID, tenantID)
 	if err != nil {
 		return rollbackWithErrorStack(tx, errors.WithStack(err))
 	}
@@ -115,15 +243,18 @@ func deleteResource(resourceID string, tx *sql.Tx) error {
 	return nil
 }
 
-var GetResourcesByIDsQuery = "SELECT BIN_TO_UUID(id), category, content FROM resources WHERE id IN (UUID_TO_BIN(?)"
+var GetResourcesByIDsQuery = "SELECT BIN_TO_UUID(id), COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity FROM resources WHERE tenant_id = ? AND id IN (UUID_TO_BIN(?)"
 
-func getResourcesByIDs(IDs []uuid.UUID, tx *sql.Tx) ([]models.Resource, error) {
-	query := GetResourcesByIDsQuery + strings.Repeat(",UUID_TO_BIN(?)", len(IDs)-1) + ") ORDER BY created_at DESC"
-	interfaceList := make([]interface{}, len(IDs))
+func getResourcesByIDs(ctx context.Context, tenantID string, IDs []uuid.UUID, filter *models.ResourceFilter, tx *transaction) ([]models.Resource, error) {
+	clause, filterArgs := resourceFilterClause(filter)
+	query := GetResourcesByIDsQuery + strings.Repeat(",UUID_TO_BIN(?)", len(IDs)-1) + ")" + clause
+	interfaceList := make([]interface{}, 0, len(IDs)+len(filterArgs)+1)
+	interfaceList = append(interfaceList, tenantID)
 	for i := range IDs {
-		interfaceList[i] = IDs[i]
+		interfaceList = append(interfaceList, IDs[i])
 	}
-	rows, err := tx.Query(query, interfaceList...)
+	interfaceList = append(interfaceList, filterArgs...)
+	rows, err := tx.QueryContext(ctx, query, interfaceList...)
 	if err != nil {
 		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
 	}
@@ -135,7 +266,8 @@ func getResourcesByIDs(IDs []uuid.UUID, tx *sql.Tx) ([]models.Resource, error) {
 	resources := make([]models.Resource, 0)
 	for rows.Next() {
 		resource := models.Resource{}
-		err := rows.Scan(&resource.ID, &resource.Category, &resource.Content)
+		err := rows.Scan(&resource.ID, &resource.OwnerID, &resource.Category, &resource.Content, &resource.CreatedAt, &resource.UpdatedAt, &resource.PublishAt, &resource.ExpireAt, &resource.Blob,
+			&resource.Checksum, &resource.Size, &resource.Integrity)
 		if err != nil {
 			return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
 		}
@@ -154,14 +286,14 @@ func getResourcesByIDs(IDs []uuid.UUID, tx *sql.Tx) ([]models.Resource, error) {
 }
 
 const getResourceByCategoryQuery = `
-	SELECT BIN_TO_UUID(id), category, content 
-	FROM resources 
-	WHERE category = ? 
-	ORDER BY created_at DESC
+	SELECT BIN_TO_UUID(id), COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity
+	FROM resources
+	WHERE category = ? AND tenant_id = ?
 `
 
-func getResourcesByCategory(category int, tx *sql.Tx) ([]models.Resource, error) {
-	rows, err := tx.Query(getResourceByCategoryQuery, category)
+func getResourcesByCategory(ctx context.Context, tenantID string, category int, filter *models.ResourceFilter, tx *transaction) ([]models.Resource, error) {
+	clause, filterArgs := resourceFilterClause(filter)
+	rows, err := tx.QueryContext(ctx, getResourceByCategoryQuery+clause, append([]interface{}{category, tenantID}, filterArgs...)...)
 	if err != nil {
 		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
 	}
@@ -173,7 +305,8 @@ func getResourcesByCategory(category int, tx *sql.Tx) ([]models.Resource, error)
 	resources := make([]models.Resource, 0)
 	for rows.Next() {
 		resource := models.Resource{}
-		err := rows.Scan(&resource.ID, &resource.Category, &resource.Content)
+		err := rows.Scan(&resource.ID, &resource.OwnerID, &resource.Category, &resource.Content, &resource.CreatedAt, &resource.UpdatedAt, &resource.PublishAt, &resource.ExpireAt, &resource.Blob,
+			&resource.Checksum, &resource.Size, &resource.Integrity)
 		if err != nil {
 			return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
 		}
@@ -191,49 +324,92 @@ func getResourcesByCategory(category int, tx *sql.Tx) ([]models.Resource, error)
 	return resources, nil
 }
 
-var GetCategoryByNameQuery = "SELECT id, name, description FROM categories WHERE name = ?"
+// resourceFilterClause returns the conditions and the ordering of a resource listing with their arguments.
+// A nil filter orders the resources by creation time, newest first, and leaves out the hidden ones.
+func resourceFilterClause(filter *models.ResourceFilter) (string, []interface{}) {
+	column := models.ResourceSortCreatedAt
+	order := "DESC"
+	args := make([]interface{}, 0, 4)
+
+	var clause strings.Builder
+	if filter == nil || !filter.IncludeHidden {
+		at := now()
+		clause.WriteString(" AND (publish_at IS NULL OR publish_at <= ?) AND (expire_at IS NULL OR expire_at > ?)")
+		args = append(args, at, at)
+	}
+	if filter != nil {
+		// the column name goes into the query, so only the known ones are accepted
+		if filter.SortBy == models.ResourceSortUpdatedAt {
+			column = models.ResourceSortUpdatedAt
+		}
+		if filter.Ascending {
+			order = "ASC"
+		}
+		if filter.Since != nil {
+			clause.WriteString(" AND " + column + " >= ?")
+			args = append(args, filter.Since.UTC())
+		}
+		if filter.Until != nil {
+			clause.WriteString(" AND " + column + " < ?")
+			args = append(args, filter.Until.UTC())
+		}
+	}
+	// the id keeps the order of the resources changed in the same millisecond stable
+	clause.WriteString(" ORDER BY " + column + " " + order + ", id " + order)
 
-func (mySQL *MySQL) getCategoryByName(name string) (*models.Category, error) {
-	category := &models.Category{}
+	return clause.String(), args
+}
 
-	tx, err := mySQL.db.Begin()
-	if err != nil {
-		return nil, errors.WithStack(err)
+// sameFile tells whether the resources point at the same file with the same expectations,
+// the result of a verification is only kept while they do.
+func sameFile(a *models.Resource, b *models.Resource) bool {
+	if (a.Size == nil) != (b.Size == nil) || (a.Size != nil && *a.Size != *b.Size) {
+		return false
 	}
+	if (a.Blob == nil) != (b.Blob == nil) || (a.Blob != nil && *a.Blob != *b.Blob) {
+		return false
+	}
+	return a.Content[models.LocationKey] == b.Content[models.LocationKey] && strings.EqualFold(a.Checksum, b.Checksum)
+}
 
-	result := tx.QueryRow(GetCategoryByNameQuery, name)
+// schedulePending tells which of the scheduled events of the resource are still to be emitted at the given time.
+func schedulePending(resource *models.Resource, at time.Time) (publish bool, expire bool) {
+	return resource.PublishAt != nil && resource.PublishAt.After(at), resource.ExpireAt != nil && resource.ExpireAt.After(at)
+}
 
-	err = result.Scan(&category.ID, &category.Name, &category.Description)
-	switch {
-	case err == sql.ErrNoRows:
-		if errRb := tx.Commit(); errRb != nil {
-			return nil, errors.WithStack(err)
-		}
-		return nil, sql.ErrNoRows
-	case err != nil:
-		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
-	default:
+func utcOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
 	}
+	return t.UTC()
+}
 
-	return category, tx.Commit()
+// now returns the current time at the precision of the timestamp columns.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
 }
 
 const getCategoryByIDQuery = `
-	SELECT id, name, description 
-	FROM categories WHERE id = ?
+	SELECT id, name, description, updated_at
+	FROM categories WHERE id = ? AND tenant_id = ?
 `
 
-func (mySQL *MySQL) GetCategoryByID(id int) (*models.Category, error) {
+func (mySQL *MySQL) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	category := &models.Category{}
 
-	tx, err := mySQL.db.Begin()
+	tx, err := mySQL.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	result := tx.QueryRow(getCategoryByIDQuery, id)
+	result := tx.QueryRowContext(ctx, getCategoryByIDQuery, id, tenantID)
 
-	err = result.Scan(&category.ID, &category.Name, &category.Description)
+	err = result.Scan(&category.ID, &category.Name, &category.Description, &category.UpdatedAt)
 	switch {
 	case err == sql.ErrNoRows:
 		if errRb := tx.Commit(); errRb != nil {
@@ -249,17 +425,32 @@ func (mySQL *MySQL) GetCategoryByID(id int) (*models.Category, error) {
 }
 
 const getCategorsQuery = `
-	SELECT id, name, description 
+	SELECT id, name, description, updated_at
 	FROM categories
+	WHERE tenant_id = ?
 `
 
-func (mySQL *MySQL) getCategories() ([]models.Category, error) {
-	tx, err := mySQL.db.Begin()
+func (mySQL *MySQL) getCategories(ctx context.Context, tenantID string) ([]models.Category, error) {
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := getCategoriesTx(ctx, tenantID, tx)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := tx.Query(getCategorsQuery)
+	// every tenant is provisioned with the system categories
+	if len(categories) == 0 {
+		return nil, rollbackWithErrorStack(tx, errors.WithStack(ErrTenantNotFound))
+	}
+
+	return categories, tx.Commit()
+}
+
+func getCategoriesTx(ctx context.Context, tenantID string, tx *transaction) ([]models.Category, error) {
+	rows, err := tx.QueryContext(ctx, getCategorsQuery, tenantID)
 	if err != nil {
 		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
 	}
@@ -271,7 +462,7 @@ func (mySQL *MySQL) getCategories() ([]models.Category, error) {
 	categories := make([]models.Category, 0)
 	for rows.Next() {
 		category := models.Category{}
-		err := rows.Scan(&category.ID, &category.Name, &category.Description)
+		err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.UpdatedAt)
 		if err != nil {
 			return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
 		}
@@ -282,9 +473,5 @@ func (mySQL *MySQL) getCategories() ([]models.Category, error) {
 		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
 	}
 
-	if len(categories) == 0 {
-		return nil, sql.ErrNoRows
-	}
-
 	return categories, nil
 }
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+const getResourcesToVerifyQuery = `
+	SELECT BIN_TO_UUID(id), tenant_id, COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity
+	FROM resources
+	WHERE verified_at IS NULL OR verified_at < ?
+	ORDER BY verified_at, id
+	LIMIT ?
+`
+
+// GetResourcesToVerify returns the resources of every tenant never verified or verified last before checkedBefore,
+// the ones waiting longest first.
+func (mySQL *MySQL) GetResourcesToVerify(ctx context.Context, checkedBefore time.Time, limit int) ([]models.TenantResource, error) {
+	rows, err := mySQL.db.QueryContext(ctx, getResourcesToVerifyQuery, checkedBefore.UTC(), limit)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	resources := make([]models.TenantResource, 0)
+	for rows.Next() {
+		resource := models.TenantResource{}
+		err := rows.Scan(&resource.ID, &resource.TenantID, &resource.OwnerID, &resource.Category, &resource.Content,
+			&resource.CreatedAt, &resource.UpdatedAt, &resource.PublishAt, &resource.ExpireAt, &resource.Blob,
+			&resource.Checksum, &resource.Size, &resource.Integrity)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, errors.WithStack(rows.Err())
+}
+
+const setResourceIntegrityQuery = `
+	UPDATE resources
+	SET integrity = CAST(CONVERT(? USING utf8) AS JSON), verified_at = ?
+	WHERE id = UUID_TO_BIN(?) AND tenant_id = ?
+`
+
+// SetResourceIntegrity records the verification of the resource as it was at updatedAt.
+// The result is dropped if the resource has changed since then, the change queues it for verification again.
+// A changed status is announced like the other changes of the resource, so clients can hide the broken ones.
+func (mySQL *MySQL) SetResourceIntegrity(ctx context.Context, id uuid.UUID, updatedAt time.Time, integrity *models.Integrity, checkedAt time.Time) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	before, err := getResourceForUpdate(ctx, tenantID, id.String(), tx)
+	if err != nil {
+		if err == ErrResourcesMissing {
+			// deleted in the meantime
+			return nil
+		}
+		return err
+	}
+
+	if !before.UpdatedAt.Equal(updatedAt) {
+		return tx.Rollback()
+	}
+
+	if _, err := tx.ExecContext(ctx, setResourceIntegrityQuery, integrity, checkedAt.UTC(), id, tenantID); err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if integrityStatus(before.Integrity) != integrityStatus(integrity) {
+		after := *before
+		after.Integrity = integrity
+		if err := recordResourceChange(ctx, tenantID, models.AuditOperationUpdate, before, &after, tx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func integrityStatus(integrity *models.Integrity) string {
+	if integrity == nil {
+		return ""
+	}
+	return integrity.Status
+}
@@ -3,35 +3,56 @@ package storage
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"github.com/proemergotech/log/v3"
 	migrate "github.com/rubenv/sql-migrate"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/cache"
+	"github.com/artofimagination/mysql-resources-db-go-service/db/migrations"
 )
 
+// MySQL is the storage on a MySQL database, the other databases run its queries translated by their dialect.
 type MySQL struct {
-	db *sqlx.DB
+	db                   *database
+	defaultResourceQuota int
+	categoryCache        *cache.Cache
+}
+
+// NewMySQL creates the MySQL storage.
+// defaultResourceQuota limits the number of resources of the tenants without an explicit quota, 0 means unlimited.
+// categoryCache keeps the categories of the tenants, nil disables caching.
+func NewMySQL(db *sqlx.DB, defaultResourceQuota int, categoryCache *cache.Cache) *MySQL {
+	return newMySQL(db, mysqlDialect{}, defaultResourceQuota, categoryCache)
 }
 
-func NewMySQL(db *sqlx.DB) *MySQL {
+func newMySQL(db *sqlx.DB, dialect dialect, defaultResourceQuota int, categoryCache *cache.Cache) *MySQL {
 	return &MySQL{
-		db: db,
+		db:                   &database{DB: db.DB, dialect: dialect},
+		defaultResourceQuota: defaultResourceQuota,
+		categoryCache:        categoryCache,
 	}
 }
 
+// BootstrapSystem applies the migrations found in migrationDirectory, or the ones built into the binary if it is empty.
 func (mySQL *MySQL) BootstrapSystem(migrationDirectory string) error {
-	fmt.Printf("Executing MYSQL migration\n")
-	migrations := &migrate.FileMigrationSource{
+	dialect := mySQL.db.dialect.migrationDialect()
+	fmt.Printf("Executing %s migration\n", dialect)
+	var migrations migrate.MigrationSource = &migrate.FileMigrationSource{
 		Dir: migrationDirectory,
 	}
+	if migrationDirectory == "" {
+		migrations = &migrate.HttpFileSystemMigrationSource{FileSystem: http.FS(mySQL.db.dialect.embeddedMigrations())}
+	}
 	fmt.Printf("Getting migration files\n")
 
 	var n int
 	var err error
 	for retryCount := 20; retryCount > 0; retryCount-- {
-		n, err = migrate.Exec(mySQL.db.DB, "mysql", migrations, migrate.Up)
+		n, err = migrate.Exec(mySQL.db.DB, dialect, migrations, migrate.Up)
 		if err == nil {
 			break
 		}
@@ -45,3 +66,8 @@ func (mySQL *MySQL) BootstrapSystem(migrationDirectory string) error {
 	fmt.Printf("Applied %d migration(s)!\n", n)
 	return nil
 }
+
+// SchemaVersion returns the version of the migrations built into the binary.
+func (mySQL *MySQL) SchemaVersion() string {
+	return migrations.Version(mySQL.db.dialect.embeddedMigrations())
+}
@@ -0,0 +1,318 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+var resourceEventTypes = map[string]string{
+	models.AuditOperationAdd:    models.EventResourceCreated,
+	models.AuditOperationUpdate: models.EventResourceUpdated,
+	models.AuditOperationDelete: models.EventResourceDeleted,
+}
+
+// recordResourceChange writes the audit event and the outbox event of a resource mutation into tx.
+func recordResourceChange(ctx context.Context, tenantID string, operation string, before *models.Resource, after *models.Resource, tx *transaction) error {
+	if err := addAuditEvent(ctx, tenantID, operation, before, after, tx); err != nil {
+		return err
+	}
+
+	resource := after
+	if resource == nil {
+		resource = before
+	}
+
+	return addOutboxEvent(ctx, tenantID, models.AggregateResource, resource.ID.String(), resourceEventTypes[operation], resource, tx)
+}
+
+const addOutboxEventQuery = `
+	INSERT INTO
+	outbox_events(tenant_id, aggregate_type, aggregate_id, event_type, payload, created_at)
+	VALUES
+	(?, ?, ?, ?, CAST(CONVERT(? USING utf8) AS JSON), ?)
+`
+
+// addOutboxEvent stores the change event in the transaction of the change,
+// the relay publishes it only after the transaction has been committed.
+func addOutboxEvent(ctx context.Context, tenantID string, aggregateType string, aggregateID string, eventType string, payload interface{}, tx *transaction) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	_, err = tx.ExecContext(ctx, addOutboxEventQuery, tenantID, aggregateType, aggregateID, eventType, data, time.Now().UTC())
+	if err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	return nil
+}
+
+// maxOutboxErrorLength caps the delivery error stored with a failed outbox event.
+const maxOutboxErrorLength = 1024
+
+// getDueOutboxEventsQuery selects the unpublished events that are not waiting for a retry or leased by a relay.
+// An event is due only if no earlier event of its aggregate, of the same tenant, is pending, so each resource's events are delivered in order
+// even while an earlier one is retried or is being published by another relay.
+const getDueOutboxEventsQuery = `
+	SELECT e.id, e.tenant_id, e.aggregate_type, e.aggregate_id, e.event_type, e.payload, e.attempts, e.created_at
+	FROM outbox_events e
+	WHERE e.published_at IS NULL AND e.dead_at IS NULL
+	AND (e.next_attempt_at IS NULL OR e.next_attempt_at <= ?)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM outbox_events earlier
+		WHERE earlier.tenant_id = e.tenant_id AND earlier.aggregate_type = e.aggregate_type AND earlier.aggregate_id = e.aggregate_id
+		AND earlier.published_at IS NULL AND earlier.dead_at IS NULL AND earlier.id < e.id
+	)
+	ORDER BY e.id
+	LIMIT ?
+	FOR UPDATE OF e SKIP LOCKED
+`
+
+const leaseOutboxEventsQuery = `
+	UPDATE outbox_events
+	SET next_attempt_at = ?
+	WHERE id IN (?
+`
+
+// ClaimOutboxEvents returns the oldest due events and leases them for the given time,
+// so the other relays skip them while they are being published. The events are published
+// after the claim is committed, no row is locked while the sinks are called.
+func (mySQL *MySQL) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.ChangeEvent, error) {
+	tx, err := mySQL.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+	rows, err := tx.QueryContext(ctx, getDueOutboxEventsQuery, now, limit)
+	if err != nil {
+		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	events := make([]models.ChangeEvent, 0)
+	ids := make([]int64, 0)
+	for rows.Next() {
+		event := models.ChangeEvent{}
+		var payload []byte
+		err := rows.Scan(&event.ID, &event.TenantID, &event.AggregateType, &event.AggregateID, &event.Type, &payload, &event.Attempts, &event.CreatedAt)
+		if err != nil {
+			_ = rows.Close()
+			return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+		event.Payload = payload
+		events = append(events, event)
+		ids = append(ids, event.ID)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if len(ids) > 0 {
+		args := append([]interface{}{now.Add(lease)}, int64Args(ids)...)
+		if _, err := tx.ExecContext(ctx, inClause(leaseOutboxEventsQuery, len(ids)), args...); err != nil {
+			return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+	}
+
+	return events, tx.Commit()
+}
+
+const completeOutboxEventsQuery = `
+	UPDATE outbox_events
+	SET published_at = ?, next_attempt_at = NULL, last_error = NULL
+	WHERE id IN (?
+`
+
+// CompleteOutboxEvents marks the events as published.
+func (mySQL *MySQL) CompleteOutboxEvents(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := append([]interface{}{time.Now().UTC()}, int64Args(ids)...)
+	if _, err := mySQL.db.ExecContext(ctx, inClause(completeOutboxEventsQuery, len(ids)), args...); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+const failOutboxEventQuery = `
+	UPDATE outbox_events
+	SET attempts = attempts + 1, next_attempt_at = ?, last_error = ?, dead_at = ?
+	WHERE id = ?
+`
+
+// FailOutboxEvent records a failed delivery. The event is retried at nextAttemptAt, or moved to the
+// dead letters if dead is set, which lets the later events of its aggregate through.
+func (mySQL *MySQL) FailOutboxEvent(ctx context.Context, event *models.ChangeEvent, publishErr error, nextAttemptAt time.Time, dead bool) error {
+	lastError := publishErr.Error()
+	if len(lastError) > maxOutboxErrorLength {
+		lastError = lastError[:maxOutboxErrorLength]
+	}
+
+	var deadAt *time.Time
+	if dead {
+		now := time.Now().UTC()
+		deadAt = &now
+	}
+
+	if _, err := mySQL.db.ExecContext(ctx, failOutboxEventQuery, nextAttemptAt.UTC(), lastError, deadAt, event.ID); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func inClause(query string, count int) string {
+	return query + strings.Repeat(",?", count-1) + ")"
+}
+
+func int64Args(values []int64) []interface{} {
+	args := make([]interface{}, len(values))
+	for i := range values {
+		args[i] = values[i]
+	}
+	return args
+}
+
+const getChangeLogSequenceQuery = `
+	SELECT last_position
+	FROM change_log_sequence
+	WHERE id = 1
+	FOR UPDATE
+`
+
+const getUnsequencedChangeEventsQuery = `
+	SELECT id
+	FROM outbox_events
+	WHERE log_position IS NULL
+	ORDER BY id
+	LIMIT ?
+`
+
+const setChangeEventPositionQuery = `
+	UPDATE outbox_events
+	SET log_position = ?
+	WHERE id = ?
+`
+
+const setChangeLogSequenceQuery = `
+	UPDATE change_log_sequence
+	SET last_position = ?
+	WHERE id = 1
+`
+
+// SequenceChangeEvents appends the committed events without a position to the change log and returns how many it appended.
+// The event IDs are taken when the events are written, so a transaction committing late leaves behind an ID a reader
+// may have moved past already. The positions are handed out under the lock of the sequence instead, the transactions
+// appending to the change log commit one after the other, so a reader never sees a position before the lower ones.
+func (mySQL *MySQL) SequenceChangeEvents(ctx context.Context, limit int) (int, error) {
+	tx, err := mySQL.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	var position int64
+	if err := tx.QueryRowContext(ctx, getChangeLogSequenceQuery).Scan(&position); err != nil {
+		return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	rows, err := tx.QueryContext(ctx, getUnsequencedChangeEventsQuery, limit)
+	if err != nil {
+		return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if len(ids) == 0 {
+		return 0, tx.Commit()
+	}
+
+	for _, id := range ids {
+		position++
+		if _, err := tx.ExecContext(ctx, setChangeEventPositionQuery, position, id); err != nil {
+			return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, setChangeLogSequenceQuery, position); err != nil {
+		return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	return len(ids), errors.WithStack(tx.Commit())
+}
+
+const getChangeEventsQuery = `
+	SELECT id, log_position, tenant_id, aggregate_type, aggregate_id, event_type, payload, created_at
+	FROM outbox_events
+	WHERE tenant_id = ? AND aggregate_type = ? AND log_position > ?
+	ORDER BY log_position
+	LIMIT ?
+`
+
+// GetChangeEvents reads the change log of the tenant following afterPosition, the change log is the outbox kept after publishing.
+func (mySQL *MySQL) GetChangeEvents(ctx context.Context, aggregateType string, afterPosition int64, limit int) ([]models.ChangeEvent, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := mySQL.db.QueryContext(ctx, getChangeEventsQuery, tenantID, aggregateType, afterPosition, limit)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	events := make([]models.ChangeEvent, 0)
+	for rows.Next() {
+		event := models.ChangeEvent{}
+		var payload []byte
+		err := rows.Scan(&event.ID, &event.Position, &event.TenantID, &event.AggregateType, &event.AggregateID, &event.Type, &payload, &event.CreatedAt)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+
+	return events, errors.WithStack(rows.Err())
+}
+
+const getLatestChangeLogPositionQuery = `
+	SELECT last_position
+	FROM change_log_sequence
+	WHERE id = 1
+`
+
+// GetLatestChangeLogPosition returns the position of the newest event of the change log, readers start from here when they have no position yet.
+func (mySQL *MySQL) GetLatestChangeLogPosition(ctx context.Context) (int64, error) {
+	var position int64
+	if err := mySQL.db.QueryRowContext(ctx, getLatestChangeLogPositionQuery).Scan(&position); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return position, nil
+}
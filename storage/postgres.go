@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/cache"
+)
+
+// PostgreSQL is the storage on a PostgreSQL database, keeping the ids in native uuid and the documents in jsonb columns.
+// It runs the queries of the MySQL storage translated to PostgreSQL, the schema has its own migrations.
+type PostgreSQL struct {
+	*MySQL
+}
+
+// NewPostgreSQL creates the PostgreSQL storage, its arguments are the ones of NewMySQL.
+// The connections have to use the UTC time zone, the timestamps are read in the time zone of the session.
+func NewPostgreSQL(db *sqlx.DB, defaultResourceQuota int, categoryCache *cache.Cache) *PostgreSQL {
+	return &PostgreSQL{MySQL: newMySQL(db, &postgresDialect{}, defaultResourceQuota, categoryCache)}
+}
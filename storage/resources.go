@@ -1,8 +1,8 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -13,63 +13,111 @@ import (
 var ErrResourceNotFound = errors.New("The selected resource not found")
 var ErrResourceAlreadyExists = errors.New("The resource already exists")
 var ErrResourceHasTooManyAttachments = errors.New("The resource has too many attachements")
+var ErrCategoryNotFound = errors.New("The selected category not found")
+var ErrTenantMissing = errors.New("The request has no tenant")
+var ErrTenantNotFound = errors.New("The tenant is not provisioned")
+var ErrTenantAlreadyExists = errors.New("The tenant already exists")
+var ErrTenantQuotaExceeded = errors.New("The resource quota of the tenant is exceeded")
+var ErrInvalidSchedule = errors.New("The resource must expire after it is published")
 
 var ErrDuplicateEntrySubString = "Duplicate entry"
 
 // MaxContentItems describes the maximum number or resources to upload to a resources an attachement
 var MaxContentItems = 2
 
-func (mySQL *MySQL) AddResource(resource *models.Resource) (err error) {
+// attachmentCount returns the number of attachment resources referenced by content.
+func attachmentCount(content models.ContentMap) int {
+	count := 0
+	for k := range content {
+		if k != models.LocationKey {
+			count++
+		}
+	}
+	return count
+}
+
+func (mySQL *MySQL) AddResource(ctx context.Context, resource *models.Resource) (err error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	resource.OwnerID = ownerFromContext(ctx)
+
+	if len(resource.Content) > MaxContentItems {
+		return errors.WithStack(ErrResourceHasTooManyAttachments)
+	}
+
+	if resource.PublishAt != nil && resource.ExpireAt != nil && !resource.ExpireAt.After(*resource.PublishAt) {
+		return errors.WithStack(ErrInvalidSchedule)
+	}
 
-	tx, err := mySQL.db.Begin()
+	category, err := mySQL.getCategoryByName(ctx, tenantID, models.CategoryContent)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	category, err := mySQL.getCategoryByName(models.CategoryContent)
+	tx, err := mySQL.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if len(resource.Content) > MaxContentItems {
-		return errors.WithStack(ErrResourceHasTooManyAttachments)
+	if err := checkResourceQuota(ctx, tenantID, attachmentCount(resource.Content)+1, mySQL.defaultResourceQuota, tx); err != nil {
+		return err
+	}
+
+	if err := categoryExists(ctx, tenantID, resource.Category, tx); err != nil {
+		return err
 	}
 
 	for k, v := range resource.Content {
 		if k != models.LocationKey {
 			resourceItem, err := models.NewResource(k, category.ID, v)
 			if err != nil {
-				return errors.WithStack(err)
+				return rollbackWithErrorStack(tx, errors.WithStack(err))
 			}
-			if err := addResource(resourceItem, tx); err != nil {
-				if strings.Contains(err.Error(), ErrDuplicateEntrySubString) {
+			resourceItem.OwnerID = resource.OwnerID
+			if err := addResource(ctx, tenantID, resourceItem, tx); err != nil {
+				if mySQL.db.dialect.isDuplicateEntry(err) {
 					return errors.WithStack(ErrResourceAlreadyExists)
 				}
 				return err
 			}
+			if err := recordResourceChange(ctx, tenantID, models.AuditOperationAdd, nil, resourceItem, tx); err != nil {
+				return err
+			}
 		}
 	}
 
-	if err := addResource(resource, tx); err != nil {
-		if strings.Contains(err.Error(), ErrDuplicateEntrySubString) {
+	if err := addResource(ctx, tenantID, resource, tx); err != nil {
+		if mySQL.db.dialect.isDuplicateEntry(err) {
 			return errors.WithStack(ErrResourceAlreadyExists)
 		}
 		return errors.WithStack(err)
 	}
 
+	if err := recordResourceChange(ctx, tenantID, models.AuditOperationAdd, nil, resource, tx); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-func (mySQL *MySQL) GetResourcesByCategory(category int) ([]models.Resource, error) {
-	tx, err := mySQL.db.Begin()
+// GetResourcesByCategory lists the resources of the category, a nil filter orders them by creation time, newest first.
+func (mySQL *MySQL) GetResourcesByCategory(ctx context.Context, category int, filter *models.ResourceFilter) ([]models.Resource, error) {
+	tenantID, err := tenantFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	resources, err := getResourcesByCategory(category, tx)
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := getResourcesByCategory(ctx, tenantID, category, filter, tx)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, ErrResourceNotFound
+			return nil, rollbackWithErrorStack(tx, ErrResourceNotFound)
 		}
 		return nil, err
 	}
@@ -77,16 +125,22 @@ func (mySQL *MySQL) GetResourcesByCategory(category int) ([]models.Resource, err
 	return resources, tx.Commit()
 }
 
-func (mySQL *MySQL) GetResourcesByIDs(IDs []uuid.UUID) ([]models.Resource, error) {
-	tx, err := mySQL.db.Begin()
+// GetResourcesByIDs returns the existing ones of the resources, a nil filter orders them by creation time, newest first.
+func (mySQL *MySQL) GetResourcesByIDs(ctx context.Context, IDs []uuid.UUID, filter *models.ResourceFilter) ([]models.Resource, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := mySQL.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resources, err := getResourcesByIDs(IDs, tx)
+	resources, err := getResourcesByIDs(ctx, tenantID, IDs, filter, tx)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, ErrResourceNotFound
+			return nil, rollbackWithErrorStack(tx, ErrResourceNotFound)
 		}
 		return nil, err
 	}
@@ -94,8 +148,13 @@ func (mySQL *MySQL) GetResourcesByIDs(IDs []uuid.UUID) ([]models.Resource, error
 	return resources, tx.Commit()
 }
 
-func (mySQL *MySQL) GetResourceByID(ID uuid.UUID) (*models.Resource, error) {
-	resources, err := mySQL.getResourceByID(ID)
+func (mySQL *MySQL) GetResourceByID(ctx context.Context, ID uuid.UUID) (*models.Resource, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := mySQL.getResourceByID(ctx, tenantID, ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrResourceNotFound
@@ -106,27 +165,60 @@ func (mySQL *MySQL) GetResourceByID(ID uuid.UUID) (*models.Resource, error) {
 	return resources, nil
 }
 
-func (mySQL *MySQL) UpdateResource(resource *models.Resource) error {
-	tx, err := mySQL.db.Begin()
+func (mySQL *MySQL) UpdateResource(ctx context.Context, resource *models.Resource) error {
+	tenantID, err := tenantFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
 	if len(resource.Content) > MaxContentItems {
-		return rollbackWithErrorStack(tx, errors.WithStack(ErrResourceHasTooManyAttachments))
+		return errors.WithStack(ErrResourceHasTooManyAttachments)
+	}
+
+	if resource.PublishAt != nil && resource.ExpireAt != nil && !resource.ExpireAt.After(*resource.PublishAt) {
+		return errors.WithStack(ErrInvalidSchedule)
 	}
 
-	resourceFromDB, err := mySQL.getResourceByID(resource.ID)
+	category, err := mySQL.getCategoryByName(ctx, tenantID, models.CategoryContent)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tx, err := mySQL.db.BeginTx(ctx, nil)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return rollbackWithErrorStack(tx, errors.WithStack(ErrResourceNotFound))
-		}
 		return err
 	}
 
-	category, err := mySQL.getCategoryByName(models.CategoryContent)
+	resourceFromDB, err := getResourceForUpdate(ctx, tenantID, resource.ID.String(), tx)
 	if err != nil {
-		return rollbackWithErrorStack(tx, errors.WithStack(err))
+		if err == ErrResourcesMissing {
+			return errors.WithStack(ErrResourceNotFound)
+		}
+		return err
+	}
+	resource.OwnerID = resourceFromDB.OwnerID
+	resource.CreatedAt = resourceFromDB.CreatedAt
+	resource.Blob = resourceFromDB.Blob
+	resource.Integrity = nil
+	if sameFile(resource, resourceFromDB) {
+		resource.Integrity = resourceFromDB.Integrity
+	}
+
+	newItems := 0
+	for k := range resource.Content {
+		if _, ok := resourceFromDB.Content[k]; !ok {
+			newItems++
+		}
+	}
+
+	if newItems > 0 {
+		if err := checkResourceQuota(ctx, tenantID, newItems, mySQL.defaultResourceQuota, tx); err != nil {
+			return err
+		}
+	}
+
+	if err := categoryExists(ctx, tenantID, resource.Category, tx); err != nil {
+		return err
 	}
 
 	for k, v := range resource.Content {
@@ -135,57 +227,84 @@ func (mySQL *MySQL) UpdateResource(resource *models.Resource) error {
 			if err != nil {
 				return rollbackWithErrorStack(tx, errors.WithStack(err))
 			}
-			if err := addResource(resourceItem, tx); err != nil {
-				if strings.Contains(err.Error(), ErrDuplicateEntrySubString) {
+			resourceItem.OwnerID = resource.OwnerID
+			if err := addResource(ctx, tenantID, resourceItem, tx); err != nil {
+				if mySQL.db.dialect.isDuplicateEntry(err) {
 					return ErrResourceAlreadyExists
 				}
 				return err
 			}
+			if err := recordResourceChange(ctx, tenantID, models.AuditOperationAdd, nil, resourceItem, tx); err != nil {
+				return err
+			}
 		}
 	}
 
-	if err := updateResource(resource, tx); err != nil {
+	if err := updateResource(ctx, tenantID, resource, tx); err != nil {
 		if err == ErrResourcesMissing {
 			return ErrResourceNotFound
 		}
 		return err
 	}
 
+	if err := recordResourceChange(ctx, tenantID, models.AuditOperationUpdate, resourceFromDB, resource, tx); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-func (mySQL *MySQL) DeleteResource(id uuid.UUID, content models.ContentMap) error {
-	tx, err := mySQL.db.Begin()
+// DeleteResource deletes the resource with the attachments listed in content and returns the deleted resources.
+func (mySQL *MySQL) DeleteResource(ctx context.Context, id uuid.UUID, content models.ContentMap) ([]models.Resource, error) {
+	tenantID, err := tenantFromContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(content)+1)
 	for k := range content {
 		if k != models.LocationKey {
-			if err := deleteResource(k, tx); err != nil {
-				if err == ErrResourcesMissing {
-					return ErrResourceNotFound
-				}
-				return err
-			}
+			ids = append(ids, k)
 		}
 	}
+	ids = append(ids, id.String())
+
+	deleted := make([]models.Resource, 0, len(ids))
+	for _, resourceID := range ids {
+		before, err := getResourceForUpdate(ctx, tenantID, resourceID, tx)
+		if err != nil {
+			if err == ErrResourcesMissing {
+				return nil, ErrResourceNotFound
+			}
+			return nil, err
+		}
 
-	if err := deleteResource(id.String(), tx); err != nil {
-		if err == ErrResourcesMissing {
-			return ErrResourceNotFound
+		if err := deleteResource(ctx, tenantID, resourceID, tx); err != nil {
+			if err == ErrResourcesMissing {
+				return nil, ErrResourceNotFound
+			}
+			return nil, err
 		}
-		return err
+
+		if err := recordResourceChange(ctx, tenantID, models.AuditOperationDelete, before, nil, tx); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, *before)
 	}
 
-	return tx.Commit()
+	return deleted, tx.Commit()
 }
 
-func (mySQL *MySQL) GetCategories() ([]models.Category, error) {
-	categories, err := mySQL.getCategories()
+func (mySQL *MySQL) GetCategories(ctx context.Context) ([]models.Category, error) {
+	tenantID, err := tenantFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return categories, nil
+	return mySQL.tenantCategories(ctx, tenantID)
 }
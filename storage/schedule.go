@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// scheduledEvent describes one of the scheduled moments of the resources.
+type scheduledEvent struct {
+	eventType  string
+	dueQuery   string
+	clearQuery string
+}
+
+var scheduledEvents = []scheduledEvent{
+	{
+		eventType: models.EventResourcePublished,
+		dueQuery: `
+			SELECT BIN_TO_UUID(id), tenant_id, COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity
+			FROM resources
+			WHERE publish_event_pending AND publish_at <= ?
+			ORDER BY publish_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		`,
+		clearQuery: `
+			UPDATE resources
+			SET publish_event_pending = FALSE
+			WHERE tenant_id = ? AND id = UUID_TO_BIN(?)
+		`,
+	},
+	{
+		eventType: models.EventResourceExpired,
+		dueQuery: `
+			SELECT BIN_TO_UUID(id), tenant_id, COALESCE(owner_id, ''), category, content, created_at, updated_at, publish_at, expire_at, blob_meta, COALESCE(checksum, ''), size, integrity
+			FROM resources
+			WHERE expire_event_pending AND expire_at <= ?
+			ORDER BY expire_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		`,
+		clearQuery: `
+			UPDATE resources
+			SET expire_event_pending = FALSE
+			WHERE tenant_id = ? AND id = UUID_TO_BIN(?)
+		`,
+	},
+}
+
+// ProcessScheduledResources adds the change events of the resources published or expired by now, at most limit of each kind.
+// The events go through the outbox like every other change, so they reach the stream and the webhooks as well.
+// It returns the number of events added, the caller runs it again while it is not zero.
+func (mySQL *MySQL) ProcessScheduledResources(ctx context.Context, now time.Time, limit int) (int, error) {
+	processed := 0
+	for _, event := range scheduledEvents {
+		count, err := mySQL.processScheduledEvent(ctx, event, now.UTC(), limit)
+		if err != nil {
+			return processed, err
+		}
+		processed += count
+	}
+	return processed, nil
+}
+
+func (mySQL *MySQL) processScheduledEvent(ctx context.Context, event scheduledEvent, now time.Time, limit int) (int, error) {
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	rows, err := tx.QueryContext(ctx, event.dueQuery, now, limit)
+	if err != nil {
+		return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	tenants := make([]string, 0)
+	resources := make([]models.Resource, 0)
+	for rows.Next() {
+		var tenantID string
+		resource := models.Resource{}
+		err := rows.Scan(&resource.ID, &tenantID, &resource.OwnerID, &resource.Category, &resource.Content,
+			&resource.CreatedAt, &resource.UpdatedAt, &resource.PublishAt, &resource.ExpireAt, &resource.Blob,
+			&resource.Checksum, &resource.Size, &resource.Integrity)
+		if err != nil {
+			_ = rows.Close()
+			return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+		tenants = append(tenants, tenantID)
+		resources = append(resources, resource)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if len(resources) == 0 {
+		return 0, tx.Commit()
+	}
+
+	for i := range resources {
+		if err := addOutboxEvent(ctx, tenants[i], models.AggregateResource, resources[i].ID.String(), event.eventType, &resources[i], tx); err != nil {
+			return 0, err
+		}
+		// the ids are unique within the tenants only
+		if _, err := tx.ExecContext(ctx, event.clearQuery, tenants[i], resources[i].ID); err != nil {
+			return 0, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+	}
+
+	return len(resources), tx.Commit()
+}
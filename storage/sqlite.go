@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"net/url"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	// the pure Go SQLite driver keeps the service a single static binary
+	_ "modernc.org/sqlite"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/cache"
+)
+
+// sqliteDriverName is the name the SQLite driver registers.
+const sqliteDriverName = "sqlite"
+
+// SQLite is the storage in a SQLite database file, for local development, demos and small edge installs.
+// It runs the queries of the MySQL storage translated to SQLite, its migrations are built into the binary.
+// A database file is used by a single instance of the service.
+type SQLite struct {
+	*MySQL
+}
+
+// NewSQLite creates the SQLite storage on a database opened by OpenSQLite, its other arguments are the ones of NewMySQL.
+func NewSQLite(db *sqlx.DB, defaultResourceQuota int, categoryCache *cache.Cache) *SQLite {
+	return &SQLite{MySQL: newMySQL(db, &sqliteDialect{}, defaultResourceQuota, categoryCache)}
+}
+
+// OpenSQLite opens the SQLite database file at path, it is created if it does not exist.
+// The transactions wait for each other instead of failing while the database is locked,
+// the write-ahead log lets the reads run while a transaction is writing.
+func OpenSQLite(path string) (*sqlx.DB, error) {
+	options := url.Values{}
+	options.Add("_pragma", "foreign_keys(1)")
+	options.Add("_pragma", "busy_timeout(10000)")
+	options.Add("_pragma", "journal_mode(WAL)")
+	options.Set("_txlock", "immediate")
+	options.Set("_time_format", "sqlite")
+
+	db, err := sqlx.Open(sqliteDriverName, "file:"+path+"?"+options.Encode())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return db, nil
+}
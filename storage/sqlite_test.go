@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestSQLiteTransactionsLockOnBegin checks that a transaction reading before it writes is not failed
+// by a write committed in the meantime, the other writers wait for it instead.
+func TestSQLiteTransactionsLockOnBegin(t *testing.T) {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "lock.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec("CREATE TABLE counters (id INTEGER PRIMARY KEY, value INTEGER NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO counters (id, value) VALUES (1, 0)"); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value int
+	if err := tx.QueryRow("SELECT value FROM counters WHERE id = 1").Scan(&value); err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := db.Exec("UPDATE counters SET value = value + 10 WHERE id = 1")
+		written <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := tx.Exec("UPDATE counters SET value = ? WHERE id = 1", value+1); err != nil {
+		t.Fatalf("the transaction failed after the concurrent write: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("SELECT value FROM counters WHERE id = 1").Scan(&value); err != nil {
+		t.Fatal(err)
+	}
+	if value != 11 {
+		t.Fatalf("expected both writes applied, got %d", value)
+	}
+}
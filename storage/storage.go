@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// Storage is the persistence used by the service, implemented by MySQL, PostgreSQL, SQLite and the decorators wrapping it.
+type Storage interface {
+	AddTenant(ctx context.Context, tenantID string, resourceQuota *int) error
+
+	AddResource(ctx context.Context, resource *models.Resource) error
+	GetResourceByID(ctx context.Context, ID uuid.UUID) (*models.Resource, error)
+	GetResourcesByIDs(ctx context.Context, IDs []uuid.UUID, filter *models.ResourceFilter) ([]models.Resource, error)
+	GetResourcesByCategory(ctx context.Context, category int, filter *models.ResourceFilter) ([]models.Resource, error)
+	UpdateResource(ctx context.Context, resource *models.Resource) error
+	DeleteResource(ctx context.Context, id uuid.UUID, content models.ContentMap) ([]models.Resource, error)
+	SetResourceBlob(ctx context.Context, id uuid.UUID, blob *models.Blob, location string) (*models.Resource, *models.Resource, error)
+	GetCategories(ctx context.Context) ([]models.Category, error)
+	ProcessScheduledResources(ctx context.Context, now time.Time, limit int) (int, error)
+	GetResourcesToVerify(ctx context.Context, checkedBefore time.Time, limit int) ([]models.TenantResource, error)
+	SetResourceIntegrity(ctx context.Context, id uuid.UUID, updatedAt time.Time, integrity *models.Integrity, checkedAt time.Time) error
+
+	GetAuditEvents(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEvent, error)
+	DeleteAuditEventsBefore(ctx context.Context, before time.Time) (int64, error)
+
+	SequenceChangeEvents(ctx context.Context, limit int) (int, error)
+	GetChangeEvents(ctx context.Context, aggregateType string, afterPosition int64, limit int) ([]models.ChangeEvent, error)
+	GetLatestChangeLogPosition(ctx context.Context) (int64, error)
+
+	AddWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	UpdateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error
+	GetWebhookSubscriptions(ctx context.Context, activeOnly bool) ([]models.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	GetDeadWebhookDeliveries(ctx context.Context, subscriptionID *uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, id int64) error
+}
+
+var _ Storage = (*MySQL)(nil)
+var _ Storage = (*PostgreSQL)(nil)
+var _ Storage = (*SQLite)(nil)
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+const addTenantQuery = `
+	INSERT INTO tenants(id, resource_quota)
+	VALUES (?, ?)
+`
+
+const provisionTenantCategoriesQuery = `
+	INSERT INTO categories(tenant_id, name, description)
+	SELECT ?, name, description
+	FROM categories
+	WHERE tenant_id = ?
+`
+
+const tenantExistsQuery = `
+	SELECT COUNT(*)
+	FROM tenants
+	WHERE id = ?
+`
+
+// AddTenant provisions a tenant with the system categories, the requests of the tenants not provisioned are refused.
+// A nil resourceQuota leaves the tenant on the default quota.
+func (mySQL *MySQL) AddTenant(ctx context.Context, tenantID string, resourceQuota *int) error {
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, addTenantQuery, tenantID, resourceQuota); err != nil {
+		if mySQL.db.dialect.isDuplicateEntry(err) {
+			return rollbackWithErrorStack(tx, errors.WithStack(ErrTenantAlreadyExists))
+		}
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if _, err := tx.ExecContext(ctx, provisionTenantCategoriesQuery, tenantID, auth.DefaultTenantID); err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	categories, err := getCategoriesTx(ctx, tenantID, tx)
+	if err != nil {
+		return err
+	}
+
+	for i := range categories {
+		category := &categories[i]
+		if err := addOutboxEvent(ctx, tenantID, models.AggregateCategory, strconv.Itoa(category.ID), models.EventCategoryCreated, category, tx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// checkTenant fails unless the tenant is provisioned.
+func (mySQL *MySQL) checkTenant(ctx context.Context, tenantID string) error {
+	var count int
+	if err := mySQL.db.QueryRowContext(ctx, tenantExistsQuery, tenantID).Scan(&count); err != nil {
+		return errors.WithStack(err)
+	}
+	if count == 0 {
+		return errors.WithStack(ErrTenantNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,515 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+var ErrWebhookSubscriptionNotFound = errors.New("The selected webhook subscription not found")
+var ErrWebhookDeliveryNotFound = errors.New("The selected webhook delivery not found")
+
+// maxDeliveryErrorLength matches the size of the last_error column.
+const maxDeliveryErrorLength = 1024
+
+const addWebhookSubscriptionQuery = `
+	INSERT INTO
+	webhook_subscriptions(id, tenant_id, url, categories, event_types, secret, active, created_at, updated_at)
+	VALUES
+	(UUID_TO_BIN(?), ?, ?, CAST(CONVERT(? USING utf8) AS JSON), CAST(CONVERT(? USING utf8) AS JSON), ?, ?, ?, ?)
+`
+
+func (mySQL *MySQL) AddWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := mySQL.checkTenant(ctx, tenantID); err != nil {
+		return err
+	}
+
+	categories, eventTypes, err := marshalSubscriptionFilters(subscription)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	_, err = mySQL.db.ExecContext(
+		ctx,
+		addWebhookSubscriptionQuery,
+		subscription.ID,
+		tenantID,
+		subscription.URL,
+		categories,
+		eventTypes,
+		subscription.Secret,
+		subscription.Active,
+		now,
+		now,
+	)
+	return errors.WithStack(err)
+}
+
+const updateWebhookSubscriptionQuery = `
+	UPDATE webhook_subscriptions
+	SET url = ?, categories = CAST(CONVERT(? USING utf8) AS JSON), event_types = CAST(CONVERT(? USING utf8) AS JSON),
+	secret = ?, active = ?, consecutive_failures = CASE WHEN ? THEN 0 ELSE consecutive_failures END, updated_at = ?
+	WHERE id = UUID_TO_BIN(?) AND tenant_id = ?
+`
+
+// UpdateWebhookSubscription replaces the settings of the subscription.
+// Activating a subscription clears its failure counter, so it gets a fresh start after it was disabled.
+func (mySQL *MySQL) UpdateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	categories, eventTypes, err := marshalSubscriptionFilters(subscription)
+	if err != nil {
+		return err
+	}
+
+	result, err := mySQL.db.ExecContext(
+		ctx,
+		updateWebhookSubscriptionQuery,
+		subscription.URL,
+		categories,
+		eventTypes,
+		subscription.Secret,
+		subscription.Active,
+		subscription.Active,
+		time.Now().UTC(),
+		subscription.ID,
+		tenantID,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if affected == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+const deleteWebhookSubscriptionQuery = `
+	DELETE FROM webhook_subscriptions
+	WHERE id = UUID_TO_BIN(?) AND tenant_id = ?
+`
+
+func (mySQL *MySQL) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := mySQL.db.ExecContext(ctx, deleteWebhookSubscriptionQuery, id, tenantID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if affected == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+const getWebhookSubscriptionsQuery = `
+	SELECT BIN_TO_UUID(id), url, categories, event_types, secret, active, consecutive_failures, created_at, updated_at
+	FROM webhook_subscriptions
+	WHERE tenant_id = ?
+`
+
+// GetWebhookSubscriptions returns the subscriptions of the tenant, or only its active ones if activeOnly is set.
+func (mySQL *MySQL) GetWebhookSubscriptions(ctx context.Context, activeOnly bool) ([]models.WebhookSubscription, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := getWebhookSubscriptionsQuery
+	if activeOnly {
+		query += " AND active = TRUE"
+	}
+	query += " ORDER BY created_at"
+
+	rows, err := mySQL.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	subscriptions := make([]models.WebhookSubscription, 0)
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+
+	return subscriptions, errors.WithStack(rows.Err())
+}
+
+func (mySQL *MySQL) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	row := mySQL.db.QueryRowContext(ctx, getWebhookSubscriptionsQuery+" AND id = UUID_TO_BIN(?)", tenantID, id)
+	subscription, err := scanWebhookSubscription(row)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, ErrWebhookSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookSubscription(row scanner) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{}
+	var categories, eventTypes []byte
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&categories,
+		&eventTypes,
+		&subscription.Secret,
+		&subscription.Active,
+		&subscription.ConsecutiveFailures,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := json.Unmarshal(categories, &subscription.Categories); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := json.Unmarshal(eventTypes, &subscription.EventTypes); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return subscription, nil
+}
+
+func marshalSubscriptionFilters(subscription *models.WebhookSubscription) ([]byte, []byte, error) {
+	if subscription.Categories == nil {
+		subscription.Categories = []int{}
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+
+	categories, err := json.Marshal(subscription.Categories)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return categories, eventTypes, nil
+}
+
+const addWebhookDeliveryQuery = `
+	INSERT IGNORE INTO
+	webhook_deliveries(subscription_id, tenant_id, event_id, payload, status, next_attempt_at, created_at, updated_at)
+	VALUES
+	(UUID_TO_BIN(?), ?, ?, CAST(CONVERT(? USING utf8) AS JSON), ?, ?, ?, ?)
+`
+
+// AddWebhookDeliveries queues the event for every given subscription.
+// An event already queued for a subscription is not queued again, so redelivered outbox events are not duplicated.
+func (mySQL *MySQL) AddWebhookDeliveries(ctx context.Context, event *models.ChangeEvent, subscriptions []models.WebhookSubscription) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+	for _, subscription := range subscriptions {
+		_, err := tx.ExecContext(ctx, addWebhookDeliveryQuery, subscription.ID, tenantID, event.ID, payload, models.WebhookDeliveryPending, now, now, now)
+		if err != nil {
+			return rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+	}
+
+	return tx.Commit()
+}
+
+const getDueWebhookDeliveriesQuery = `
+	SELECT d.id, BIN_TO_UUID(d.subscription_id), d.event_id, d.payload, d.status, d.attempts, d.next_attempt_at, COALESCE(d.last_error, ''), d.created_at, s.url, s.secret
+	FROM webhook_deliveries d
+	JOIN webhook_subscriptions s ON s.id = d.subscription_id
+	WHERE d.status = ? AND d.next_attempt_at <= ? AND s.active = TRUE
+	ORDER BY d.next_attempt_at, d.id
+	LIMIT ?
+	FOR UPDATE
+`
+
+const leaseWebhookDeliveriesQuery = `
+	UPDATE webhook_deliveries
+	SET next_attempt_at = ?
+	WHERE id IN (?
+`
+
+// ClaimWebhookDeliveries returns the due deliveries of every tenant and leases them for the given time,
+// so the other delivery workers skip them while they are being sent.
+func (mySQL *MySQL) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	tx, err := mySQL.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+	rows, err := tx.QueryContext(ctx, getDueWebhookDeliveriesQuery, models.WebhookDeliveryPending, now, limit)
+	if err != nil {
+		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	ids := make([]int64, 0)
+	for rows.Next() {
+		delivery := models.WebhookDelivery{}
+		var payload []byte
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.URL,
+			&delivery.Secret,
+		)
+		if err != nil {
+			_ = rows.Close()
+			return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+		ids = append(ids, delivery.ID)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if len(ids) > 0 {
+		args := append([]interface{}{now.Add(lease)}, int64Args(ids)...)
+		if _, err := tx.ExecContext(ctx, inClause(leaseWebhookDeliveriesQuery, len(ids)), args...); err != nil {
+			return nil, rollbackWithErrorStack(tx, errors.WithStack(err))
+		}
+	}
+
+	return deliveries, tx.Commit()
+}
+
+const completeWebhookDeliveryQuery = `
+	UPDATE webhook_deliveries
+	SET status = ?, attempts = attempts + 1, last_error = NULL, updated_at = ?
+	WHERE id = ?
+`
+
+const resetWebhookSubscriptionFailuresQuery = `
+	UPDATE webhook_subscriptions
+	SET consecutive_failures = 0
+	WHERE id = UUID_TO_BIN(?)
+`
+
+// CompleteWebhookDelivery marks the delivery as delivered and clears the failure counter of its subscription.
+func (mySQL *MySQL) CompleteWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, completeWebhookDeliveryQuery, models.WebhookDeliveryDelivered, time.Now().UTC(), delivery.ID); err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if _, err := tx.ExecContext(ctx, resetWebhookSubscriptionFailuresQuery, delivery.SubscriptionID); err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	return tx.Commit()
+}
+
+const failWebhookDeliveryQuery = `
+	UPDATE webhook_deliveries
+	SET status = ?, attempts = attempts + 1, next_attempt_at = ?, last_error = ?, updated_at = ?
+	WHERE id = ?
+`
+
+const countWebhookSubscriptionFailureQuery = `
+	UPDATE webhook_subscriptions
+	SET active = CASE WHEN consecutive_failures + 1 >= ? THEN FALSE ELSE active END,
+	consecutive_failures = consecutive_failures + 1
+	WHERE id = UUID_TO_BIN(?)
+`
+
+// FailWebhookDelivery records a failed attempt. The delivery is retried at nextAttemptAt, or moved to the
+// dead letters if dead is set. The subscription is disabled once it reaches disableAfter consecutive failures.
+func (mySQL *MySQL) FailWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery, deliveryErr error, nextAttemptAt time.Time, dead bool, disableAfter int) error {
+	status := models.WebhookDeliveryPending
+	if dead {
+		status = models.WebhookDeliveryDead
+	}
+
+	lastError := deliveryErr.Error()
+	if len(lastError) > maxDeliveryErrorLength {
+		lastError = lastError[:maxDeliveryErrorLength]
+	}
+
+	tx, err := mySQL.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = tx.ExecContext(ctx, failWebhookDeliveryQuery, status, nextAttemptAt.UTC(), lastError, time.Now().UTC(), delivery.ID)
+	if err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	if _, err := tx.ExecContext(ctx, countWebhookSubscriptionFailureQuery, disableAfter, delivery.SubscriptionID); err != nil {
+		return rollbackWithErrorStack(tx, errors.WithStack(err))
+	}
+
+	return tx.Commit()
+}
+
+const getDeadWebhookDeliveriesQuery = `
+	SELECT id, BIN_TO_UUID(subscription_id), event_id, payload, status, attempts, next_attempt_at, COALESCE(last_error, ''), created_at
+	FROM webhook_deliveries
+	WHERE tenant_id = ? AND status = ?
+`
+
+// GetDeadWebhookDeliveries lists the dead letters of the tenant, optionally only the ones of a single subscription.
+func (mySQL *MySQL) GetDeadWebhookDeliveries(ctx context.Context, subscriptionID *uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := getDeadWebhookDeliveriesQuery
+	args := []interface{}{tenantID, models.WebhookDeliveryDead}
+	if subscriptionID != nil {
+		query += " AND subscription_id = UUID_TO_BIN(?)"
+		args = append(args, *subscriptionID)
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := mySQL.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := models.WebhookDelivery{}
+		var payload []byte
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventID,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, errors.WithStack(rows.Err())
+}
+
+const replayWebhookDeliveryQuery = `
+	UPDATE webhook_deliveries
+	SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ?
+	WHERE id = ? AND tenant_id = ? AND status = ?
+`
+
+// ReplayWebhookDelivery moves a dead letter back to the delivery queue.
+func (mySQL *MySQL) ReplayWebhookDelivery(ctx context.Context, id int64) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	result, err := mySQL.db.ExecContext(ctx, replayWebhookDeliveryQuery, models.WebhookDeliveryPending, now, now, id, tenantID, models.WebhookDeliveryDead)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if affected == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+
+	return nil
+}
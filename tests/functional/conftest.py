@@ -2,6 +2,7 @@ import requests
 import time
 import pytest
 import os
+import itertools
 
 
 def getPort():
@@ -15,9 +16,17 @@ def getPort():
         return variables["RESOURCE_DB_PORT"]
 
 
+# clientNumbers gives every connection a forwarded address of its own
+clientNumbers = itertools.count(1)
+
+
 class HTTPConnector():
     def __init__(self):
         self.URL = "http://127.0.0.1:" + getPort()
+        # the service trusts the test network as a proxy, so every connection
+        # is rate limited as a client of its own
+        n = next(clientNumbers)
+        self.clientIP = "198.18." + str(n // 256) + "." + str(n % 256)
         connected = False
         timeout = 30
         while timeout > 0:
@@ -34,13 +43,18 @@ class HTTPConnector():
         if connected is False:
             raise Exception("Cannot connect to test server")
 
-    def GET(self, address, params):
+    def GET(self, address, params, headers=None):
         url = self.URL + address
-        return requests.get(url=url, params=params)
+        return requests.get(
+            url=url, params=params, headers=self.forwarded(headers))
 
-    def POST(self, address, json):
+    def POST(self, address, json, headers=None):
         url = self.URL + address
-        return requests.post(url=url, json=json)
+        return requests.post(
+            url=url, json=json, headers=self.forwarded(headers))
+
+    def forwarded(self, headers):
+        return dict(headers or {}, **{"X-Forwarded-For": self.clientIP})
 
 
 @pytest.fixture
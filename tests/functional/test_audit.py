@@ -0,0 +1,36 @@
+import json
+
+
+def test_AuditEvents(httpConnection):
+    headers = {
+        "X-Tenant-ID": "audit-tenant",
+        "X-Owner-ID": "auditor",
+        "X-Request-ID": "audit-request-1",
+    }
+    r = httpConnection.GET("/get-categories", None, headers)
+    category = json.loads(r.text)["data"][0]["id"]
+
+    resource = {
+        "id": "8f6b5a0e-33c5-4d5e-8a53-7d3f0f2b9c11",
+        "category": category,
+        "content": {
+            "location": "auditLocation",
+        }
+    }
+    r = httpConnection.POST("/add-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    r = httpConnection.GET(
+        "/api/v1/audit",
+        {"resource_id": resource["id"], "actor": "auditor"},
+        headers)
+    events = json.loads(r.text)["data"]
+    assert len(events) == 1
+    assert events[0]["operation"] == "add"
+    assert events[0]["request_id"] == "audit-request-1"
+    assert events[0]["diff"]["before"] is None
+    assert events[0]["diff"]["after"]["id"] == resource["id"]
+
+    r = httpConnection.GET(
+        "/api/v1/audit", {"resource_id": resource["id"]}, None)
+    assert json.loads(r.text)["data"] == []
@@ -0,0 +1,73 @@
+import hashlib
+import json
+import requests
+
+
+def test_ResourceBlob(httpConnection):
+    headers = {"X-Tenant-ID": "blob-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    category = json.loads(r.text)["data"][0]["id"]
+
+    resource = {
+        "id": "5d7c1e2a-4b3f-4a9e-8c6d-2f1e0a9b8c7d",
+        "category": category,
+        "content": {
+            "location": "blobLocation",
+        }
+    }
+    r = httpConnection.POST("/add-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    path = "/api/v1/resources/" + resource["id"] + "/blob"
+    r = httpConnection.GET(path, None, headers)
+    assert r.status_code == 404, r.text
+
+    content = b"0123456789" * 100
+    r = requests.post(
+        url=httpConnection.URL + path,
+        data=content,
+        headers=dict(headers, **{"Content-Type": "text/plain"}))
+    assert r.status_code == 200, r.text
+    uploaded = json.loads(r.text)
+    assert uploaded["content"]["location"] == path
+    assert uploaded["blob"] == {
+        "size": len(content),
+        "content_type": "text/plain",
+        "sha256": hashlib.sha256(content).hexdigest(),
+    }
+
+    r = httpConnection.GET(path, None, headers)
+    assert r.status_code == 200
+    assert r.content == content
+    assert r.headers["Content-Type"] == "text/plain"
+    assert r.headers["ETag"] == '"' + hashlib.sha256(content).hexdigest() + '"'
+    assert r.headers["Vary"] == "X-Tenant-ID, X-Owner-ID"
+
+    r = httpConnection.GET(path, None, dict(headers, Range="bytes=10-19"))
+    assert r.status_code == 206
+    assert r.content == content[10:20]
+    assert r.headers["Content-Range"] == "bytes 10-19/" + str(len(content))
+
+    r = httpConnection.GET(
+        path, None, dict(headers, **{"If-None-Match": r.headers["ETag"]}))
+    assert r.status_code == 304
+
+    replacement = b"\x00\x01\x02binary"
+    r = requests.post(
+        url=httpConnection.URL + path,
+        files={"file": ("data.bin", replacement, "application/x-test")},
+        headers=headers)
+    assert r.status_code == 200, r.text
+    assert json.loads(r.text)["blob"]["content_type"] == "application/x-test"
+
+    r = httpConnection.GET(path, None, headers)
+    assert r.content == replacement
+
+    r = httpConnection.GET("/api/v1/resources/" + resource["id"] + "/", None, headers)
+    assert json.loads(r.text)["blob"]["size"] == len(replacement)
+
+    r = requests.post(
+        url=httpConnection.URL + "/api/v1/resources/0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d/blob",
+        data=content,
+        headers=headers)
+    assert r.status_code == 202, r.text
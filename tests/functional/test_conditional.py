@@ -0,0 +1,54 @@
+import json
+import time
+
+
+def test_ConditionalGet(httpConnection):
+    headers = {"X-Tenant-ID": "conditional-tenant"}
+    r = httpConnection.GET("/api/v1/categories/", None, headers)
+    assert r.status_code == 200, r.text
+    assert r.headers["Cache-Control"] == "private, no-cache"
+    # the caches must keep the responses of the tenants apart
+    assert r.headers["Vary"] == "X-Tenant-ID, X-Owner-ID"
+    category = json.loads(r.text)["data"][0]["id"]
+
+    r = httpConnection.GET(
+        "/api/v1/categories/", None,
+        dict(headers, **{"If-None-Match": r.headers["ETag"]}))
+    assert r.status_code == 304
+
+    resource = {
+        "id": "5f1d2c3b-4a59-4e6f-8a7b-9c0d1e2f3a4b",
+        "category": category,
+        "content": {
+            "location": "conditionalLocation",
+        }
+    }
+    r = httpConnection.POST("/add-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    path = "/api/v1/resources/" + resource["id"] + "/"
+    r = httpConnection.GET(path, None, headers)
+    assert r.status_code == 200, r.text
+    etag = r.headers["ETag"]
+    lastModified = r.headers["Last-Modified"]
+
+    r = httpConnection.GET(
+        path, None, dict(headers, **{"If-None-Match": etag}))
+    assert r.status_code == 304
+    assert r.text == ""
+
+    r = httpConnection.GET(
+        path, None, dict(headers, **{"If-Modified-Since": lastModified}))
+    assert r.status_code == 304
+
+    # Last-Modified has a precision of seconds
+    time.sleep(1)
+    resource["content"]["location"] = "conditionalLocationUpdated"
+    r = httpConnection.POST("/update-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    r = httpConnection.GET(
+        path, None, dict(headers, **{"If-None-Match": etag}))
+    assert r.status_code == 200, r.text
+    assert r.headers["ETag"] != etag
+    assert r.headers["Last-Modified"] != lastModified
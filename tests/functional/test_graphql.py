@@ -0,0 +1,52 @@
+import json
+
+
+def test_GraphQLResourceWithAttachments(httpConnection):
+    headers = {"X-Tenant-ID": "graphql-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    categories = json.loads(r.text)["data"]
+    newsFeed = [c for c in categories if c["name"] == "News feed"][0]["id"]
+
+    resource = {
+        "id": "3c1f6a44-1d9e-4b7c-9a3e-4c2f9b8d7e61",
+        "category": newsFeed,
+        "content": {
+            "location": "graphqlLocation",
+            "6d0c2b1a-8e4f-4a3b-b5c6-7d8e9f0a1b2c": "graphqlLocation/attachment.bin",
+        }
+    }
+    r = httpConnection.POST("/add-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    query = """
+    query($category: Int) {
+      resources(category: $category, first: 10) {
+        totalCount
+        edges {
+          node {
+            id
+            location
+            category { name }
+            attachments { id location }
+          }
+        }
+      }
+    }
+    """
+    r = httpConnection.POST(
+        "/graphql",
+        {"query": query, "variables": {"category": newsFeed}},
+        headers)
+    assert r.status_code == 200, r.text
+
+    response = json.loads(r.text)
+    assert "errors" not in response, r.text
+    connection = response["data"]["resources"]
+    assert connection["totalCount"] == 1
+    node = connection["edges"][0]["node"]
+    assert node["id"] == resource["id"]
+    assert node["category"]["name"] == "News feed"
+    assert node["attachments"] == [{
+        "id": "6d0c2b1a-8e4f-4a3b-b5c6-7d8e9f0a1b2c",
+        "location": "graphqlLocation/attachment.bin",
+    }]
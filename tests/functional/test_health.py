@@ -0,0 +1,14 @@
+import json
+
+
+def test_Readiness(httpConnection):
+    r = httpConnection.GET("/healthcheck", None)
+    assert r.status_code == 200, r.text
+
+    r = httpConnection.GET("/readyz", None)
+    assert r.status_code == 200, r.text
+    report = json.loads(r.text)["data"]
+    assert report["ready"] is True
+    assert report["draining"] is False
+    assert report["checks"]["database"] == "ok"
+    assert report["schema_version"] != ""
@@ -0,0 +1,53 @@
+import hashlib
+import json
+import requests
+import time
+
+
+def test_ResourceIntegrity(httpConnection):
+    headers = {"X-Tenant-ID": "integrity-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    category = json.loads(r.text)["data"][0]["id"]
+
+    content = b"verified content"
+    resource = {
+        "id": "3f6a9c2e-1b4d-4e7a-9c8b-5d2f0e1a3b4c",
+        "category": category,
+        "content": {
+            "location": "integrityLocation",
+        },
+        "checksum": hashlib.sha256(content).hexdigest().upper(),
+        "size": len(content),
+    }
+
+    invalid = dict(resource, id="7c1e3a5b-9d2f-4b6a-8e0c-1f3a5b7c9d2e")
+    invalid["checksum"] = "not a checksum"
+    r = httpConnection.POST("/add-resource", invalid, headers)
+    assert r.status_code == 400, r.text
+
+    r = httpConnection.POST("/add-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    path = "/api/v1/resources/" + resource["id"] + "/"
+    r = httpConnection.GET(path, None, headers)
+    stored = json.loads(r.text)
+    assert stored["checksum"] == resource["checksum"].lower()
+    assert stored["size"] == len(content)
+    assert "integrity" not in stored
+
+    r = requests.post(
+        url=httpConnection.URL + path + "blob",
+        data=content,
+        headers=dict(headers, **{"Content-Type": "text/plain"}))
+    assert r.status_code == 200, r.text
+
+    integrity = None
+    for _ in range(20):
+        r = httpConnection.GET(path, None, headers)
+        integrity = json.loads(r.text).get("integrity")
+        if integrity is not None:
+            break
+        time.sleep(0.5)
+
+    assert integrity is not None
+    assert integrity["status"] == "ok"
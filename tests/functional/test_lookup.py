@@ -0,0 +1,69 @@
+import json
+import uuid
+
+
+def test_LookupResources(httpConnection):
+    headers = {"X-Tenant-ID": "lookup-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    category = json.loads(r.text)["data"][0]["id"]
+
+    attachment = "5e0c7a1b-9d2f-4c3e-8a6b-1f2e3d4c5b6a"
+    ids = [
+        "2a6c3b0e-4d8f-4b5c-9a7e-9f0d1c2e3a4b",
+        "3b7d4c1f-5e9a-4c6d-8b8f-0a1e2d3f4b5c",
+    ]
+    for id in ids:
+        resource = {
+            "id": id,
+            "category": category,
+            "content": {"location": "lookupLocation"},
+        }
+        if id == ids[0]:
+            resource["content"][attachment] = "lookupLocation/attachment.bin"
+        r = httpConnection.POST("/add-resource", resource, headers)
+        assert r.status_code == 201, r.text
+
+    missing = "4c8e5d2a-6f0b-4d7e-9c9a-1b2f3e4a5c6d"
+    request = {"ids": [ids[1], missing, ids[0], ids[1]]}
+    r = httpConnection.POST("/api/v1/resources/lookup", request, headers)
+    assert r.status_code == 200, r.text
+    lookup = json.loads(r.text)["data"]
+    assert [r["id"] for r in lookup["resources"]] == [ids[1], ids[0]]
+    assert lookup["missing"] == [missing]
+    assert "attachments" not in lookup
+
+    request["include_attachments"] = True
+    r = httpConnection.POST("/api/v1/resources/lookup", request, headers)
+    assert r.status_code == 200, r.text
+    lookup = json.loads(r.text)["data"]
+    assert list(lookup["attachments"].keys()) == [attachment]
+    assert lookup["attachments"][attachment]["id"] == attachment
+
+    r = httpConnection.POST(
+        "/api/v1/resources/lookup", {"ids": [missing]}, headers)
+    assert r.status_code == 200, r.text
+    lookup = json.loads(r.text)["data"]
+    assert lookup["resources"] == []
+    assert lookup["missing"] == [missing]
+
+    r = httpConnection.POST("/api/v1/resources/lookup", {"ids": []}, headers)
+    assert r.status_code == 400, r.text
+
+    # a lookup returns 100 resources at most, the attachments included
+    tooMany = [ids[0]] + [missing] * 99
+    r = httpConnection.POST(
+        "/api/v1/resources/lookup", {"ids": tooMany + [missing]}, headers)
+    assert r.status_code == 400, r.text
+
+    r = httpConnection.POST(
+        "/api/v1/resources/lookup", {"ids": tooMany}, headers)
+    assert r.status_code == 200, r.text
+
+    tooMany = [ids[0]] + [str(uuid.uuid4()) for _ in range(99)]
+    r = httpConnection.POST(
+        "/api/v1/resources/lookup",
+        {"ids": tooMany, "include_attachments": True},
+        headers)
+    assert r.status_code == 400, r.text
+    assert json.loads(r.text)["error"].endswith(
+        "The lookup exceeds the maximum number of resources")
@@ -0,0 +1,11 @@
+import json
+
+
+def test_OpenAPIDocument(httpConnection):
+    r = httpConnection.GET("/api/v1/openapi.json", None)
+    assert r.status_code == 200, r.text
+
+    document = json.loads(r.text)
+    assert document["openapi"].startswith("3.")
+    assert "get" in document["paths"]["/api/v1/resources/{resource_id}/"]
+    assert "Resource" in document["components"]["schemas"]
@@ -0,0 +1,51 @@
+import json
+import uuid
+
+
+def test_RateLimit(httpConnection):
+    headers = {"X-Tenant-ID": "ratelimit-tenant"}
+
+    r = httpConnection.GET("/healthcheck", None, headers)
+    assert "RateLimit-Limit" not in r.headers
+
+    r = httpConnection.GET("/get-categories", None, headers)
+    assert r.status_code == 200, r.text
+    assert int(r.headers["RateLimit-Limit"]) > 0
+    assert int(r.headers["RateLimit-Remaining"]) >= 0
+    category = json.loads(r.text)["data"][0]["id"]
+
+    limited = None
+    for _ in range(100):
+        resource = {
+            "id": str(uuid.uuid4()),
+            "category": category,
+            "content": {
+                "location": "rateLimitLocation",
+            }
+        }
+        r = httpConnection.POST("/add-resource", resource, headers)
+        if r.status_code == 429:
+            limited = r
+            break
+        assert r.status_code == 201, r.text
+
+    assert limited is not None
+    assert int(limited.headers["Retry-After"]) >= 1
+    assert limited.headers["RateLimit-Remaining"] == "0"
+    assert json.loads(limited.text)["error"] == "The rate limit is exceeded"
+
+    # the reads have a bucket of their own
+    r = httpConnection.GET("/get-categories", None, headers)
+    assert r.status_code == 200, r.text
+
+
+def test_GetResourcesByIDsLimit(httpConnection):
+    headers = {"X-Tenant-ID": "ratelimit-tenant"}
+    ids = [str(uuid.uuid4()) for _ in range(101)]
+
+    r = httpConnection.GET("/api/v1/resources/", {"ids": ids}, headers)
+    assert r.status_code == 400, r.text
+
+    # none of them exist, but the request is accepted
+    r = httpConnection.GET("/api/v1/resources/", {"ids": ids[:100]}, headers)
+    assert r.status_code in (200, 202), r.text
@@ -0,0 +1,24 @@
+import json
+import uuid
+
+
+def test_RequestID(httpConnection):
+    headers = {"X-Tenant-ID": "requestid-tenant", "X-Request-ID": "functional-test-request"}
+
+    r = httpConnection.GET("/get-categories", None, headers)
+    assert r.status_code == 200, r.text
+    assert r.headers["X-Request-ID"] == "functional-test-request"
+    assert "request_id" not in json.loads(r.text)
+
+    r = httpConnection.GET("/get-categories", None, {"X-Tenant-ID": "requestid-tenant"})
+    generated = r.headers["X-Request-ID"]
+    assert str(uuid.UUID(generated)) == generated
+
+    r = httpConnection.GET(
+        "/get-categories", None, dict(headers, **{"X-Request-ID": "x" * 129}))
+    assert r.headers["X-Request-ID"] != "x" * 129
+
+    r = httpConnection.GET("/api/v1/no-such-route", None, headers)
+    assert r.status_code == 404, r.text
+    assert r.headers["X-Request-ID"] == "functional-test-request"
+    assert json.loads(r.text)["request_id"] == "functional-test-request"
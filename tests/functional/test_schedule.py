@@ -0,0 +1,70 @@
+import datetime
+import json
+import requests
+import time
+
+
+def isoformat(t):
+    return t.strftime("%Y-%m-%dT%H:%M:%S.%fZ")
+
+
+def listedIDs(httpConnection, path, params, headers):
+    r = httpConnection.GET(path, params, headers)
+    response = json.loads(r.text)
+    if response["error"] != "":
+        return []
+    return [resource["id"] for resource in response["data"]]
+
+
+def test_ScheduledResource(httpConnection):
+    headers = {"X-Tenant-ID": "schedule-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    categories = json.loads(r.text)["data"]
+    newsFeed = [c for c in categories if c["name"] == "News feed"][0]["id"]
+
+    now = datetime.datetime.utcnow()
+    resource = {
+        "id": "9b2e4c6a-8d1f-4e3b-a5c7-0f9e8d7c6b5a",
+        "category": newsFeed,
+        "content": {
+            "location": "scheduleLocation",
+        },
+        "publish_at": isoformat(now + datetime.timedelta(seconds=3)),
+        "expire_at": isoformat(now + datetime.timedelta(seconds=6)),
+    }
+
+    invalid = dict(resource, id="1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+    invalid["expire_at"] = invalid["publish_at"]
+    r = httpConnection.POST("/add-resource", invalid, headers)
+    assert r.status_code == 400, r.text
+
+    r = httpConnection.POST("/add-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    path = "/api/v1/resources/categories/" + str(newsFeed)
+    assert listedIDs(httpConnection, path, None, headers) == []
+    assert listedIDs(
+        httpConnection, path, {"include_hidden": "true"}, headers) == \
+        [resource["id"]]
+
+    stream = requests.get(
+        httpConnection.URL + "/api/v1/resources/stream",
+        params={"category": newsFeed},
+        headers=headers,
+        stream=True,
+        timeout=15)
+
+    events = []
+    for line in stream.iter_lines(decode_unicode=True):
+        if line.startswith("event: "):
+            events.append(line[len("event: "):])
+            if events[-1] == "resource.published":
+                assert listedIDs(httpConnection, path, None, headers) == \
+                    [resource["id"]]
+            if events[-1] == "resource.expired":
+                break
+    stream.close()
+
+    assert events == ["resource.published", "resource.expired"]
+    time.sleep(0.1)
+    assert listedIDs(httpConnection, path, None, headers) == []
@@ -0,0 +1,44 @@
+import json
+import requests
+import threading
+import time
+
+
+def test_ResourceStream(httpConnection):
+    headers = {"X-Tenant-ID": "stream-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    category = json.loads(r.text)["data"][0]["id"]
+
+    resource = {
+        "id": "c3a1d6a2-1f0b-4a43-9b0e-6f5d2f8e7a10",
+        "category": category,
+        "content": {
+            "location": "streamLocation",
+        }
+    }
+
+    def addResource():
+        time.sleep(1)
+        httpConnection.POST("/add-resource", resource, headers)
+
+    threading.Thread(target=addResource).start()
+
+    stream = requests.get(
+        httpConnection.URL + "/api/v1/resources/stream",
+        params={"category": category},
+        headers=headers,
+        stream=True,
+        timeout=10)
+    assert stream.headers["Content-Type"] == "text/event-stream"
+
+    event = {}
+    for line in stream.iter_lines(decode_unicode=True):
+        if line.startswith("event: "):
+            event["type"] = line[len("event: "):]
+        elif line.startswith("data: "):
+            event["data"] = json.loads(line[len("data: "):])
+            break
+    stream.close()
+
+    assert event["type"] == "resource.created"
+    assert event["data"]["id"] == resource["id"]
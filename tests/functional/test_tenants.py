@@ -0,0 +1,66 @@
+import json
+
+
+def getCategoryID(httpConnection, name, headers):
+    r = httpConnection.GET("/get-categories", None, headers)
+    for category in json.loads(r.text)["data"]:
+        if category["name"] == name:
+            return category["id"]
+    return None
+
+
+def test_TenantIsolation(httpConnection):
+    ownerHeaders = {"X-Tenant-ID": "tenant-a", "X-Owner-ID": "user-a"}
+    resource = {
+        "id": "5d0f4c3e-6f0e-4b57-9d0c-2a4c36a1e9f1",
+        "category": getCategoryID(httpConnection, "News feed", ownerHeaders),
+        "content": {
+            "location": "tenantLocation",
+        }
+    }
+
+    r = httpConnection.POST("/add-resource", resource, ownerHeaders)
+    assert r.status_code == 201, r.text
+
+    r = httpConnection.GET(
+        "/get-resource-by-id", {"id": resource["id"]}, ownerHeaders)
+    response = json.loads(r.text)
+    assert response["error"] == ""
+    assert response["data"]["owner_id"] == "user-a"
+
+    otherHeaders = {"X-Tenant-ID": "tenant-b"}
+    r = httpConnection.GET(
+        "/get-resource-by-id", {"id": resource["id"]}, otherHeaders)
+    response = json.loads(r.text)
+    assert response["error"] == "The selected resource not found"
+
+    r = httpConnection.POST("/delete-resource", resource, otherHeaders)
+    response = json.loads(r.text)
+    assert response["error"] == "The selected resource not found"
+
+    # the ids are unique within a tenant, the same id is free for another one
+    otherResource = dict(resource, category=getCategoryID(httpConnection, "News feed", otherHeaders))
+    r = httpConnection.POST("/add-resource", otherResource, otherHeaders)
+    assert r.status_code == 201, r.text
+
+    r = httpConnection.GET(
+        "/get-resource-by-id", {"id": resource["id"]}, ownerHeaders)
+    response = json.loads(r.text)
+    assert response["data"]["owner_id"] == "user-a"
+
+
+def test_UnprovisionedTenant(httpConnection):
+    headers = {"X-Tenant-ID": "unprovisioned-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    assert r.status_code == 403, r.text
+    assert json.loads(r.text)["error"] == "The tenant is not provisioned"
+
+    resource = {
+        "id": "0b6f6d0e-8a55-4c55-9a0f-3c0d0b7c6f10",
+        "category": 1,
+        "content": {
+            "location": "unprovisionedLocation",
+        }
+    }
+    r = httpConnection.POST("/add-resource", resource, headers)
+    assert r.status_code == 403, r.text
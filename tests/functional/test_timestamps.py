@@ -0,0 +1,63 @@
+import json
+import time
+
+
+def test_ResourceTimestamps(httpConnection):
+    headers = {"X-Tenant-ID": "timestamps-tenant"}
+    r = httpConnection.GET("/get-categories", None, headers)
+    category = json.loads(r.text)["data"][0]["id"]
+
+    ids = [
+        "0c4a1f8e-2b6d-4f3a-9e5c-7d8b9a0c1e2f",
+        "1d5b2a9f-3c7e-4a4b-8f6d-8e9c0b1d2f3a",
+    ]
+    for id in ids:
+        resource = {
+            "id": id,
+            "category": category,
+            "content": {"location": "timestampsLocation"},
+        }
+        r = httpConnection.POST("/add-resource", resource, headers)
+        assert r.status_code == 201, r.text
+        time.sleep(0.01)
+
+    path = "/api/v1/resources/categories/" + str(category)
+    r = httpConnection.GET(path, None, headers)
+    resources = json.loads(r.text)["data"]
+    assert [r["id"] for r in resources] == list(reversed(ids))
+    first = resources[1]
+    assert first["created_at"] == first["updated_at"]
+
+    r = httpConnection.GET(path, {"sort": "created_at"}, headers)
+    assert [r["id"] for r in json.loads(r.text)["data"]] == ids
+
+    time.sleep(0.01)
+    resource = {
+        "id": ids[0],
+        "category": category,
+        "content": {"location": "timestampsLocationUpdated"},
+    }
+    r = httpConnection.POST("/update-resource", resource, headers)
+    assert r.status_code == 201, r.text
+
+    r = httpConnection.GET(path, {"sort": "-updated_at"}, headers)
+    resources = json.loads(r.text)["data"]
+    assert [r["id"] for r in resources] == ids
+    updated = resources[0]
+    assert updated["created_at"] == first["created_at"]
+    assert updated["updated_at"] != updated["created_at"]
+
+    r = httpConnection.GET(
+        path,
+        {"sort": "updated_at", "since": updated["updated_at"]},
+        headers)
+    assert [r["id"] for r in json.loads(r.text)["data"]] == [ids[0]]
+
+    r = httpConnection.GET(
+        "/api/v1/resources/",
+        {"ids": ids, "until": first["created_at"]},
+        headers)
+    assert json.loads(r.text)["error"] == "The selected resource not found"
+
+    r = httpConnection.GET(path, {"sort": "id"}, headers)
+    assert r.status_code == 400
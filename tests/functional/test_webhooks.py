@@ -0,0 +1,46 @@
+import json
+import requests
+
+
+def test_WebhookSubscriptions(httpConnection):
+    headers = {"X-Tenant-ID": "webhook-tenant"}
+    subscription = {
+        "url": "http://203.0.113.10:9/callback",
+        "categories": [1],
+        "event_types": ["resource.created"],
+        "secret": "0123456789abcdef0123",
+    }
+    r = httpConnection.POST("/api/v1/webhooks/", subscription, headers)
+    assert r.status_code == 201, r.text
+    created = json.loads(r.text)["data"]
+    assert created["active"] is True
+    assert "secret" not in created
+
+    r = httpConnection.GET("/api/v1/webhooks/", None, headers)
+    ids = [s["id"] for s in json.loads(r.text)["data"]]
+    assert created["id"] in ids
+
+    r = httpConnection.GET("/api/v1/webhooks/", None, {"X-Tenant-ID": "other"})
+    assert json.loads(r.text)["data"] == []
+
+    url = httpConnection.URL + "/api/v1/webhooks/" + created["id"] + "/"
+    update = dict(subscription, id="00000000-0000-0000-0000-000000000000", url="https://203.0.113.11/callback")
+    r = requests.put(url, json=update, headers=headers)
+    assert r.status_code == 200, r.text
+    updated = json.loads(r.text)["data"]
+    assert updated["id"] == created["id"]
+    assert updated["url"] == "https://203.0.113.11/callback"
+
+    r = requests.delete(url, headers=headers)
+    assert r.status_code == 200
+    r = requests.delete(url, headers=headers)
+    assert r.status_code == 404
+
+
+def test_WebhookPrivateTargets(httpConnection):
+    headers = {"X-Tenant-ID": "webhook-tenant"}
+    for target in ["http://127.0.0.1:9/callback", "http://169.254.169.254/latest/meta-data", "http://10.0.0.1/callback",
+                   "http://localhost/callback", "http://[::1]/callback"]:
+        subscription = {"url": target, "secret": "0123456789abcdef0123"}
+        r = httpConnection.POST("/api/v1/webhooks/", subscription, headers)
+        assert r.status_code == 400, target + ": " + r.text
@@ -0,0 +1,11 @@
+#!/bin/bash
+# Provisions the tenants of the functional tests in the running resources-db-server container,
+# once the service has migrated the database. The requests of the tenants not provisioned are refused.
+source .env
+until curl -sf http://127.0.0.1:$RESOURCE_DB_PORT/healthcheck > /dev/null; do
+  sleep 1
+done
+
+docker exec resources-db-server ./main tenants add \
+  audit-tenant blob-tenant conditional-tenant graphql-tenant integrity-tenant lookup-tenant other \
+  ratelimit-tenant requestid-tenant schedule-tenant stream-tenant tenant-a tenant-b timestamps-tenant webhook-tenant
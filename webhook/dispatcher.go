@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+const (
+	HeaderDeliveryID = "X-Webhook-Delivery"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// DispatcherConfig holds the delivery and retry policy of the Dispatcher.
+type DispatcherConfig struct {
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+	DisableAfter int
+	// AllowPrivateTargets lets the deliveries connect to loopback, link-local and private addresses, for development.
+	AllowPrivateTargets bool
+}
+
+// Dispatcher sends the queued webhook deliveries.
+// A failed delivery is retried with exponential backoff and becomes a dead letter after the last attempt.
+type Dispatcher struct {
+	storage Storage
+	client  *http.Client
+	cfg     DispatcherConfig
+}
+
+func NewDispatcher(storage Storage, cfg DispatcherConfig) *Dispatcher {
+	return &Dispatcher{
+		storage: storage,
+		client:  newClient(cfg.Timeout, cfg.AllowPrivateTargets),
+		cfg:     cfg,
+	}
+}
+
+// Sign returns the signature of a delivery, the hex encoded HMAC-SHA256 of "<timestamp>.<body>".
+// Subscribers verify it with the secret they registered and reject stale timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run sends the deliveries that are due.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	// the deliveries are sent one after the other, the lease has to outlive the whole batch
+	lease := time.Duration(d.cfg.BatchSize) * d.cfg.Timeout
+
+	deliveries, err := d.storage.ClaimWebhookDeliveries(ctx, d.cfg.BatchSize, lease)
+	if err != nil {
+		return errors.Wrap(err, "cannot claim webhook deliveries")
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		delivery := &deliveries[i]
+		if sendErr := d.send(ctx, delivery); sendErr != nil {
+			attempts := delivery.Attempts + 1
+			dead := attempts >= d.cfg.MaxAttempts
+			log.Warn(ctx, "Webhook delivery failed", "delivery_id", delivery.ID, "attempts", attempts, "dead", dead, "error", sendErr)
+
+			if err := d.storage.FailWebhookDelivery(ctx, delivery, sendErr, time.Now().Add(d.backoff(attempts)), dead, d.cfg.DisableAfter); err != nil {
+				return errors.Wrap(err, "cannot record failed webhook delivery")
+			}
+			continue
+		}
+
+		if err := d.storage.CompleteWebhookDelivery(ctx, delivery); err != nil {
+			return errors.Wrap(err, "cannot record webhook delivery")
+		}
+	}
+
+	return nil
+}
+
+// backoff returns the wait before the next attempt, doubling with every failed attempt up to the maximum.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.cfg.BackoffBase
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.cfg.BackoffMax {
+			return d.cfg.BackoffMax
+		}
+	}
+	return wait
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) error {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/artofimagination/mysql-resources-db-go-service/auth"
+	"github.com/artofimagination/mysql-resources-db-go-service/models"
+)
+
+// Storage is the part of the storage the webhook subscriptions are kept in.
+type Storage interface {
+	GetWebhookSubscriptions(ctx context.Context, activeOnly bool) ([]models.WebhookSubscription, error)
+	AddWebhookDeliveries(ctx context.Context, event *models.ChangeEvent, subscriptions []models.WebhookSubscription) error
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	CompleteWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	FailWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery, deliveryErr error, nextAttemptAt time.Time, dead bool, disableAfter int) error
+}
+
+// SubscriptionSink is the outbox sink that queues every change event for the matching subscriptions of its tenant.
+// The deliveries themselves are sent by the Dispatcher, so a slow subscriber never holds back the outbox.
+type SubscriptionSink struct {
+	storage Storage
+}
+
+func NewSubscriptionSink(storage Storage) *SubscriptionSink {
+	return &SubscriptionSink{
+		storage: storage,
+	}
+}
+
+func (s *SubscriptionSink) Name() string {
+	return "webhook subscriptions"
+}
+
+func (s *SubscriptionSink) Publish(ctx context.Context, event models.ChangeEvent) error {
+	ctx = auth.WithIdentity(ctx, auth.Identity{TenantID: event.TenantID})
+
+	subscriptions, err := s.storage.GetWebhookSubscriptions(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	matching := make([]models.WebhookSubscription, 0, len(subscriptions))
+	for i := range subscriptions {
+		if subscriptions[i].Matches(&event) {
+			matching = append(matching, subscriptions[i])
+		}
+	}
+
+	if len(matching) == 0 {
+		return nil
+	}
+
+	return s.storage.AddWebhookDeliveries(ctx, &event, matching)
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrTargetNotAllowed = errors.New("The webhook URL has to be an http or https URL of a public address")
+
+// reservedNetworks are the networks not reached over the internet that the methods of net.IP do not cover.
+var reservedNetworks = parseNetworks(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, with the broadcast address
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// publicIP reports whether ip is a public unicast address, the loopback, link-local and private ones are not.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckTarget rejects the webhook URL unless it is an http or https URL whose host resolves to public addresses only.
+// The addresses are checked again when the deliveries connect, the host may resolve differently by then.
+func CheckTarget(ctx context.Context, resolver *net.Resolver, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return ErrTargetNotAllowed
+	}
+
+	if ip := net.ParseIP(target.Hostname()); ip != nil {
+		if !publicIP(ip) {
+			return ErrTargetNotAllowed
+		}
+		return nil
+	}
+
+	addresses, err := resolver.LookupIPAddr(ctx, target.Hostname())
+	if err != nil {
+		return errors.Wrap(ErrTargetNotAllowed, err.Error())
+	}
+	for _, address := range addresses {
+		if !publicIP(address.IP) {
+			return ErrTargetNotAllowed
+		}
+	}
+	return nil
+}
+
+// controlTarget refuses the connections to addresses that are not public, it is the Control of the dialer of the deliveries.
+func controlTarget(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return errors.Wrap(ErrTargetNotAllowed, "cannot connect to "+address)
+	}
+	return nil
+}
+
+// newClient returns the client sending the deliveries. Unless the private targets are allowed, its dialer checks every address
+// it connects to, which covers the hosts resolving to another address since the check of the subscription and the redirects.
+// It does not use the proxy of the environment, the address it connects to has to be the one of the subscriber for the check.
+func newClient(timeout time.Duration, allowPrivateTargets bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !allowPrivateTargets {
+		dialer.Control = controlTarget
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"203.0.113.10":    true,
+		"8.8.8.8":         true,
+		"2001:4860::8888": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"0.0.0.0":         false,
+		"100.64.0.1":      false,
+		"224.0.0.1":       false,
+		"::ffff:10.0.0.1": false,
+	}
+	for address, want := range tests {
+		if got := publicIP(net.ParseIP(address)); got != want {
+			t.Errorf("publicIP(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	tests := map[string]bool{
+		"https://203.0.113.10/callback":     true,
+		"http://203.0.113.10:8080/callback": true,
+		"http://127.0.0.1:9/callback":       false,
+		"http://[::1]/callback":             false,
+		"http://169.254.169.254/latest":     false,
+		"http://localhost/callback":         false,
+		"ftp://203.0.113.10/callback":       false,
+		"file:///etc/passwd":                false,
+	}
+	for target, allowed := range tests {
+		err := CheckTarget(context.Background(), net.DefaultResolver, target)
+		if allowed && err != nil {
+			t.Errorf("CheckTarget(%s) = %v, want nil", target, err)
+		}
+		if !allowed && errors.Cause(err) != ErrTargetNotAllowed {
+			t.Errorf("CheckTarget(%s) = %v, want %v", target, err, ErrTargetNotAllowed)
+		}
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	_, err := newClient(time.Second, false).Get(server.URL)
+	if !errors.Is(err, ErrTargetNotAllowed) {
+		t.Fatalf("the delivery to %s got %v, want %v", server.URL, err, ErrTargetNotAllowed)
+	}
+
+	resp, err := newClient(time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+}
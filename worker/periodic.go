@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/proemergotech/log/v3"
+)
+
+// Periodic runs a job at a fixed interval in the background until it is stopped or its context is cancelled.
+// A failing run is logged and retried on the next tick, it never stops the worker.
+// A panicking run stops the worker, the panic is reported as its error.
+type Periodic struct {
+	name     string
+	interval time.Duration
+	job      func(ctx context.Context) error
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPeriodic(name string, interval time.Duration, job func(ctx context.Context) error) *Periodic {
+	return &Periodic{
+		name:     name,
+		interval: interval,
+		job:      job,
+	}
+}
+
+func (p *Periodic) Start(ctx context.Context, errorCh chan<- error) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				errorCh <- errors.Errorf("%s panicked: %v", p.name, r)
+			}
+		}()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := p.job(ctx); err != nil && ctx.Err() == nil {
+				err = errors.Wrap(err, p.name+" run failed")
+				log.Error(ctx, err.Error(), "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (p *Periodic) Stop(timeout time.Duration) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return errors.New(p.name + " did not stop in time")
+	}
+}